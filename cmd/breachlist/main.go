@@ -0,0 +1,78 @@
+// Command breachlist builds the gzipped password lists internal/breach reads.
+//
+// It reads one entry per line from stdin: plain passwords by default, or
+// SHA-1 hashes with -sha1, which also accepts the "HASH:COUNT" lines of a
+// Pwned Passwords download. With -min-count, hashes seen fewer times are
+// skipped.
+//
+// The embedded list is rebuilt from internal/breach/common-passwords.txt by
+// go generate ./internal/breach. For BREACHED_PASSWORDS_FILE, build a larger
+// list from a Pwned Passwords download, e.g.
+//
+//	go run ./cmd/breachlist -sha1 -min-count 100 < pwned-passwords-sha1.txt > breached.txt.gz
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"crypto/sha1"
+	"encoding/hex"
+	"flag"
+	"log"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+func main() {
+	hashed := flag.Bool("sha1", false, "input lines are SHA-1 hex hashes, optionally followed by :COUNT")
+	minCount := flag.Int("min-count", 0, "with -sha1, skip hashes with a smaller COUNT")
+	flag.Parse()
+
+	var hashes []string
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+
+		if !*hashed {
+			sum := sha1.Sum([]byte(line))
+			hashes = append(hashes, strings.ToUpper(hex.EncodeToString(sum[:])))
+			continue
+		}
+
+		hash, count, _ := strings.Cut(line, ":")
+		if n, err := strconv.Atoi(count); *minCount > 0 && (err != nil || n < *minCount) {
+			continue
+		}
+		if len(hash) != sha1.Size*2 {
+			log.Fatalf("Invalid SHA-1 hash %q", hash)
+		}
+		hashes = append(hashes, strings.ToUpper(hash))
+	}
+	if err := scanner.Err(); err != nil {
+		log.Fatalf("Failed to read input: %v", err)
+	}
+
+	slices.Sort(hashes)
+	hashes = slices.Compact(hashes)
+
+	zw, err := gzip.NewWriterLevel(os.Stdout, gzip.BestCompression)
+	if err != nil {
+		log.Fatal(err)
+	}
+	w := bufio.NewWriter(zw)
+	for _, h := range hashes {
+		w.WriteString(h + "\n")
+	}
+	if err := w.Flush(); err != nil {
+		log.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("Wrote %d hashes", len(hashes))
+}
@@ -0,0 +1,86 @@
+// Command jwtkey creates token signing keys for JWT_KEYS_DIR.
+//
+// It writes a new PKCS#8 private key to stdout, optionally scheduled with a
+// Not-Before header. With -public it instead reads a private key from stdin
+// and writes its public key, to keep verifying tokens of a retired key.
+//
+//	go run ./cmd/jwtkey -not-before 2026-11-01T00:00:00Z > keys/2026-11.pem
+//	go run ./cmd/jwtkey -public < keys/2026-10.pem > 2026-10.pub && mv 2026-10.pub keys/2026-10.pem
+package main
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"flag"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"github.com/dvprokofiev/seating-generator-api/internal/signing"
+)
+
+func main() {
+	alg := flag.String("alg", "EdDSA", "signing algorithm: EdDSA or RS256")
+	bits := flag.Int("bits", 3072, "RSA key size for RS256")
+	notBefore := flag.String("not-before", "", "RFC 3339 time the key starts signing")
+	public := flag.Bool("public", false, "read a private key from stdin and write its public key")
+	flag.Parse()
+
+	if *public {
+		writePublic()
+		return
+	}
+
+	var key crypto.Signer
+	var err error
+	switch *alg {
+	case "EdDSA":
+		_, key, err = ed25519.GenerateKey(rand.Reader)
+	case "RS256":
+		key, err = rsa.GenerateKey(rand.Reader, *bits)
+	default:
+		log.Fatalf("Unknown algorithm %q, expected EdDSA or RS256", *alg)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		log.Fatal(err)
+	}
+	block := &pem.Block{Type: "PRIVATE KEY", Bytes: der}
+	if *notBefore != "" {
+		if _, err := time.Parse(time.RFC3339, *notBefore); err != nil {
+			log.Fatalf("Invalid -not-before: %v", err)
+		}
+		block.Headers = map[string]string{signing.NotBeforeHeader: *notBefore}
+	}
+	if err := pem.Encode(os.Stdout, block); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func writePublic() {
+	data, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		log.Fatalf("Failed to read input: %v", err)
+	}
+	key, err := signing.ParseKey("", data)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	der, err := x509.MarshalPKIXPublicKey(key.Public)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := pem.Encode(os.Stdout, &pem.Block{Type: "PUBLIC KEY", Bytes: der}); err != nil {
+		log.Fatal(err)
+	}
+}
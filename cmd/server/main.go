@@ -1,18 +1,33 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
+	"math"
 	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
 	"github.com/joho/godotenv"
+	"golang.org/x/crypto/bcrypt"
 
+	"github.com/dvprokofiev/seating-generator-api/internal/breach"
 	"github.com/dvprokofiev/seating-generator-api/internal/database"
 	"github.com/dvprokofiev/seating-generator-api/internal/handler"
+	"github.com/dvprokofiev/seating-generator-api/internal/mailer"
+	"github.com/dvprokofiev/seating-generator-api/internal/ratelimit"
 	"github.com/dvprokofiev/seating-generator-api/internal/repository"
 	"github.com/dvprokofiev/seating-generator-api/internal/service"
+	"github.com/dvprokofiev/seating-generator-api/internal/signing"
 )
 
 func main() {
@@ -34,18 +49,121 @@ func main() {
 	defer db.Close()
 
 	repos := repository.NewRepository(db)
-	authService := service.NewAuthService(repos.Users, os.Getenv("JWT_SECRET"))
+	policy := service.NewRolePolicy()
+	auditService := service.NewAuditService(repos.Audit, policy)
+	auditHandler := handler.NewAuditHandler(auditService)
+	var mail mailer.Mailer = mailer.LogMailer{}
+	if smtpHost := os.Getenv("SMTP_HOST"); smtpHost != "" {
+		mail = mailer.NewSMTPMailer(smtpHost, os.Getenv("SMTP_PORT"),
+			os.Getenv("SMTP_USER"), os.Getenv("SMTP_PASSWORD"), os.Getenv("MAIL_FROM"))
+	}
+
+	mfaIssuer := os.Getenv("MFA_ISSUER")
+	if mfaIssuer == "" {
+		mfaIssuer = "Seating Generator"
+	}
+
+	authOpts := []service.AuthOption{
+		service.WithPasswordHasher(passwordHasherFromEnv()),
+		service.WithPasswordPolicy(passwordPolicyFromEnv()),
+		service.WithAuditLog(auditService),
+		service.WithPolicy(policy),
+		service.WithMailer(mail),
+		service.WithLoginProtection(service.DefaultLoginProtection(ratelimit.NewMemoryStore(), repos.LoginFailures)),
+		service.WithMFA(repos.MFA, mfaIssuer),
+		service.WithAPIKeys(repos.APIKeys),
+		service.WithSessions(repos.Sessions),
+	}
+	if keys := tokenKeysFromEnv(); keys != nil {
+		authOpts = append(authOpts, service.WithTokenKeys(keys))
+	}
+	if wa := webAuthnFromEnv(mfaIssuer); wa != nil {
+		authOpts = append(authOpts, service.WithPasskeys(repos.Passkeys, wa))
+	}
+	if linkURL := os.Getenv("MAGIC_LINK_URL"); linkURL != "" {
+		authOpts = append(authOpts, service.WithMagicLinks(repos.MagicLinks, linkURL))
+	}
+	if resetURL := os.Getenv("PASSWORD_RESET_URL"); resetURL != "" {
+		authOpts = append(authOpts, service.WithPasswordResets(repos.PasswordResets, resetURL))
+	}
+
+	var orgOpts []service.OrganizationOption
+	if redirectURL := os.Getenv("OIDC_REDIRECT_URL"); redirectURL != "" {
+		authOpts = append(authOpts, service.WithOIDC(repos.OIDC, redirectURL))
+		orgOpts = append(orgOpts, service.WithOIDCProviders(repos.OIDC))
+		if os.Getenv("OIDC_LOOPBACK_ISSUERS") == "on" {
+			// local development only
+			orgOpts = append(orgOpts, service.WithLoopbackOIDCIssuers())
+		}
+	}
+
+	authService := service.NewAuthService(repos.Users, os.Getenv("JWT_SECRET"), authOpts...)
 	authHandler := handler.NewAuthHandler(authService)
+	orgService := service.NewOrganizationService(repos.Organizations, repos.Users, policy, auditService, orgOpts...)
+	orgHandler := handler.NewOrganizationHandler(orgService)
 
 	r := chi.NewRouter()
 
 	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
+	r.Use(handler.RequestMeta)
+
+	r.Get("/.well-known/jwks.json", authHandler.JWKS)
 
 	r.Route("/api/v1", func(r chi.Router) {
 		r.Route("/auth", func(r chi.Router) {
 			r.Post("/login", authHandler.Login)
 			r.Post("/register", authHandler.Register)
+			r.Post("/mfa/verify", authHandler.VerifyMFA)
+			r.Post("/mfa/enroll", authHandler.EnrollMFA)
+			r.Post("/mfa/enroll/confirm", authHandler.ConfirmMFA)
+			r.Post("/passkey/login/begin", authHandler.BeginPasskeyLogin)
+			r.Post("/passkey/login/finish", authHandler.FinishPasskeyLogin)
+			r.Post("/oidc/login/begin", authHandler.BeginOIDCLogin)
+			r.Post("/oidc/login/finish", authHandler.FinishOIDCLogin)
+			r.Post("/magic-link", authHandler.RequestMagicLink)
+			r.Post("/magic-link/login", authHandler.FinishMagicLinkLogin)
+			r.Post("/password/reset", authHandler.ResetPassword)
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(authHandler.Authenticate)
+
+			r.Post("/me/mfa/enroll", authHandler.EnrollMFA)
+			r.Post("/me/mfa/enroll/confirm", authHandler.ConfirmMFA)
+			r.Get("/me/passkeys", authHandler.ListPasskeys)
+			r.Post("/me/passkeys/register/begin", authHandler.BeginPasskeyRegistration)
+			r.Post("/me/passkeys/register/finish", authHandler.FinishPasskeyRegistration)
+			r.Delete("/me/passkeys/{passkeyID}", authHandler.DeletePasskey)
+			r.Get("/me/api-keys", authHandler.ListAPIKeys)
+			r.Post("/me/api-keys", authHandler.CreateAPIKey)
+			r.Delete("/me/api-keys/{keyID}", authHandler.RevokeAPIKey)
+			r.Get("/me/sessions", authHandler.ListSessions)
+			r.Delete("/me/sessions/{sessionID}", authHandler.RevokeSession)
+			r.Get("/me/invitations", orgHandler.ListInvitations)
+			r.Post("/me/invitations/{invitationID}/accept", orgHandler.AcceptInvitation)
+
+			r.Get("/admin/audit-log", auditHandler.ListAll)
+			r.Route("/admin/users", func(r chi.Router) {
+				r.Get("/", authHandler.SearchUsers)
+				r.Put("/{userID}/disabled", authHandler.SetUserDisabled)
+				r.Post("/{userID}/password-reset", authHandler.ForcePasswordReset)
+				r.Post("/{userID}/verify", authHandler.SetUserVerified)
+				r.Post("/{userID}/impersonate", authHandler.ImpersonateUser)
+			})
+
+			r.Route("/organizations", func(r chi.Router) {
+				r.Post("/", orgHandler.Create)
+				r.Get("/{orgID}", orgHandler.Get)
+				r.Get("/{orgID}/members", orgHandler.ListMembers)
+				r.Post("/{orgID}/invitations", orgHandler.InviteMember)
+				r.Delete("/{orgID}/members/{userID}", orgHandler.RemoveMember)
+				r.Get("/{orgID}/audit-log", auditHandler.List)
+				r.Put("/{orgID}/mfa", orgHandler.SetMFARequirement)
+				r.Get("/{orgID}/oidc", orgHandler.GetOIDCProvider)
+				r.Put("/{orgID}/oidc", orgHandler.SetOIDCProvider)
+				r.Delete("/{orgID}/oidc", orgHandler.DeleteOIDCProvider)
+			})
 		})
 	})
 
@@ -54,8 +172,146 @@ func main() {
 		port = "8080"
 	}
 
-	log.Printf("Server starting on port %s...", port)
-	if err := http.ListenAndServe(":"+port, r); err != nil {
-		log.Fatalf("Server failed: %v", err)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	srv := &http.Server{Addr: ":" + port, Handler: r}
+	go func() {
+		log.Printf("Server starting on port %s...", port)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Server failed: %v", err)
+		}
+	}()
+
+	// on SIGINT or SIGTERM, finish the requests in flight and send the
+	// sign-in links already queued before exiting
+	<-ctx.Done()
+	log.Println("Server shutting down...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Server shutdown failed: %v", err)
+	}
+	if err := authService.Close(shutdownCtx); err != nil {
+		log.Printf("Failed to send queued sign-in links: %v", err)
+	}
+}
+
+// passwordHasherFromEnv picks the hasher for new passwords. PASSWORD_HASHER
+// is "bcrypt" (default) or "argon2id"; hashes of the other algorithm are
+// still accepted and upgraded on the next login. Costs outside what the
+// algorithms accept stop the server here rather than failing the first
+// login.
+func passwordHasherFromEnv() service.PasswordHasher {
+	cost := envIntBetween("BCRYPT_COST", bcrypt.DefaultCost, bcrypt.MinCost, bcrypt.MaxCost)
+	bcryptHasher := service.NewBcryptHasher(cost)
+
+	params := service.DefaultArgon2idParams
+	params.Memory = uint32(envIntBetween("ARGON2_MEMORY_KIB", int(params.Memory), service.MinArgon2idMemory, math.MaxUint32))
+	params.Iterations = uint32(envIntBetween("ARGON2_ITERATIONS", int(params.Iterations), 1, math.MaxUint32))
+	params.Parallelism = uint8(envIntBetween("ARGON2_PARALLELISM", int(params.Parallelism), 1, math.MaxUint8))
+	argon2idHasher := service.NewArgon2idHasher(params)
+
+	switch os.Getenv("PASSWORD_HASHER") {
+	case "argon2id":
+		return service.NewRehashingHasher(argon2idHasher, bcryptHasher)
+	case "", "bcrypt":
+		return service.NewRehashingHasher(bcryptHasher, argon2idHasher)
+	default:
+		log.Fatalf("Unknown PASSWORD_HASHER %q, expected bcrypt or argon2id", os.Getenv("PASSWORD_HASHER"))
+		return nil
+	}
+}
+
+// passwordPolicyFromEnv checks new passwords against the small embedded
+// breach list unless BREACHED_PASSWORDS_FILE names a larger one built with
+// cmd/breachlist, or PASSWORD_BREACH_CHECK is off.
+func passwordPolicyFromEnv() service.PasswordPolicy {
+	p := service.DefaultPasswordPolicy()
+	p.MinLength = envInt("PASSWORD_MIN_LENGTH", p.MinLength)
+	if os.Getenv("PASSWORD_BREACH_CHECK") == "off" {
+		return p
+	}
+
+	source := breach.Embedded()
+	if path := os.Getenv("BREACHED_PASSWORDS_FILE"); path != "" {
+		var err error
+		if source, err = breach.FromFile(path); err != nil {
+			log.Fatal(err)
+		}
+	}
+	p.Breached = breach.NewChecker(source)
+	return p
+}
+
+// tokenKeysFromEnv returns nil, leaving tokens signed with JWT_SECRET, unless
+// JWT_KEYS_DIR is set. The directory is re-read every JWT_KEYS_RELOAD
+// (default 5m), so scheduled keys and removals take effect without a
+// restart.
+func tokenKeysFromEnv() *signing.Keyring {
+	dir := os.Getenv("JWT_KEYS_DIR")
+	if dir == "" {
+		return nil
+	}
+
+	keys, err := signing.NewKeyring(dir)
+	if err != nil {
+		log.Fatalf("Failed to load signing keys: %v", err)
+	}
+	if _, err := keys.SigningKey(); err != nil {
+		log.Fatalf("Failed to load signing keys from %s: %v", dir, err)
+	}
+
+	interval := 5 * time.Minute
+	if v := os.Getenv("JWT_KEYS_RELOAD"); v != "" {
+		if interval, err = time.ParseDuration(v); err != nil || interval <= 0 {
+			log.Fatalf("Invalid JWT_KEYS_RELOAD %q", v)
+		}
+	}
+	go keys.Watch(context.Background(), interval)
+	return keys
+}
+
+// webAuthnFromEnv returns nil, leaving passkeys disabled, unless
+// WEBAUTHN_RP_ID is set.
+func webAuthnFromEnv(displayName string) *webauthn.WebAuthn {
+	rpID := os.Getenv("WEBAUTHN_RP_ID")
+	if rpID == "" {
+		return nil
+	}
+
+	wa, err := webauthn.New(&webauthn.Config{
+		RPID:          rpID,
+		RPDisplayName: displayName,
+		RPOrigins:     strings.Split(os.Getenv("WEBAUTHN_RP_ORIGINS"), ","),
+		// passkey logins skip TOTP, so the authenticator must verify the user
+		AuthenticatorSelection: protocol.AuthenticatorSelection{
+			UserVerification: protocol.VerificationRequired,
+		},
+	})
+	if err != nil {
+		log.Fatalf("Invalid WebAuthn configuration: %v", err)
+	}
+	return wa
+}
+
+func envInt(name string, fallback int) int {
+	v := os.Getenv(name)
+	if v == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		log.Fatalf("Invalid %s: %v", name, err)
+	}
+	return n
+}
+
+// envIntBetween is envInt for settings that must lie within [low, high].
+func envIntBetween(name string, fallback, low, high int) int {
+	n := envInt(name, fallback)
+	if n < low || n > high {
+		log.Fatalf("Invalid %s: %d is not between %d and %d", name, n, low, high)
 	}
+	return n
 }
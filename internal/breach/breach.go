@@ -0,0 +1,132 @@
+package breach
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha1"
+	_ "embed"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// passwords.txt.gz holds the SHA-1 hashes of the passwords in
+// common-passwords.txt, one uppercase hex hash per line. That file is a
+// short hand-collected list of the passwords most often found in public
+// breach compilations, plus school-themed and Russian-language variants
+// our users pick. It only catches the most obvious choices; deployments
+// that want a real list point BREACHED_PASSWORDS_FILE at one built with
+// cmd/breachlist from a Pwned Passwords download.
+//
+//go:generate sh -c "go run ../../cmd/breachlist < common-passwords.txt > passwords.txt.gz"
+//go:embed passwords.txt.gz
+var embeddedList []byte
+
+const prefixLength = 5
+
+// Source looks up breached password hashes the way the Pwned Passwords range
+// API does: given the first five hex characters of a SHA-1 hash it returns
+// the remaining 35 of every breached hash with that prefix, so the full hash
+// of the password being checked never leaves the caller.
+type Source interface {
+	Range(ctx context.Context, prefix string) ([]string, error)
+}
+
+type Checker struct {
+	source Source
+}
+
+func NewChecker(source Source) *Checker {
+	return &Checker{source: source}
+}
+
+func (c *Checker) IsBreached(ctx context.Context, password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:prefixLength], hash[prefixLength:]
+
+	suffixes, err := c.source.Range(ctx, prefix)
+	if err != nil {
+		return false, err
+	}
+	_, found := slices.BinarySearch(suffixes, suffix)
+	return found, nil
+}
+
+type embeddedSource struct {
+	load   sync.Once
+	ranges map[string][]string
+	err    error
+}
+
+var embedded = &embeddedSource{}
+
+// Embedded returns the list compiled into the binary. It is decompressed on
+// first use.
+func Embedded() Source {
+	return embedded
+}
+
+func (s *embeddedSource) Range(_ context.Context, prefix string) ([]string, error) {
+	s.load.Do(func() {
+		s.ranges, s.err = parseList(embeddedList)
+	})
+	if s.err != nil {
+		return nil, s.err
+	}
+	return s.ranges[strings.ToUpper(prefix)], nil
+}
+
+// FromFile loads a gzipped list in the format cmd/breachlist writes. The
+// whole list is kept in memory.
+func FromFile(path string) (Source, error) {
+	compressed, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read breached password list: %w", err)
+	}
+	ranges, err := parseList(compressed)
+	if err != nil {
+		return nil, err
+	}
+	return &fileSource{ranges: ranges}, nil
+}
+
+type fileSource struct {
+	ranges map[string][]string
+}
+
+func (s *fileSource) Range(_ context.Context, prefix string) ([]string, error) {
+	return s.ranges[strings.ToUpper(prefix)], nil
+}
+
+func parseList(compressed []byte) (map[string][]string, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to open breached password list: %w", err)
+	}
+	defer zr.Close()
+
+	ranges := make(map[string][]string)
+	scanner := bufio.NewScanner(zr)
+	for scanner.Scan() {
+		hash := strings.ToUpper(strings.TrimSpace(scanner.Text()))
+		if len(hash) != sha1.Size*2 {
+			continue
+		}
+		prefix := hash[:prefixLength]
+		ranges[prefix] = append(ranges[prefix], hash[prefixLength:])
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("Failed to read breached password list: %w", err)
+	}
+
+	for _, suffixes := range ranges {
+		slices.Sort(suffixes)
+	}
+	return ranges, nil
+}
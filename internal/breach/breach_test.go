@@ -0,0 +1,88 @@
+package breach
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type staticSource struct {
+	prefixes []string
+	ranges   map[string][]string
+	err      error
+}
+
+func (s *staticSource) Range(_ context.Context, prefix string) ([]string, error) {
+	s.prefixes = append(s.prefixes, prefix)
+	return s.ranges[prefix], s.err
+}
+
+func TestChecker_IsBreached(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("embedded_list", func(t *testing.T) {
+		c := NewChecker(Embedded())
+
+		for _, pw := range []string{"password123", "qwerty123", "1q2w3e4r"} {
+			breached, err := c.IsBreached(ctx, pw)
+			require.NoError(t, err)
+			assert.True(t, breached, pw)
+		}
+
+		breached, err := c.IsBreached(ctx, "correct horse battery staple 57")
+		require.NoError(t, err)
+		assert.False(t, breached)
+	})
+
+	t.Run("only_prefix_is_sent_to_source", func(t *testing.T) {
+		// SHA-1("password") = 5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8
+		src := &staticSource{ranges: map[string][]string{
+			"5BAA6": {"1E4C9B93F3F0682250B6CF8331B7EE68FD8"},
+		}}
+		c := NewChecker(src)
+
+		breached, err := c.IsBreached(ctx, "password")
+
+		require.NoError(t, err)
+		assert.True(t, breached)
+		assert.Equal(t, []string{"5BAA6"}, src.prefixes)
+	})
+
+	t.Run("list_from_file", func(t *testing.T) {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		zw.Write([]byte("5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8\n"))
+		require.NoError(t, zw.Close())
+		path := filepath.Join(t.TempDir(), "breached.txt.gz")
+		require.NoError(t, os.WriteFile(path, buf.Bytes(), 0o600))
+
+		src, err := FromFile(path)
+		require.NoError(t, err)
+		c := NewChecker(src)
+
+		breached, err := c.IsBreached(ctx, "password")
+		require.NoError(t, err)
+		assert.True(t, breached)
+		breached, err = c.IsBreached(ctx, "password123")
+		require.NoError(t, err)
+		assert.False(t, breached)
+
+		_, err = FromFile(filepath.Join(t.TempDir(), "missing.txt.gz"))
+		assert.Error(t, err)
+	})
+
+	t.Run("source_error", func(t *testing.T) {
+		c := NewChecker(&staticSource{err: errors.New("unavailable")})
+
+		_, err := c.IsBreached(ctx, "password")
+
+		assert.Error(t, err)
+	})
+}
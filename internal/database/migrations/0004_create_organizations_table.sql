@@ -0,0 +1,37 @@
+-- +goose Up
+CREATE TABLE organizations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid (),
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE users
+    ADD COLUMN organization_id UUID REFERENCES organizations (id) ON DELETE SET NULL,
+    ADD COLUMN role VARCHAR(32) NOT NULL DEFAULT 'teacher';
+
+CREATE INDEX idx_users_organization_id ON users (organization_id);
+
+CREATE TABLE organization_invitations (
+    id UUID PRIMARY KEY,
+    organization_id UUID NOT NULL REFERENCES organizations (id) ON DELETE CASCADE,
+    email VARCHAR(255) NOT NULL,
+    role VARCHAR(32) NOT NULL,
+    invited_by UUID REFERENCES users (id) ON DELETE SET NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT unique_organization_invitation UNIQUE (organization_id, email)
+);
+
+CREATE INDEX idx_organization_invitations_email ON organization_invitations (email);
+
+-- +goose Down
+DROP TABLE organization_invitations;
+
+DROP INDEX idx_users_organization_id;
+
+ALTER TABLE users
+    DROP COLUMN role,
+    DROP COLUMN organization_id;
+
+DROP TABLE organizations;
\ No newline at end of file
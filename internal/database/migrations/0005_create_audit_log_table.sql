@@ -0,0 +1,38 @@
+-- +goose Up
+CREATE TABLE audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    occurred_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    event VARCHAR(64) NOT NULL,
+    success BOOLEAN NOT NULL,
+    -- no foreign keys: entries must outlive the users and organizations they mention
+    actor_id UUID,
+    organization_id UUID,
+    target_type VARCHAR(64) NOT NULL DEFAULT '',
+    target_id VARCHAR(255) NOT NULL DEFAULT '',
+    email VARCHAR(255) NOT NULL DEFAULT '',
+    ip_address VARCHAR(45) NOT NULL DEFAULT '',
+    user_agent TEXT NOT NULL DEFAULT '',
+    details JSONB
+);
+
+CREATE INDEX idx_audit_log_organization_id ON audit_log (organization_id, id);
+CREATE INDEX idx_audit_log_actor_id ON audit_log (actor_id, id);
+-- operators look up events of accounts without an organization, including
+-- login attempts for unknown emails, by email
+CREATE INDEX idx_audit_log_email ON audit_log (email, id);
+
+-- +goose StatementBegin
+CREATE FUNCTION audit_log_reject_change() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER audit_log_append_only
+    BEFORE UPDATE OR DELETE ON audit_log
+    FOR EACH ROW EXECUTE FUNCTION audit_log_reject_change();
+
+-- +goose Down
+DROP TABLE audit_log;
+DROP FUNCTION audit_log_reject_change();
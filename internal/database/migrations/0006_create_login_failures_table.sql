@@ -0,0 +1,15 @@
+-- +goose Up
+-- Failed logins are counted per email, whether or not an account exists,
+-- so a lockout does not reveal which emails are registered.
+CREATE TABLE login_failures (
+    email VARCHAR(255) PRIMARY KEY,
+    failed_attempts INTEGER NOT NULL DEFAULT 0,
+    last_failed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    locked_until TIMESTAMP WITH TIME ZONE
+);
+
+-- every failed login drops the rows whose failures are forgotten
+CREATE INDEX idx_login_failures_last_failed_at ON login_failures (last_failed_at);
+
+-- +goose Down
+DROP TABLE login_failures;
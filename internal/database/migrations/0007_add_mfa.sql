@@ -0,0 +1,27 @@
+-- +goose Up
+ALTER TABLE users
+    ADD COLUMN mfa_secret TEXT,
+    ADD COLUMN mfa_enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN mfa_last_step BIGINT NOT NULL DEFAULT 0;
+
+ALTER TABLE organizations
+    ADD COLUMN require_mfa BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE mfa_recovery_codes (
+    id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    code_hash VARCHAR(64) NOT NULL,
+    used_at TIMESTAMP WITH TIME ZONE,
+    UNIQUE (user_id, code_hash)
+);
+
+-- +goose Down
+DROP TABLE mfa_recovery_codes;
+
+ALTER TABLE organizations
+    DROP COLUMN require_mfa;
+
+ALTER TABLE users
+    DROP COLUMN mfa_last_step,
+    DROP COLUMN mfa_enabled,
+    DROP COLUMN mfa_secret;
@@ -0,0 +1,27 @@
+-- +goose Up
+CREATE TABLE passkeys (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid (),
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    credential_id BYTEA NOT NULL UNIQUE,
+    name VARCHAR(64) NOT NULL DEFAULT '',
+    credential JSONB NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_used_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_passkeys_user_id ON passkeys (user_id);
+
+CREATE TABLE webauthn_ceremonies (
+    id UUID PRIMARY KEY,
+    user_id UUID REFERENCES users (id) ON DELETE CASCADE,
+    kind VARCHAR(16) NOT NULL,
+    session JSONB NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX idx_webauthn_ceremonies_expires_at ON webauthn_ceremonies (expires_at);
+
+-- +goose Down
+DROP TABLE webauthn_ceremonies;
+
+DROP TABLE passkeys;
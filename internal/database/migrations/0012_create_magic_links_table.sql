@@ -0,0 +1,16 @@
+-- +goose Up
+CREATE TABLE magic_links (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid (),
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    token_hash VARCHAR(64) NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT unique_user_magic_link UNIQUE (user_id)
+);
+
+CREATE UNIQUE INDEX idx_magic_links_token_hash ON magic_links (token_hash);
+CREATE INDEX idx_magic_links_expires_at ON magic_links (expires_at);
+
+-- +goose Down
+DROP TABLE magic_links;
@@ -0,0 +1,29 @@
+-- +goose Up
+-- Operators run the service and may manage any account. There is no API to
+-- appoint them; set is_operator directly for the first one.
+ALTER TABLE users
+    ADD COLUMN is_operator BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN disabled_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN password_reset_required BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Links emailed when an operator forces a password reset.
+CREATE TABLE password_resets (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid (),
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    token_hash VARCHAR(64) NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT unique_user_password_reset UNIQUE (user_id)
+);
+
+CREATE UNIQUE INDEX idx_password_resets_token_hash ON password_resets (token_hash);
+CREATE INDEX idx_password_resets_expires_at ON password_resets (expires_at);
+
+-- +goose Down
+DROP TABLE password_resets;
+
+ALTER TABLE users
+    DROP COLUMN password_reset_required,
+    DROP COLUMN disabled_at,
+    DROP COLUMN is_operator;
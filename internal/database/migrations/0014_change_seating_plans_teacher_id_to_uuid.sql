@@ -0,0 +1,14 @@
+-- +goose Up
+-- 0003 declared teacher_id as INTEGER, which cannot reference the UUID
+-- users.id. No code stores plans yet, so the column is recreated rather
+-- than converted.
+ALTER TABLE seating_plans DROP COLUMN teacher_id;
+
+ALTER TABLE seating_plans
+    ADD COLUMN teacher_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE;
+
+-- +goose Down
+ALTER TABLE seating_plans DROP COLUMN teacher_id;
+
+ALTER TABLE seating_plans
+    ADD COLUMN teacher_id INTEGER NOT NULL;
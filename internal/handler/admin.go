@@ -0,0 +1,144 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/dvprokofiev/seating-generator-api/internal/models"
+	"github.com/dvprokofiev/seating-generator-api/internal/service"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// userListResponse is a page of users. NextCursor is passed as "after" to
+// get the next page; it is empty on the last page.
+type userListResponse struct {
+	Users      []models.User `json:"users"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+}
+
+type userDisabledRequest struct {
+	Disabled *bool `json:"disabled" validate:"required"`
+}
+
+func (h *AuthHandler) SearchUsers(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := models.UserFilter{
+		EmailPrefix: q.Get("email"),
+		After:       q.Get("after"),
+		Limit:       50,
+	}
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > 200 {
+			sendError(w, http.StatusBadRequest, "Invalid limit")
+			return
+		}
+		filter.Limit = limit
+	}
+
+	users, err := h.authService.SearchUsers(r.Context(), filter)
+	if err != nil {
+		sendAdminError(w, err)
+		return
+	}
+
+	resp := userListResponse{Users: users}
+	if len(users) > 0 && len(users) == filter.Limit {
+		resp.NextCursor = users[len(users)-1].Email
+	}
+	sendJSON(w, http.StatusOK, resp)
+}
+
+func (h *AuthHandler) SetUserDisabled(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "userID"))
+	if err != nil {
+		sendError(w, http.StatusBadRequest, "Invalid user id")
+		return
+	}
+
+	var req userDisabledRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		sendError(w, http.StatusBadRequest, "Validation failed "+err.Error())
+		return
+	}
+
+	user, err := h.authService.SetUserDisabled(r.Context(), id, *req.Disabled)
+	if err != nil {
+		sendAdminError(w, err)
+		return
+	}
+
+	sendJSON(w, http.StatusOK, user)
+}
+
+func (h *AuthHandler) ForcePasswordReset(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "userID"))
+	if err != nil {
+		sendError(w, http.StatusBadRequest, "Invalid user id")
+		return
+	}
+
+	if err := h.authService.ForcePasswordReset(r.Context(), id); err != nil {
+		sendAdminError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *AuthHandler) SetUserVerified(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "userID"))
+	if err != nil {
+		sendError(w, http.StatusBadRequest, "Invalid user id")
+		return
+	}
+
+	if err := h.authService.SetUserVerified(r.Context(), id); err != nil {
+		sendAdminError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *AuthHandler) ImpersonateUser(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "userID"))
+	if err != nil {
+		sendError(w, http.StatusBadRequest, "Invalid user id")
+		return
+	}
+
+	impersonation, err := h.authService.ImpersonateUser(r.Context(), id)
+	if err != nil {
+		sendAdminError(w, err)
+		return
+	}
+
+	sendJSON(w, http.StatusOK, impersonation)
+}
+
+func sendAdminError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrUnauthenticated):
+		sendError(w, http.StatusUnauthorized, err.Error())
+	case errors.Is(err, service.ErrForbidden), errors.Is(err, service.ErrCannotImpersonate):
+		sendError(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, service.ErrCannotDisableSelf), errors.Is(err, service.ErrAccountDisabled),
+		errors.Is(err, service.ErrPasswordResetRequired), errors.Is(err, service.ErrEmailNotVerified):
+		sendError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, service.ErrUserNotFound), errors.Is(err, service.ErrPasswordResetsNotConfigured):
+		sendError(w, http.StatusNotFound, err.Error())
+	default:
+		log.Printf("Admin error: %v", err)
+		sendError(w, http.StatusInternalServerError, "Internal server error")
+	}
+}
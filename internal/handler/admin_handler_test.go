@@ -0,0 +1,169 @@
+package handler
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dvprokofiev/seating-generator-api/internal/mailer"
+	"github.com/dvprokofiev/seating-generator-api/internal/models"
+	"github.com/dvprokofiev/seating-generator-api/internal/repository"
+	"github.com/dvprokofiev/seating-generator-api/internal/service"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestAuthHandler_Admin_WithMockRepo(t *testing.T) {
+	userRepo := repository.NewMockUserRepository(t)
+	resetRepo := repository.NewMockPasswordResetRepository(t)
+	mail := &linkMailer{sent: make(chan mailer.Message, 1)}
+	authHandler := NewAuthHandler(service.NewAuthService(userRepo, "super-secret", service.WithMailer(mail),
+		service.WithPasswordResets(resetRepo, "https://seating.test/password/reset")))
+
+	r := chi.NewRouter()
+	r.Post("/auth/login", authHandler.Login)
+	r.Post("/auth/password/reset", authHandler.ResetPassword)
+	r.Group(func(r chi.Router) {
+		r.Use(authHandler.Authenticate)
+		r.Get("/admin/users", authHandler.SearchUsers)
+		r.Put("/admin/users/{userID}/disabled", authHandler.SetUserDisabled)
+		r.Post("/admin/users/{userID}/password-reset", authHandler.ForcePasswordReset)
+		r.Post("/admin/users/{userID}/verify", authHandler.SetUserVerified)
+		r.Post("/admin/users/{userID}/impersonate", authHandler.ImpersonateUser)
+	})
+
+	operator := &models.User{ID: uuid.New(), Email: "ops@test.ru", IsOperator: true}
+	teacher := &models.User{ID: uuid.New(), Email: "teacher@test.ru", Role: models.RoleTeacher}
+	operatorToken := loginAs(t, authHandler, userRepo, operator)
+	teacherToken := loginAs(t, authHandler, userRepo, teacher)
+
+	send := func(method, path, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr
+	}
+	userPath := "/admin/users/" + teacher.ID.String()
+
+	t.Run("search_200_with_cursor", func(t *testing.T) {
+		userRepo.On("Search", mock.Anything, models.UserFilter{EmailPrefix: "teacher", After: "a@test.ru", Limit: 1}).
+			Return([]models.User{*teacher}, nil).Once()
+
+		rr := send(http.MethodGet, "/admin/users?email=Teacher&after=a@test.ru&limit=1", operatorToken, "")
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var resp userListResponse
+		json.Unmarshal(rr.Body.Bytes(), &resp)
+		require.Len(t, resp.Users, 1)
+		assert.Equal(t, teacher.Email, resp.NextCursor)
+		assert.NotContains(t, rr.Body.String(), "is_operator")
+	})
+
+	t.Run("invalid_limit_400", func(t *testing.T) {
+		rr := send(http.MethodGet, "/admin/users?limit=1000", operatorToken, "")
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("teacher_403", func(t *testing.T) {
+		rr := send(http.MethodGet, "/admin/users", teacherToken, "")
+
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+	})
+
+	t.Run("unknown_user_404", func(t *testing.T) {
+		id := uuid.New()
+		userRepo.On("GetByID", mock.Anything, id).Return(nil, sql.ErrNoRows).Once()
+
+		rr := send(http.MethodPost, "/admin/users/"+id.String()+"/verify", operatorToken, "")
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("verify_204", func(t *testing.T) {
+		userRepo.On("UpdateVerified", mock.Anything, teacher.ID, true).Return(nil).Once()
+
+		rr := send(http.MethodPost, userPath+"/verify", operatorToken, "")
+
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+	})
+
+	t.Run("impersonate_200", func(t *testing.T) {
+		rr := send(http.MethodPost, userPath+"/impersonate", operatorToken, "")
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var resp service.Impersonation
+		json.Unmarshal(rr.Body.Bytes(), &resp)
+		assert.NotEmpty(t, resp.Token)
+		assert.Equal(t, 3600, resp.ExpiresIn)
+	})
+
+	t.Run("disable_200_then_teacher_403", func(t *testing.T) {
+		userRepo.On("SetDisabled", mock.Anything, teacher.ID, mock.Anything).Run(func(args mock.Arguments) {
+			teacher.DisabledAt = args.Get(2).(*time.Time)
+		}).Return(nil).Once()
+
+		rr := send(http.MethodPut, userPath+"/disabled", operatorToken, `{"disabled":true}`)
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		rr = send(http.MethodGet, "/admin/users", teacherToken, "")
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+		assert.Contains(t, rr.Body.String(), "Account is disabled")
+
+		teacher.DisabledAt = nil
+	})
+
+	t.Run("disable_missing_flag_400", func(t *testing.T) {
+		rr := send(http.MethodPut, userPath+"/disabled", operatorToken, `{}`)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("forced_reset_emails_link_and_blocks_login", func(t *testing.T) {
+		hash, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.DefaultCost)
+		teacher.PasswordHash = string(hash)
+		teacher.IsVerified = true
+		var saved *models.PasswordReset
+		userRepo.On("SetPasswordResetRequired", mock.Anything, teacher.ID, true).Run(func(mock.Arguments) {
+			teacher.PasswordResetRequired = true
+		}).Return(nil).Once()
+		resetRepo.On("Save", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			saved = args.Get(1).(*models.PasswordReset)
+		}).Return(nil).Once()
+
+		rr := send(http.MethodPost, userPath+"/password-reset", operatorToken, "")
+		require.Equal(t, http.StatusNoContent, rr.Code)
+		msg := mail.next(t)
+		assert.Equal(t, teacher.Email, msg.To)
+		_, rest, ok := strings.Cut(msg.Body, "?token=")
+		require.True(t, ok)
+		token, _, _ := strings.Cut(rest, "\n")
+
+		userRepo.On("GetByEmail", mock.Anything, teacher.Email).Return(teacher, nil).Once()
+		rr = send(http.MethodPost, "/auth/login", "", `{"email":"teacher@test.ru","password":"password123"}`)
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+		rr = send(http.MethodGet, "/admin/users", teacherToken, "")
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+
+		resetRepo.On("Get", mock.Anything, saved.TokenHash).Return(saved, nil).Once()
+		resetRepo.On("Take", mock.Anything, saved.TokenHash).Return(saved, nil).Once()
+		userRepo.On("UpdatePassword", mock.Anything, teacher.ID, mock.Anything).Return(nil).Once()
+		userRepo.On("SetPasswordResetRequired", mock.Anything, teacher.ID, false).Return(nil).Once()
+		rr = send(http.MethodPost, "/auth/password/reset", "",
+			`{"token":"`+token+`","password":"a fresh passphrase"}`)
+
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+	})
+}
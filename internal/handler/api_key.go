@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/dvprokofiev/seating-generator-api/internal/models"
+	"github.com/dvprokofiev/seating-generator-api/internal/service"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type createAPIKeyRequest struct {
+	Name      string               `json:"name" validate:"required,max=64"`
+	Scopes    []models.APIKeyScope `json:"scopes" validate:"required,min=1,dive,oneof=plans:read plans:write organization:read"`
+	ExpiresAt *time.Time           `json:"expires_at"`
+}
+
+func (h *AuthHandler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	var req createAPIKeyRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		sendError(w, http.StatusBadRequest, "Validation failed "+err.Error())
+		return
+	}
+
+	key, err := h.authService.CreateAPIKey(r.Context(), req.Name, req.Scopes, req.ExpiresAt)
+	if err != nil {
+		sendAPIKeyError(w, err)
+		return
+	}
+
+	sendJSON(w, http.StatusCreated, key)
+}
+
+func (h *AuthHandler) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := h.authService.ListAPIKeys(r.Context())
+	if err != nil {
+		sendAPIKeyError(w, err)
+		return
+	}
+
+	sendJSON(w, http.StatusOK, keys)
+}
+
+func (h *AuthHandler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "keyID"))
+	if err != nil {
+		sendError(w, http.StatusBadRequest, "Invalid API key id")
+		return
+	}
+
+	if err := h.authService.RevokeAPIKey(r.Context(), id); err != nil {
+		sendAPIKeyError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func sendAPIKeyError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrUnauthenticated):
+		sendError(w, http.StatusUnauthorized, err.Error())
+	case errors.Is(err, service.ErrForbidden):
+		sendError(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, service.ErrInvalidAPIKeyName), errors.Is(err, service.ErrInvalidAPIKeyScope),
+		errors.Is(err, service.ErrInvalidAPIKeyExpiry):
+		sendError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, service.ErrTooManyAPIKeys):
+		sendError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, service.ErrAPIKeyNotFound), errors.Is(err, service.ErrAPIKeysNotConfigured):
+		sendError(w, http.StatusNotFound, err.Error())
+	default:
+		log.Printf("API key error: %v", err)
+		sendError(w, http.StatusInternalServerError, "Internal server error")
+	}
+}
@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dvprokofiev/seating-generator-api/internal/models"
+	"github.com/dvprokofiev/seating-generator-api/internal/repository"
+	"github.com/dvprokofiev/seating-generator-api/internal/service"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthHandler_APIKeys_WithMockRepo(t *testing.T) {
+	userRepo := repository.NewMockUserRepository(t)
+	apiKeyRepo := repository.NewMockAPIKeyRepository(t)
+	authHandler := NewAuthHandler(service.NewAuthService(userRepo, "super-secret", service.WithAPIKeys(apiKeyRepo)))
+
+	r := chi.NewRouter()
+	r.Group(func(r chi.Router) {
+		r.Use(authHandler.Authenticate)
+		r.Get("/me/api-keys", authHandler.ListAPIKeys)
+		r.Post("/me/api-keys", authHandler.CreateAPIKey)
+	})
+
+	orgID := uuid.New()
+	user := &models.User{ID: uuid.New(), Email: "it@test.ru", OrganizationID: &orgID, Role: models.RoleTeacher}
+	token := loginAs(t, authHandler, userRepo, user)
+
+	var stored *models.APIKey
+	apiKeyRepo.On("ListByUser", mock.Anything, user.ID).Return([]models.APIKey{}, nil).Once()
+	apiKeyRepo.On("Create", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		stored = args.Get(1).(*models.APIKey)
+	}).Return(nil).Once()
+
+	send := func(method, auth, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/me/api-keys", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+auth)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := send(http.MethodPost, token, `{"name":"Export","scopes":["plans:read"]}`)
+	require.Equal(t, http.StatusCreated, rr.Code)
+	var created service.CreatedAPIKey
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &created))
+	require.NotEmpty(t, created.Key)
+	assert.NotContains(t, rr.Body.String(), "secret_hash")
+
+	t.Run("unknown_scope_400", func(t *testing.T) {
+		rr := send(http.MethodPost, token, `{"name":"Export","scopes":["admin"]}`)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("api_key_authenticates_but_cannot_manage_keys_403", func(t *testing.T) {
+		apiKeyRepo.On("GetByPrefix", mock.Anything, created.Prefix).Return(func(context.Context, string) (*models.APIKey, error) {
+			return stored, nil
+		}).Once()
+		apiKeyRepo.On("UpdateLastUsed", mock.Anything, stored.ID, mock.Anything).Return(nil).Once()
+
+		rr := send(http.MethodGet, created.Key, "")
+
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+	})
+
+	t.Run("unknown_api_key_401", func(t *testing.T) {
+		apiKeyRepo.On("GetByPrefix", mock.Anything, "abcdefghij").Return(nil, sql.ErrNoRows).Once()
+
+		rr := send(http.MethodGet, "sg_abcdefghij_secret", "")
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+		assert.Contains(t, rr.Body.String(), "API key")
+	})
+}
@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dvprokofiev/seating-generator-api/internal/models"
+	"github.com/dvprokofiev/seating-generator-api/internal/service"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type AuditHandler struct {
+	auditService service.AuditService
+}
+
+func NewAuditHandler(s service.AuditService) *AuditHandler {
+	return &AuditHandler{auditService: s}
+}
+
+type auditListResponse struct {
+	Events     []models.AuditEvent `json:"events"`
+	NextCursor int64               `json:"next_cursor,omitempty"`
+}
+
+// List serves an organization's audit log, newest first. Supported query
+// parameters: actor_id, event, from and to (RFC 3339), limit, and before,
+// the next_cursor value of the previous page.
+func (h *AuditHandler) List(w http.ResponseWriter, r *http.Request) {
+	orgID, err := uuid.Parse(chi.URLParam(r, "orgID"))
+	if err != nil {
+		sendError(w, http.StatusBadRequest, "Invalid organization id")
+		return
+	}
+
+	filter, err := parseAuditFilter(r)
+	if err != nil {
+		sendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	filter.OrganizationID = &orgID
+
+	events, err := h.auditService.List(r.Context(), filter)
+	h.sendEvents(w, filter, events, err)
+}
+
+// ListAll serves the whole audit log to operators. It takes the query
+// parameters of List, plus organization_id and email.
+func (h *AuditHandler) ListAll(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseAuditFilter(r)
+	if err != nil {
+		sendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if v := r.URL.Query().Get("organization_id"); v != "" {
+		orgID, err := uuid.Parse(v)
+		if err != nil {
+			sendError(w, http.StatusBadRequest, "Invalid organization_id")
+			return
+		}
+		filter.OrganizationID = &orgID
+	}
+	filter.Email = r.URL.Query().Get("email")
+
+	events, err := h.auditService.ListAll(r.Context(), filter)
+	h.sendEvents(w, filter, events, err)
+}
+
+func (h *AuditHandler) sendEvents(w http.ResponseWriter, filter models.AuditFilter, events []models.AuditEvent, err error) {
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrUnauthenticated):
+			sendError(w, http.StatusUnauthorized, err.Error())
+		case errors.Is(err, service.ErrForbidden):
+			sendError(w, http.StatusForbidden, err.Error())
+		default:
+			log.Printf("Audit log error: %v", err)
+			sendError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return
+	}
+
+	resp := auditListResponse{Events: events}
+	if len(events) > 0 && len(events) == filter.Limit {
+		resp.NextCursor = events[len(events)-1].ID
+	}
+	sendJSON(w, http.StatusOK, resp)
+}
+
+func parseAuditFilter(r *http.Request) (models.AuditFilter, error) {
+	q := r.URL.Query()
+	filter := models.AuditFilter{
+		Event: models.AuditEventType(q.Get("event")),
+		Limit: 50,
+	}
+
+	if v := q.Get("actor_id"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			return filter, errors.New("Invalid actor_id")
+		}
+		filter.ActorID = &id
+	}
+	if v := q.Get("from"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return filter, errors.New("Invalid from, expected RFC 3339 time")
+		}
+		filter.From = &t
+	}
+	if v := q.Get("to"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return filter, errors.New("Invalid to, expected RFC 3339 time")
+		}
+		filter.To = &t
+	}
+	if v := q.Get("before"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || id <= 0 {
+			return filter, errors.New("Invalid before cursor")
+		}
+		filter.BeforeID = id
+	}
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > 200 {
+			return filter, errors.New("Invalid limit, expected 1 to 200")
+		}
+		filter.Limit = n
+	}
+	return filter, nil
+}
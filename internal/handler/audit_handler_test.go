@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dvprokofiev/seating-generator-api/internal/models"
+	"github.com/dvprokofiev/seating-generator-api/internal/repository"
+	"github.com/dvprokofiev/seating-generator-api/internal/service"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAuditHandler_WithMockRepo(t *testing.T) {
+	userRepo := repository.NewMockUserRepository(t)
+	auditRepo := repository.NewMockAuditRepository(t)
+
+	authHandler := NewAuthHandler(service.NewAuthService(userRepo, "super-secret"))
+	h := NewAuditHandler(service.NewAuditService(auditRepo, service.NewRolePolicy()))
+
+	r := chi.NewRouter()
+	r.Group(func(r chi.Router) {
+		r.Use(authHandler.Authenticate)
+		r.Get("/organizations/{orgID}/audit-log", h.List)
+		r.Get("/admin/audit-log", h.ListAll)
+	})
+
+	orgID := uuid.New()
+	adminToken := loginAs(t, authHandler, userRepo, &models.User{
+		ID: uuid.New(), Email: "admin@test.ru", OrganizationID: &orgID, Role: models.RoleSchoolAdmin,
+	})
+	teacherToken := loginAs(t, authHandler, userRepo, &models.User{
+		ID: uuid.New(), Email: "teacher@test.ru", OrganizationID: &orgID, Role: models.RoleTeacher,
+	})
+	operatorToken := loginAs(t, authHandler, userRepo, &models.User{
+		ID: uuid.New(), Email: "ops@test.ru", IsOperator: true,
+	})
+
+	t.Run("admin_gets_page_with_cursor_200", func(t *testing.T) {
+		auditRepo.On("List", mock.Anything, mock.MatchedBy(func(f models.AuditFilter) bool {
+			return *f.OrganizationID == orgID && f.Event == models.AuditLogin && f.Limit == 2 && f.BeforeID == 100
+		})).Return([]models.AuditEvent{{ID: 99}, {ID: 98}}, nil).Once()
+
+		req := httptest.NewRequest(http.MethodGet, "/organizations/"+orgID.String()+"/audit-log?event=auth.login&limit=2&before=100", nil)
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		rr := httptest.NewRecorder()
+
+		r.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var resp auditListResponse
+		json.Unmarshal(rr.Body.Bytes(), &resp)
+		assert.Len(t, resp.Events, 2)
+		assert.Equal(t, int64(98), resp.NextCursor)
+	})
+
+	t.Run("teacher_403", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/organizations/"+orgID.String()+"/audit-log", nil)
+		req.Header.Set("Authorization", "Bearer "+teacherToken)
+		rr := httptest.NewRecorder()
+
+		r.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+	})
+
+	t.Run("invalid_from_400", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/organizations/"+orgID.String()+"/audit-log?from=yesterday", nil)
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		rr := httptest.NewRecorder()
+
+		r.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("operator_searches_by_email_200", func(t *testing.T) {
+		auditRepo.On("List", mock.Anything, models.AuditFilter{Email: "ghost@test.ru", Event: models.AuditLogin, Limit: 50}).
+			Return([]models.AuditEvent{{ID: 7, Email: "ghost@test.ru"}}, nil).Once()
+
+		req := httptest.NewRequest(http.MethodGet, "/admin/audit-log?email=ghost@test.ru&event=auth.login", nil)
+		req.Header.Set("Authorization", "Bearer "+operatorToken)
+		rr := httptest.NewRecorder()
+
+		r.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var resp auditListResponse
+		json.Unmarshal(rr.Body.Bytes(), &resp)
+		assert.Len(t, resp.Events, 1)
+	})
+
+	t.Run("admin_cannot_list_all_403", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/admin/audit-log", nil)
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		rr := httptest.NewRecorder()
+
+		r.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+	})
+
+	t.Run("invalid_limit_400", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/organizations/"+orgID.String()+"/audit-log?limit=5000", nil)
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		rr := httptest.NewRecorder()
+
+		r.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
@@ -0,0 +1,10 @@
+package handler
+
+import "net/http"
+
+// JWKS publishes the token verification keys at /.well-known/jwks.json so
+// other services can verify access tokens without sharing a secret.
+func (h *AuthHandler) JWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	sendJSON(w, http.StatusOK, h.authService.PublicKeys())
+}
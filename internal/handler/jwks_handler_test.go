@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dvprokofiev/seating-generator-api/internal/repository"
+	"github.com/dvprokofiev/seating-generator-api/internal/service"
+	"github.com/dvprokofiev/seating-generator-api/internal/signing"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthHandler_JWKS(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	keys, err := signing.NewKeySet(&signing.Key{ID: "2026-10", Method: jwt.SigningMethodEdDSA, Private: priv, Public: pub})
+	require.NoError(t, err)
+	h := NewAuthHandler(service.NewAuthService(repository.NewMockUserRepository(t), "super-secret", service.WithTokenKeys(keys)))
+
+	rr := httptest.NewRecorder()
+	h.JWKS(rr, httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "public, max-age=300", rr.Header().Get("Cache-Control"))
+	var resp signing.JWKS
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	require.Len(t, resp.Keys, 1)
+	assert.Equal(t, "2026-10", resp.Keys[0].KeyID)
+	assert.Equal(t, "OKP", resp.Keys[0].KeyType)
+	assert.NotContains(t, rr.Body.String(), "\"d\"")
+}
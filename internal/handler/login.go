@@ -4,14 +4,16 @@ import (
 	"encoding/json"
 	"errors"
 	"log"
+	"math"
 	"net/http"
+	"strconv"
 
 	"github.com/dvprokofiev/seating-generator-api/internal/service"
 )
 
 type loginRequest struct {
 	Email    string `json:"email" validate:"required,email"`
-	Password string `json:"password" validate:"required,min=8"`
+	Password string `json:"password" validate:"required"`
 }
 
 type loginResponse struct {
@@ -31,11 +33,18 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	token, err := h.authService.Login(r.Context(), req.Email, req.Password)
+	result, err := h.authService.Login(r.Context(), req.Email, req.Password)
 	if err != nil {
+		var retryLater *service.RetryLaterError
 		switch {
+		case errors.As(err, &retryLater):
+			seconds := int(math.Ceil(retryLater.RetryAfter.Seconds()))
+			w.Header().Set("Retry-After", strconv.Itoa(max(seconds, 1)))
+			sendError(w, http.StatusTooManyRequests, "Too many login attempts, try again later")
 		case errors.Is(err, service.ErrInvalidCredentials):
 			sendError(w, http.StatusUnauthorized, "Incorrect e-mail or password")
+		case errors.Is(err, service.ErrAccountDisabled), errors.Is(err, service.ErrPasswordResetRequired):
+			sendError(w, http.StatusForbidden, err.Error())
 		default:
 			log.Printf("Login error: %v", err)
 			sendError(w, http.StatusInternalServerError, "Internal server error")
@@ -43,5 +52,15 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	sendJSON(w, http.StatusOK, loginResponse{Token: token})
+	sendLoginResult(w, result)
+}
+
+// sendLoginResult answers a login with the access token, or with the MFA
+// challenge the user has to pass first.
+func sendLoginResult(w http.ResponseWriter, result *service.LoginResult) {
+	if result.Challenge != nil {
+		sendMFAChallenge(w, result.Challenge)
+		return
+	}
+	sendJSON(w, http.StatusOK, loginResponse{Token: result.Token})
 }
@@ -6,9 +6,12 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"testing"
+	"time"
 
 	"github.com/dvprokofiev/seating-generator-api/internal/models"
+	"github.com/dvprokofiev/seating-generator-api/internal/ratelimit"
 	"github.com/dvprokofiev/seating-generator-api/internal/repository"
 	"github.com/dvprokofiev/seating-generator-api/internal/service"
 	"github.com/google/uuid"
@@ -101,3 +104,27 @@ func TestAuthHandler_WithMockRepo(t *testing.T) {
 		assert.Equal(t, http.StatusUnauthorized, rr.Code)
 	})
 }
+
+func TestAuthHandler_Login_RateLimited(t *testing.T) {
+	mockRepo := repository.NewMockUserRepository(t)
+	protection := service.DefaultLoginProtection(ratelimit.NewMemoryStore(), nil)
+	protection.PerEmail = ratelimit.Limit{Burst: 1, Per: time.Minute}
+	h := NewAuthHandler(service.NewAuthService(mockRepo, "super-secret", service.WithLoginProtection(protection)))
+
+	mockRepo.On("GetByEmail", mock.Anything, "busy@test.ru").Return(nil, nil).Once()
+
+	body, _ := json.Marshal(map[string]string{"email": "busy@test.ru", "password": "password123"})
+
+	rr := httptest.NewRecorder()
+	h.Login(rr, httptest.NewRequest(http.MethodPost, "/auth/login", bytes.NewBuffer(body)))
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	rr = httptest.NewRecorder()
+	h.Login(rr, httptest.NewRequest(http.MethodPost, "/auth/login", bytes.NewBuffer(body)))
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+
+	// the bucket refills while the first login hashes, so allow some slack
+	retryAfter, err := strconv.Atoi(rr.Header().Get("Retry-After"))
+	assert.NoError(t, err)
+	assert.InDelta(t, 55, retryAfter, 5)
+}
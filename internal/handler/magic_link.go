@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/dvprokofiev/seating-generator-api/internal/service"
+)
+
+type magicLinkRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type magicLinkLoginRequest struct {
+	Token string `json:"token" validate:"required,max=64"`
+}
+
+// RequestMagicLink always answers 202 for a well-formed email, whether or
+// not an account exists for it.
+func (h *AuthHandler) RequestMagicLink(w http.ResponseWriter, r *http.Request) {
+	var req magicLinkRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		sendError(w, http.StatusBadRequest, "Validation failed "+err.Error())
+		return
+	}
+
+	if err := h.authService.RequestMagicLink(r.Context(), req.Email); err != nil {
+		sendMagicLinkError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (h *AuthHandler) FinishMagicLinkLogin(w http.ResponseWriter, r *http.Request) {
+	var req magicLinkLoginRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		sendError(w, http.StatusBadRequest, "Validation failed "+err.Error())
+		return
+	}
+
+	result, err := h.authService.FinishMagicLinkLogin(r.Context(), req.Token)
+	if err != nil {
+		sendMagicLinkError(w, err)
+		return
+	}
+
+	sendLoginResult(w, result)
+}
+
+func sendMagicLinkError(w http.ResponseWriter, err error) {
+	var retryLater *service.RetryLaterError
+	switch {
+	case errors.As(err, &retryLater):
+		seconds := int(math.Ceil(retryLater.RetryAfter.Seconds()))
+		w.Header().Set("Retry-After", strconv.Itoa(max(seconds, 1)))
+		sendError(w, http.StatusTooManyRequests, "Too many login attempts, try again later")
+	case errors.Is(err, service.ErrInvalidEmail):
+		sendError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, service.ErrInvalidMagicLink):
+		sendError(w, http.StatusUnauthorized, err.Error())
+	case errors.Is(err, service.ErrAccountDisabled), errors.Is(err, service.ErrPasswordResetRequired):
+		sendError(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, service.ErrMagicLinksNotConfigured):
+		sendError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrMagicLinksBusy):
+		sendError(w, http.StatusServiceUnavailable, err.Error())
+	default:
+		log.Printf("Magic link error: %v", err)
+		sendError(w, http.StatusInternalServerError, "Internal server error")
+	}
+}
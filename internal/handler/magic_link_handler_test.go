@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dvprokofiev/seating-generator-api/internal/mailer"
+	"github.com/dvprokofiev/seating-generator-api/internal/models"
+	"github.com/dvprokofiev/seating-generator-api/internal/repository"
+	"github.com/dvprokofiev/seating-generator-api/internal/service"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// linkMailer hands over the links the service sends in the background.
+type linkMailer struct {
+	sent chan mailer.Message
+}
+
+func (m *linkMailer) Send(_ context.Context, msg mailer.Message) error {
+	m.sent <- msg
+	return nil
+}
+
+func (m *linkMailer) next(t *testing.T) mailer.Message {
+	t.Helper()
+	select {
+	case msg := <-m.sent:
+		return msg
+	case <-time.After(5 * time.Second):
+		t.Fatal("no mail sent")
+		return mailer.Message{}
+	}
+}
+
+func TestAuthHandler_MagicLink_WithMockRepo(t *testing.T) {
+	userRepo := repository.NewMockUserRepository(t)
+	linkRepo := repository.NewMockMagicLinkRepository(t)
+	mail := &linkMailer{sent: make(chan mailer.Message, 1)}
+	h := NewAuthHandler(service.NewAuthService(userRepo, "super-secret", service.WithMailer(mail),
+		service.WithMagicLinks(linkRepo, "https://seating.test/login/link")))
+
+	user := &models.User{ID: uuid.New(), Email: "teacher@test.ru", Role: models.RoleTeacher, IsVerified: true}
+
+	send := func(handler http.HandlerFunc, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		handler(rr, httptest.NewRequest(http.MethodPost, "/auth/magic-link", strings.NewReader(body)))
+		return rr
+	}
+
+	t.Run("unknown_email_202", func(t *testing.T) {
+		looked := make(chan struct{})
+		userRepo.On("GetByEmail", mock.Anything, "ghost@test.ru").Run(func(mock.Arguments) {
+			close(looked)
+		}).Return(nil, sql.ErrNoRows).Once()
+
+		rr := send(h.RequestMagicLink, `{"email":"ghost@test.ru"}`)
+
+		assert.Equal(t, http.StatusAccepted, rr.Code)
+		<-looked
+		assert.Empty(t, mail.sent)
+	})
+
+	t.Run("invalid_email_400", func(t *testing.T) {
+		rr := send(h.RequestMagicLink, `{"email":"not-an-email"}`)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("link_exchanged_for_token_200", func(t *testing.T) {
+		var saved *models.MagicLink
+		userRepo.On("GetByEmail", mock.Anything, user.Email).Return(user, nil).Once()
+		userRepo.On("GetByID", mock.Anything, user.ID).Return(user, nil).Once()
+		linkRepo.On("Save", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			saved = args.Get(1).(*models.MagicLink)
+		}).Return(nil).Once()
+
+		rr := send(h.RequestMagicLink, `{"email":"teacher@test.ru"}`)
+		require.Equal(t, http.StatusAccepted, rr.Code)
+		msg := mail.next(t)
+		require.NotNil(t, saved)
+
+		_, rest, ok := strings.Cut(msg.Body, "?token=")
+		require.True(t, ok)
+		token, _, _ := strings.Cut(rest, "\n")
+		linkRepo.On("Take", mock.Anything, saved.TokenHash).Return(saved, nil).Once()
+
+		rr = send(h.FinishMagicLinkLogin, `{"token":"`+token+`"}`)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var resp loginResponse
+		json.Unmarshal(rr.Body.Bytes(), &resp)
+		assert.NotEmpty(t, resp.Token)
+	})
+
+	t.Run("used_link_401", func(t *testing.T) {
+		linkRepo.On("Take", mock.Anything, mock.Anything).Return(nil, sql.ErrNoRows).Once()
+
+		rr := send(h.FinishMagicLinkLogin, `{"token":"ALREADYUSEDTOKEN"}`)
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+}
@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/dvprokofiev/seating-generator-api/internal/service"
+)
+
+// mfaChallengeResponse answers a login whose password was right but which
+// needs a second factor. MFAToken goes to /auth/mfa/verify, or, when
+// EnrollmentRequired is set, to /auth/mfa/enroll first.
+type mfaChallengeResponse struct {
+	MFARequired        bool   `json:"mfa_required"`
+	EnrollmentRequired bool   `json:"enrollment_required"`
+	MFAToken           string `json:"mfa_token"`
+	ExpiresIn          int    `json:"expires_in"`
+}
+
+type mfaVerifyRequest struct {
+	MFAToken string `json:"mfa_token" validate:"required"`
+	Code     string `json:"code" validate:"required,max=32"`
+}
+
+type mfaEnrollRequest struct {
+	MFAToken string `json:"mfa_token"`
+}
+
+type mfaConfirmRequest struct {
+	MFAToken string `json:"mfa_token"`
+	Code     string `json:"code" validate:"required,max=32"`
+}
+
+func sendMFAChallenge(w http.ResponseWriter, challenge *service.MFAChallenge) {
+	sendJSON(w, http.StatusOK, mfaChallengeResponse{
+		MFARequired:        true,
+		EnrollmentRequired: challenge.EnrollmentRequired,
+		MFAToken:           challenge.Token,
+		ExpiresIn:          int(challenge.ExpiresIn.Seconds()),
+	})
+}
+
+func (h *AuthHandler) VerifyMFA(w http.ResponseWriter, r *http.Request) {
+	var req mfaVerifyRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		sendError(w, http.StatusBadRequest, "Validation failed "+err.Error())
+		return
+	}
+
+	token, err := h.authService.VerifyMFA(r.Context(), req.MFAToken, req.Code)
+	if err != nil {
+		sendMFAError(w, err)
+		return
+	}
+
+	sendJSON(w, http.StatusOK, loginResponse{Token: token})
+}
+
+// EnrollMFA starts enrollment either for the authenticated caller or, on the
+// public route, for the holder of an enrollment challenge from Login.
+func (h *AuthHandler) EnrollMFA(w http.ResponseWriter, r *http.Request) {
+	var req mfaEnrollRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		sendError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	enrollment, err := h.authService.BeginMFAEnrollment(r.Context(), req.MFAToken)
+	if err != nil {
+		sendMFAError(w, err)
+		return
+	}
+
+	sendJSON(w, http.StatusOK, enrollment)
+}
+
+func (h *AuthHandler) ConfirmMFA(w http.ResponseWriter, r *http.Request) {
+	var req mfaConfirmRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		sendError(w, http.StatusBadRequest, "Validation failed "+err.Error())
+		return
+	}
+
+	confirmation, err := h.authService.ConfirmMFAEnrollment(r.Context(), req.MFAToken, req.Code)
+	if err != nil {
+		sendMFAError(w, err)
+		return
+	}
+
+	sendJSON(w, http.StatusOK, confirmation)
+}
+
+func sendMFAError(w http.ResponseWriter, err error) {
+	var retryLater *service.RetryLaterError
+	switch {
+	case errors.As(err, &retryLater):
+		seconds := int(math.Ceil(retryLater.RetryAfter.Seconds()))
+		w.Header().Set("Retry-After", strconv.Itoa(max(seconds, 1)))
+		sendError(w, http.StatusTooManyRequests, "Too many attempts, try again later")
+	case errors.Is(err, service.ErrUnauthenticated), errors.Is(err, service.ErrInvalidToken):
+		sendError(w, http.StatusUnauthorized, "Invalid or expired token")
+	case errors.Is(err, service.ErrForbidden), errors.Is(err, service.ErrAccountDisabled),
+		errors.Is(err, service.ErrPasswordResetRequired):
+		sendError(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, service.ErrInvalidMFACode):
+		sendError(w, http.StatusUnauthorized, err.Error())
+	case errors.Is(err, service.ErrMFAAlreadyEnabled):
+		sendError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, service.ErrMFANotEnrolled):
+		sendError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, service.ErrMFANotConfigured):
+		sendError(w, http.StatusNotFound, err.Error())
+	default:
+		log.Printf("MFA error: %v", err)
+		sendError(w, http.StatusInternalServerError, "Internal server error")
+	}
+}
@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dvprokofiev/seating-generator-api/internal/models"
+	"github.com/dvprokofiev/seating-generator-api/internal/repository"
+	"github.com/dvprokofiev/seating-generator-api/internal/service"
+	"github.com/google/uuid"
+	"github.com/pquerna/otp/totp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestAuthHandler_MFA_WithMockRepo(t *testing.T) {
+	const secret = "JBSWY3DPEHPK3PXP"
+
+	userRepo := repository.NewMockUserRepository(t)
+	mfaRepo := repository.NewMockMFARepository(t)
+	h := NewAuthHandler(service.NewAuthService(userRepo, "super-secret", service.WithMFA(mfaRepo, "Seating Generator")))
+
+	hash, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.DefaultCost)
+	user := &models.User{ID: uuid.New(), Email: "mfa@test.ru", PasswordHash: string(hash)}
+	userRepo.On("GetByEmail", mock.Anything, user.Email).Return(user, nil)
+	userRepo.On("GetByID", mock.Anything, user.ID).Return(user, nil)
+	mfaRepo.On("Get", mock.Anything, user.ID).Return(&models.MFASettings{Secret: secret, Enabled: true}, nil)
+
+	var challenge mfaChallengeResponse
+	t.Run("login_returns_challenge_200", func(t *testing.T) {
+		body, _ := json.Marshal(map[string]string{"email": user.Email, "password": "password123"})
+		rr := httptest.NewRecorder()
+
+		h.Login(rr, httptest.NewRequest(http.MethodPost, "/auth/login", bytes.NewBuffer(body)))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &challenge))
+		assert.True(t, challenge.MFARequired)
+		assert.NotEmpty(t, challenge.MFAToken)
+		assert.NotContains(t, rr.Body.String(), `"token"`)
+	})
+
+	t.Run("wrong_code_401", func(t *testing.T) {
+		body, _ := json.Marshal(mfaVerifyRequest{MFAToken: challenge.MFAToken, Code: "000000"})
+		rr := httptest.NewRecorder()
+
+		h.VerifyMFA(rr, httptest.NewRequest(http.MethodPost, "/auth/mfa/verify", bytes.NewBuffer(body)))
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+
+	t.Run("valid_code_returns_token_200", func(t *testing.T) {
+		mfaRepo.On("ConsumeStep", mock.Anything, user.ID, mock.AnythingOfType("int64")).Return(true, nil).Once()
+		code, _ := totp.GenerateCode(secret, time.Now())
+		body, _ := json.Marshal(mfaVerifyRequest{MFAToken: challenge.MFAToken, Code: code})
+		rr := httptest.NewRecorder()
+
+		h.VerifyMFA(rr, httptest.NewRequest(http.MethodPost, "/auth/mfa/verify", bytes.NewBuffer(body)))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var resp loginResponse
+		json.Unmarshal(rr.Body.Bytes(), &resp)
+		assert.NotEmpty(t, resp.Token)
+	})
+
+	t.Run("enroll_without_caller_401", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+
+		h.EnrollMFA(rr, httptest.NewRequest(http.MethodPost, "/auth/mfa/enroll", nil))
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+}
@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/dvprokofiev/seating-generator-api/internal/models"
+	"github.com/dvprokofiev/seating-generator-api/internal/service"
+)
+
+// Authenticate rejects requests without a valid bearer token or API key, or
+// from disabled accounts and accounts awaiting a forced password reset, and stores the caller's principal in the request
+// context for the service layer.
+func (h *AuthHandler) Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || token == "" {
+			sendError(w, http.StatusUnauthorized, "Missing or malformed Authorization header")
+			return
+		}
+
+		if strings.HasPrefix(token, models.APIKeyPrefix) {
+			principal, err := h.authService.AuthenticateAPIKey(r.Context(), token)
+			if err != nil {
+				switch {
+				case errors.Is(err, service.ErrAccountDisabled), errors.Is(err, service.ErrPasswordResetRequired):
+					sendError(w, http.StatusForbidden, err.Error())
+					return
+				case !errors.Is(err, service.ErrInvalidAPIKey):
+					log.Printf("API key error: %v", err)
+				}
+				sendError(w, http.StatusUnauthorized, "Invalid or expired API key")
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(service.ContextWithPrincipal(r.Context(), *principal)))
+			return
+		}
+
+		principal, err := h.authService.ParseToken(r.Context(), token)
+		if err != nil {
+			switch {
+			case errors.Is(err, service.ErrAccountDisabled), errors.Is(err, service.ErrPasswordResetRequired):
+				sendError(w, http.StatusForbidden, err.Error())
+				return
+			case !errors.Is(err, service.ErrInvalidToken):
+				log.Printf("Session error: %v", err)
+			}
+			sendError(w, http.StatusUnauthorized, "Invalid or expired token")
+			return
+		}
+
+		ctx := service.ContextWithPrincipal(r.Context(), *principal)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// RequestMeta stores the client address and user agent in the request
+// context, where the service layer picks them up for the audit log.
+func RequestMeta(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			ip = r.RemoteAddr
+		}
+
+		ctx := service.ContextWithRequestMeta(r.Context(), service.RequestMeta{
+			IPAddress: ip,
+			UserAgent: r.UserAgent(),
+		})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/dvprokofiev/seating-generator-api/internal/service"
+	"github.com/google/uuid"
+)
+
+type oidcLoginBeginRequest struct {
+	OrganizationID uuid.UUID `json:"organization_id" validate:"required"`
+}
+
+type oidcLoginBeginResponse struct {
+	AuthorizationURL string `json:"authorization_url"`
+}
+
+// oidcLoginFinishRequest carries the query parameters the identity provider
+// appended to the redirect URL.
+type oidcLoginFinishRequest struct {
+	State string `json:"state" validate:"required,max=64"`
+	Code  string `json:"code" validate:"required"`
+}
+
+func (h *AuthHandler) BeginOIDCLogin(w http.ResponseWriter, r *http.Request) {
+	var req oidcLoginBeginRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		sendError(w, http.StatusBadRequest, "Validation failed "+err.Error())
+		return
+	}
+
+	authURL, err := h.authService.BeginOIDCLogin(r.Context(), req.OrganizationID)
+	if err != nil {
+		sendOIDCError(w, err)
+		return
+	}
+
+	sendJSON(w, http.StatusOK, oidcLoginBeginResponse{AuthorizationURL: authURL})
+}
+
+func (h *AuthHandler) FinishOIDCLogin(w http.ResponseWriter, r *http.Request) {
+	var req oidcLoginFinishRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		sendError(w, http.StatusBadRequest, "Validation failed "+err.Error())
+		return
+	}
+
+	token, err := h.authService.FinishOIDCLogin(r.Context(), req.State, req.Code)
+	if err != nil {
+		sendOIDCError(w, err)
+		return
+	}
+
+	sendJSON(w, http.StatusOK, loginResponse{Token: token})
+}
+
+func sendOIDCError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidOIDCState):
+		sendError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, service.ErrOIDCLoginFailed), errors.Is(err, service.ErrOIDCEmailNotVerified):
+		sendError(w, http.StatusUnauthorized, err.Error())
+	case errors.Is(err, service.ErrOIDCEmailNotAllowed), errors.Is(err, service.ErrOIDCAccountConflict),
+		errors.Is(err, service.ErrAccountDisabled), errors.Is(err, service.ErrPasswordResetRequired):
+		sendError(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, service.ErrOIDCIdentityLinked), errors.Is(err, service.ErrOIDCAccountExists):
+		sendError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, service.ErrOIDCNotConfigured):
+		sendError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrOIDCUnavailable):
+		sendError(w, http.StatusBadGateway, err.Error())
+	default:
+		log.Printf("OIDC error: %v", err)
+		sendError(w, http.StatusInternalServerError, "Internal server error")
+	}
+}
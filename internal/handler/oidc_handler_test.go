@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dvprokofiev/seating-generator-api/internal/models"
+	"github.com/dvprokofiev/seating-generator-api/internal/repository"
+	"github.com/dvprokofiev/seating-generator-api/internal/service"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAuthHandler_OIDC_WithMockRepo(t *testing.T) {
+	userRepo := repository.NewMockUserRepository(t)
+	oidcRepo := repository.NewMockOIDCRepository(t)
+	h := NewAuthHandler(service.NewAuthService(userRepo, "super-secret",
+		service.WithOIDC(oidcRepo, "https://seating.test/sso/callback")))
+
+	t.Run("begin_without_provider_404", func(t *testing.T) {
+		orgID := uuid.New()
+		oidcRepo.On("GetProvider", mock.Anything, orgID).Return(nil, sql.ErrNoRows).Once()
+		body, _ := json.Marshal(map[string]string{"organization_id": orgID.String()})
+		rr := httptest.NewRecorder()
+
+		h.BeginOIDCLogin(rr, httptest.NewRequest(http.MethodPost, "/auth/oidc/login/begin", bytes.NewBuffer(body)))
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("begin_without_organization_400", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+
+		h.BeginOIDCLogin(rr, httptest.NewRequest(http.MethodPost, "/auth/oidc/login/begin", strings.NewReader(`{}`)))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("finish_unknown_state_400", func(t *testing.T) {
+		oidcRepo.On("TakeLoginState", mock.Anything, "stale").Return(nil, sql.ErrNoRows).Once()
+		body, _ := json.Marshal(map[string]string{"state": "stale", "code": "abc"})
+		rr := httptest.NewRecorder()
+
+		h.FinishOIDCLogin(rr, httptest.NewRequest(http.MethodPost, "/auth/oidc/login/finish", bytes.NewBuffer(body)))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("finish_without_code_400", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+
+		h.FinishOIDCLogin(rr, httptest.NewRequest(http.MethodPost, "/auth/oidc/login/finish", strings.NewReader(`{"state":"abc"}`)))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
+
+func TestSendOIDCError(t *testing.T) {
+	for err, code := range map[error]int{
+		service.ErrOIDCAccountConflict: http.StatusForbidden,
+		service.ErrOIDCIdentityLinked:  http.StatusConflict,
+		service.ErrOIDCAccountExists:   http.StatusConflict,
+		service.ErrOIDCLoginFailed:     http.StatusUnauthorized,
+		service.ErrOIDCUnavailable:     http.StatusBadGateway,
+	} {
+		rr := httptest.NewRecorder()
+		sendOIDCError(rr, err)
+		assert.Equal(t, code, rr.Code, err.Error())
+	}
+}
+
+func TestOrganizationHandler_OIDCProvider_WithMockRepo(t *testing.T) {
+	userRepo := repository.NewMockUserRepository(t)
+	oidcRepo := repository.NewMockOIDCRepository(t)
+	auditRepo := repository.NewMockAuditRepository(t)
+	auditRepo.On("Append", mock.Anything, mock.AnythingOfType("*models.AuditEvent")).Return(nil).Maybe()
+
+	policy := service.NewRolePolicy()
+	authHandler := NewAuthHandler(service.NewAuthService(userRepo, "super-secret"))
+	h := NewOrganizationHandler(service.NewOrganizationService(repository.NewMockOrganizationRepository(t), userRepo,
+		policy, service.NewAuditService(auditRepo, policy), service.WithOIDCProviders(oidcRepo)))
+
+	r := chi.NewRouter()
+	r.Group(func(r chi.Router) {
+		r.Use(authHandler.Authenticate)
+		r.Get("/organizations/{orgID}/oidc", h.GetOIDCProvider)
+		r.Put("/organizations/{orgID}/oidc", h.SetOIDCProvider)
+		r.Delete("/organizations/{orgID}/oidc", h.DeleteOIDCProvider)
+	})
+
+	orgID := uuid.New()
+	adminToken := loginAs(t, authHandler, userRepo, &models.User{
+		ID: uuid.New(), Email: "admin@test.ru", OrganizationID: &orgID, Role: models.RoleSchoolAdmin,
+	})
+	teacherToken := loginAs(t, authHandler, userRepo, &models.User{
+		ID: uuid.New(), Email: "teacher@test.ru", OrganizationID: &orgID, Role: models.RoleTeacher,
+	})
+
+	send := func(method, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/organizations/"+orgID.String()+"/oidc", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("admin_sets_provider_200_without_secret", func(t *testing.T) {
+		oidcRepo.On("SaveProvider", mock.Anything, mock.MatchedBy(func(p *models.OIDCProvider) bool {
+			return p.OrganizationID == orgID && p.ClientSecret == "s3cret"
+		})).Return(nil).Once()
+
+		rr := send(http.MethodPut, adminToken,
+			`{"issuer":"https://idp.school.test","client_id":"seating","client_secret":"s3cret","email_domain":"school.test"}`)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.NotContains(t, rr.Body.String(), "s3cret")
+	})
+
+	t.Run("insecure_issuer_400", func(t *testing.T) {
+		rr := send(http.MethodPut, adminToken, `{"issuer":"http://idp.school.test","client_id":"seating"}`)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("teacher_403", func(t *testing.T) {
+		rr := send(http.MethodGet, teacherToken, "")
+
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+	})
+
+	t.Run("delete_missing_404", func(t *testing.T) {
+		oidcRepo.On("DeleteProvider", mock.Anything, orgID).Return(sql.ErrNoRows).Once()
+
+		rr := send(http.MethodDelete, adminToken, "")
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
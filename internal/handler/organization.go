@@ -0,0 +1,288 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/dvprokofiev/seating-generator-api/internal/models"
+	"github.com/dvprokofiev/seating-generator-api/internal/service"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+type OrganizationHandler struct {
+	orgService service.OrganizationService
+	validator  *validator.Validate
+}
+
+func NewOrganizationHandler(s service.OrganizationService) *OrganizationHandler {
+	return &OrganizationHandler{
+		orgService: s,
+		validator:  validator.New(),
+	}
+}
+
+type createOrganizationRequest struct {
+	Name string `json:"name" validate:"required,max=255"`
+}
+
+type inviteMemberRequest struct {
+	Email string      `json:"email" validate:"required,email"`
+	Role  models.Role `json:"role" validate:"omitempty,oneof=teacher school_admin"`
+}
+
+type mfaRequirementRequest struct {
+	Required *bool `json:"required" validate:"required"`
+}
+
+type oidcProviderRequest struct {
+	Issuer       string `json:"issuer" validate:"required,url,max=255"`
+	ClientID     string `json:"client_id" validate:"required,max=255"`
+	ClientSecret string `json:"client_secret" validate:"max=255"`
+	EmailDomain  string `json:"email_domain" validate:"omitempty,fqdn,max=255"`
+}
+
+func (h *OrganizationHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req createOrganizationRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		sendError(w, http.StatusBadRequest, "Validation failed "+err.Error())
+		return
+	}
+
+	org, err := h.orgService.Create(r.Context(), req.Name)
+	if err != nil {
+		sendOrganizationError(w, err)
+		return
+	}
+
+	sendJSON(w, http.StatusCreated, org)
+}
+
+func (h *OrganizationHandler) Get(w http.ResponseWriter, r *http.Request) {
+	orgID, err := uuid.Parse(chi.URLParam(r, "orgID"))
+	if err != nil {
+		sendError(w, http.StatusBadRequest, "Invalid organization id")
+		return
+	}
+
+	org, err := h.orgService.Get(r.Context(), orgID)
+	if err != nil {
+		sendOrganizationError(w, err)
+		return
+	}
+
+	sendJSON(w, http.StatusOK, org)
+}
+
+func (h *OrganizationHandler) ListMembers(w http.ResponseWriter, r *http.Request) {
+	orgID, err := uuid.Parse(chi.URLParam(r, "orgID"))
+	if err != nil {
+		sendError(w, http.StatusBadRequest, "Invalid organization id")
+		return
+	}
+
+	members, err := h.orgService.ListMembers(r.Context(), orgID)
+	if err != nil {
+		sendOrganizationError(w, err)
+		return
+	}
+
+	sendJSON(w, http.StatusOK, members)
+}
+
+// InviteMember answers 202 whether or not the email belongs to anyone, so
+// that invitations cannot be used to probe for registered addresses.
+func (h *OrganizationHandler) InviteMember(w http.ResponseWriter, r *http.Request) {
+	orgID, err := uuid.Parse(chi.URLParam(r, "orgID"))
+	if err != nil {
+		sendError(w, http.StatusBadRequest, "Invalid organization id")
+		return
+	}
+
+	var req inviteMemberRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		sendError(w, http.StatusBadRequest, "Validation failed "+err.Error())
+		return
+	}
+	if req.Role == "" {
+		req.Role = models.RoleTeacher
+	}
+
+	if err := h.orgService.InviteMember(r.Context(), orgID, req.Email, req.Role); err != nil {
+		sendOrganizationError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (h *OrganizationHandler) ListInvitations(w http.ResponseWriter, r *http.Request) {
+	invitations, err := h.orgService.ListInvitations(r.Context())
+	if err != nil {
+		sendOrganizationError(w, err)
+		return
+	}
+
+	sendJSON(w, http.StatusOK, invitations)
+}
+
+func (h *OrganizationHandler) AcceptInvitation(w http.ResponseWriter, r *http.Request) {
+	invitationID, err := uuid.Parse(chi.URLParam(r, "invitationID"))
+	if err != nil {
+		sendError(w, http.StatusBadRequest, "Invalid invitation id")
+		return
+	}
+
+	org, err := h.orgService.AcceptInvitation(r.Context(), invitationID)
+	if err != nil {
+		sendOrganizationError(w, err)
+		return
+	}
+
+	sendJSON(w, http.StatusOK, org)
+}
+
+func (h *OrganizationHandler) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	orgID, err := uuid.Parse(chi.URLParam(r, "orgID"))
+	if err != nil {
+		sendError(w, http.StatusBadRequest, "Invalid organization id")
+		return
+	}
+	userID, err := uuid.Parse(chi.URLParam(r, "userID"))
+	if err != nil {
+		sendError(w, http.StatusBadRequest, "Invalid user id")
+		return
+	}
+
+	if err := h.orgService.RemoveMember(r.Context(), orgID, userID); err != nil {
+		sendOrganizationError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *OrganizationHandler) SetMFARequirement(w http.ResponseWriter, r *http.Request) {
+	orgID, err := uuid.Parse(chi.URLParam(r, "orgID"))
+	if err != nil {
+		sendError(w, http.StatusBadRequest, "Invalid organization id")
+		return
+	}
+
+	var req mfaRequirementRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		sendError(w, http.StatusBadRequest, "Validation failed "+err.Error())
+		return
+	}
+
+	org, err := h.orgService.SetMFARequired(r.Context(), orgID, *req.Required)
+	if err != nil {
+		sendOrganizationError(w, err)
+		return
+	}
+
+	sendJSON(w, http.StatusOK, org)
+}
+
+func (h *OrganizationHandler) GetOIDCProvider(w http.ResponseWriter, r *http.Request) {
+	orgID, err := uuid.Parse(chi.URLParam(r, "orgID"))
+	if err != nil {
+		sendError(w, http.StatusBadRequest, "Invalid organization id")
+		return
+	}
+
+	provider, err := h.orgService.GetOIDCProvider(r.Context(), orgID)
+	if err != nil {
+		sendOrganizationError(w, err)
+		return
+	}
+
+	sendJSON(w, http.StatusOK, provider)
+}
+
+func (h *OrganizationHandler) SetOIDCProvider(w http.ResponseWriter, r *http.Request) {
+	orgID, err := uuid.Parse(chi.URLParam(r, "orgID"))
+	if err != nil {
+		sendError(w, http.StatusBadRequest, "Invalid organization id")
+		return
+	}
+
+	var req oidcProviderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		sendError(w, http.StatusBadRequest, "Validation failed "+err.Error())
+		return
+	}
+
+	provider, err := h.orgService.SetOIDCProvider(r.Context(), orgID, models.OIDCProvider{
+		Issuer:       req.Issuer,
+		ClientID:     req.ClientID,
+		ClientSecret: req.ClientSecret,
+		EmailDomain:  req.EmailDomain,
+	})
+	if err != nil {
+		sendOrganizationError(w, err)
+		return
+	}
+
+	sendJSON(w, http.StatusOK, provider)
+}
+
+func (h *OrganizationHandler) DeleteOIDCProvider(w http.ResponseWriter, r *http.Request) {
+	orgID, err := uuid.Parse(chi.URLParam(r, "orgID"))
+	if err != nil {
+		sendError(w, http.StatusBadRequest, "Invalid organization id")
+		return
+	}
+
+	if err := h.orgService.DeleteOIDCProvider(r.Context(), orgID); err != nil {
+		sendOrganizationError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func sendOrganizationError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrUnauthenticated):
+		sendError(w, http.StatusUnauthorized, err.Error())
+	case errors.Is(err, service.ErrForbidden), errors.Is(err, service.ErrEmailNotVerified):
+		sendError(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, service.ErrOrganizationNotFound), errors.Is(err, service.ErrUserNotFound),
+		errors.Is(err, service.ErrOIDCProviderNotFound), errors.Is(err, service.ErrOIDCNotConfigured),
+		errors.Is(err, service.ErrInvitationNotFound):
+		sendError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrAlreadyInOrganization):
+		sendError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, service.ErrInvalidOrganizationName), errors.Is(err, service.ErrInvalidRole),
+		errors.Is(err, service.ErrCannotRemoveSelf), errors.Is(err, service.ErrInvalidOIDCIssuer):
+		sendError(w, http.StatusBadRequest, err.Error())
+	default:
+		log.Printf("Organization error: %v", err)
+		sendError(w, http.StatusInternalServerError, "Internal server error")
+	}
+}
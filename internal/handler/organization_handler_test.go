@@ -0,0 +1,191 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dvprokofiev/seating-generator-api/internal/models"
+	"github.com/dvprokofiev/seating-generator-api/internal/repository"
+	"github.com/dvprokofiev/seating-generator-api/internal/service"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// loginAs signs user in through the real Login handler and returns the token.
+func loginAs(t *testing.T, authHandler *AuthHandler, userRepo *repository.MockUserRepository, user *models.User) string {
+	t.Helper()
+	const password = "password123"
+
+	hash, _ := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	user.PasswordHash = string(hash)
+	userRepo.On("GetByEmail", mock.Anything, user.Email).Return(user, nil).Once()
+	// every authenticated request checks that the account is still enabled
+	userRepo.On("GetByID", mock.Anything, user.ID).Return(user, nil).Maybe()
+
+	body, _ := json.Marshal(map[string]string{"email": user.Email, "password": password})
+	rr := httptest.NewRecorder()
+	authHandler.Login(rr, httptest.NewRequest(http.MethodPost, "/auth/login", bytes.NewBuffer(body)))
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var resp map[string]string
+	json.Unmarshal(rr.Body.Bytes(), &resp)
+	return resp["token"]
+}
+
+func TestOrganizationHandler_WithMockRepo(t *testing.T) {
+	userRepo := repository.NewMockUserRepository(t)
+	orgRepo := repository.NewMockOrganizationRepository(t)
+	auditRepo := repository.NewMockAuditRepository(t)
+	auditRepo.On("Append", mock.Anything, mock.AnythingOfType("*models.AuditEvent")).Return(nil).Maybe()
+
+	policy := service.NewRolePolicy()
+	authHandler := NewAuthHandler(service.NewAuthService(userRepo, "super-secret"))
+	h := NewOrganizationHandler(service.NewOrganizationService(orgRepo, userRepo, policy, service.NewAuditService(auditRepo, policy)))
+
+	r := chi.NewRouter()
+	r.Group(func(r chi.Router) {
+		r.Use(authHandler.Authenticate)
+		r.Post("/organizations", h.Create)
+		r.Get("/organizations/{orgID}", h.Get)
+		r.Post("/organizations/{orgID}/invitations", h.InviteMember)
+		r.Post("/me/invitations/{invitationID}/accept", h.AcceptInvitation)
+		r.Delete("/organizations/{orgID}/members/{userID}", h.RemoveMember)
+	})
+
+	orgID := uuid.New()
+	admin := &models.User{ID: uuid.New(), Email: "admin@test.ru", OrganizationID: &orgID, Role: models.RoleSchoolAdmin}
+	teacher := &models.User{ID: uuid.New(), Email: "teacher@test.ru", OrganizationID: &orgID, Role: models.RoleTeacher}
+
+	adminToken := loginAs(t, authHandler, userRepo, admin)
+	teacherToken := loginAs(t, authHandler, userRepo, teacher)
+
+	t.Run("missing_token_401", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/organizations/"+orgID.String(), nil)
+		rr := httptest.NewRecorder()
+
+		r.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+
+	t.Run("garbage_token_401", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/organizations/"+orgID.String(), nil)
+		req.Header.Set("Authorization", "Bearer not-a-jwt")
+		rr := httptest.NewRecorder()
+
+		r.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+
+	t.Run("create_by_member_409", func(t *testing.T) {
+		body, _ := json.Marshal(map[string]string{"name": "Second school"})
+		req := httptest.NewRequest(http.MethodPost, "/organizations", bytes.NewBuffer(body))
+		req.Header.Set("Authorization", "Bearer "+teacherToken)
+		rr := httptest.NewRecorder()
+
+		r.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusConflict, rr.Code)
+	})
+
+	t.Run("get_own_organization_200", func(t *testing.T) {
+		orgRepo.On("GetByID", mock.Anything, orgID).Return(&models.Organization{ID: orgID, Name: "School 57"}, nil).Once()
+
+		req := httptest.NewRequest(http.MethodGet, "/organizations/"+orgID.String(), nil)
+		req.Header.Set("Authorization", "Bearer "+teacherToken)
+		rr := httptest.NewRecorder()
+
+		r.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("get_foreign_organization_403", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/organizations/"+uuid.NewString(), nil)
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		rr := httptest.NewRecorder()
+
+		r.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+	})
+
+	t.Run("invalid_org_id_400", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/organizations/not-a-uuid", nil)
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		rr := httptest.NewRecorder()
+
+		r.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	invite := func(token string, body map[string]string) *httptest.ResponseRecorder {
+		payload, _ := json.Marshal(body)
+		req := httptest.NewRequest(http.MethodPost, "/organizations/"+orgID.String()+"/invitations", bytes.NewBuffer(payload))
+		req.Header.Set("Authorization", "Bearer "+token)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("teacher_invite_403", func(t *testing.T) {
+		rr := invite(teacherToken, map[string]string{"email": "new@test.ru"})
+
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+	})
+
+	t.Run("invite_answers_the_same_for_any_email_202", func(t *testing.T) {
+		orgRepo.On("SaveInvitation", mock.Anything, mock.AnythingOfType("*models.Invitation")).Return(nil).Twice()
+
+		registered := invite(adminToken, map[string]string{"email": teacher.Email})
+		unknown := invite(adminToken, map[string]string{"email": "ghost@test.ru"})
+
+		assert.Equal(t, http.StatusAccepted, registered.Code)
+		assert.Equal(t, http.StatusAccepted, unknown.Code)
+		assert.Equal(t, registered.Body.String(), unknown.Body.String())
+	})
+
+	t.Run("invite_invalid_role_400", func(t *testing.T) {
+		rr := invite(adminToken, map[string]string{"email": "new@test.ru", "role": "principal"})
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("accept_invitation_200", func(t *testing.T) {
+		invitee := &models.User{ID: uuid.New(), Email: "new@test.ru", Role: models.RoleTeacher, IsVerified: true}
+		inviteeToken := loginAs(t, authHandler, userRepo, invitee)
+		invitationID := uuid.New()
+		orgRepo.On("AcceptInvitation", mock.Anything, invitationID, invitee.ID, invitee.Email).
+			Return(&models.Invitation{ID: invitationID, OrganizationID: orgID, Role: models.RoleTeacher}, nil).Once()
+		orgRepo.On("GetByID", mock.Anything, orgID).Return(&models.Organization{ID: orgID, Name: "School 57"}, nil).Once()
+
+		req := httptest.NewRequest(http.MethodPost, "/me/invitations/"+invitationID.String()+"/accept", nil)
+		req.Header.Set("Authorization", "Bearer "+inviteeToken)
+		rr := httptest.NewRecorder()
+
+		r.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("admin_remove_member_204", func(t *testing.T) {
+		orgRepo.On("RemoveMember", mock.Anything, orgID, teacher.ID).Return(nil).Once()
+
+		req := httptest.NewRequest(http.MethodDelete, "/organizations/"+orgID.String()+"/members/"+teacher.ID.String(), nil)
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		rr := httptest.NewRecorder()
+
+		r.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+	})
+}
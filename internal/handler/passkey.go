@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/dvprokofiev/seating-generator-api/internal/service"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// passkeyFinishRequest carries the PublicKeyCredential returned by the
+// browser, serialized as by PublicKeyCredential.toJSON().
+type passkeyFinishRequest struct {
+	CeremonyID uuid.UUID       `json:"ceremony_id" validate:"required"`
+	Name       string          `json:"name" validate:"max=64"`
+	Credential json.RawMessage `json:"credential" validate:"required"`
+}
+
+// BeginPasskeyLogin takes no body. The browser offers the user's
+// discoverable credentials, so no email address is needed.
+func (h *AuthHandler) BeginPasskeyLogin(w http.ResponseWriter, r *http.Request) {
+	ceremony, err := h.authService.BeginPasskeyLogin(r.Context())
+	if err != nil {
+		sendPasskeyError(w, err)
+		return
+	}
+
+	sendJSON(w, http.StatusOK, ceremony)
+}
+
+func (h *AuthHandler) FinishPasskeyLogin(w http.ResponseWriter, r *http.Request) {
+	var req passkeyFinishRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		sendError(w, http.StatusBadRequest, "Validation failed "+err.Error())
+		return
+	}
+
+	token, err := h.authService.FinishPasskeyLogin(r.Context(), req.CeremonyID, req.Credential)
+	if err != nil {
+		sendPasskeyError(w, err)
+		return
+	}
+
+	sendJSON(w, http.StatusOK, loginResponse{Token: token})
+}
+
+func (h *AuthHandler) BeginPasskeyRegistration(w http.ResponseWriter, r *http.Request) {
+	ceremony, err := h.authService.BeginPasskeyRegistration(r.Context())
+	if err != nil {
+		sendPasskeyError(w, err)
+		return
+	}
+
+	sendJSON(w, http.StatusOK, ceremony)
+}
+
+func (h *AuthHandler) FinishPasskeyRegistration(w http.ResponseWriter, r *http.Request) {
+	var req passkeyFinishRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		sendError(w, http.StatusBadRequest, "Validation failed "+err.Error())
+		return
+	}
+
+	passkey, err := h.authService.FinishPasskeyRegistration(r.Context(), req.CeremonyID, req.Credential, req.Name)
+	if err != nil {
+		sendPasskeyError(w, err)
+		return
+	}
+
+	sendJSON(w, http.StatusCreated, passkey)
+}
+
+func (h *AuthHandler) ListPasskeys(w http.ResponseWriter, r *http.Request) {
+	passkeys, err := h.authService.ListPasskeys(r.Context())
+	if err != nil {
+		sendPasskeyError(w, err)
+		return
+	}
+
+	sendJSON(w, http.StatusOK, passkeys)
+}
+
+func (h *AuthHandler) DeletePasskey(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "passkeyID"))
+	if err != nil {
+		sendError(w, http.StatusBadRequest, "Invalid passkey id")
+		return
+	}
+
+	if err := h.authService.DeletePasskey(r.Context(), id); err != nil {
+		sendPasskeyError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func sendPasskeyError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrUnauthenticated), errors.Is(err, service.ErrInvalidPasskey):
+		sendError(w, http.StatusUnauthorized, err.Error())
+	case errors.Is(err, service.ErrForbidden), errors.Is(err, service.ErrAccountDisabled),
+		errors.Is(err, service.ErrPasswordResetRequired):
+		sendError(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, service.ErrInvalidCeremony):
+		sendError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, service.ErrPasskeyExists):
+		sendError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, service.ErrPasskeyNotFound), errors.Is(err, service.ErrPasskeysNotConfigured):
+		sendError(w, http.StatusNotFound, err.Error())
+	default:
+		log.Printf("Passkey error: %v", err)
+		sendError(w, http.StatusInternalServerError, "Internal server error")
+	}
+}
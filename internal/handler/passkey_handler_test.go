@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dvprokofiev/seating-generator-api/internal/repository"
+	"github.com/dvprokofiev/seating-generator-api/internal/service"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthHandler_Passkeys_WithMockRepo(t *testing.T) {
+	userRepo := repository.NewMockUserRepository(t)
+	passkeyRepo := repository.NewMockPasskeyRepository(t)
+	wa, err := webauthn.New(&webauthn.Config{
+		RPID:          "seating.test",
+		RPDisplayName: "Seating Generator",
+		RPOrigins:     []string{"https://seating.test"},
+	})
+	require.NoError(t, err)
+	h := NewAuthHandler(service.NewAuthService(userRepo, "super-secret", service.WithPasskeys(passkeyRepo, wa)))
+
+	t.Run("begin_login_200", func(t *testing.T) {
+		passkeyRepo.On("SaveCeremony", mock.Anything, mock.Anything).Return(nil).Once()
+		rr := httptest.NewRecorder()
+
+		h.BeginPasskeyLogin(rr, httptest.NewRequest(http.MethodPost, "/auth/passkey/login/begin", nil))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var resp struct {
+			CeremonyID uuid.UUID `json:"ceremony_id"`
+			Options    struct {
+				PublicKey struct {
+					Challenge        string `json:"challenge"`
+					RPID             string `json:"rpId"`
+					UserVerification string `json:"userVerification"`
+				} `json:"publicKey"`
+			} `json:"options"`
+		}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		assert.NotEqual(t, uuid.Nil, resp.CeremonyID)
+		assert.NotEmpty(t, resp.Options.PublicKey.Challenge)
+		assert.Equal(t, "seating.test", resp.Options.PublicKey.RPID)
+		assert.Equal(t, "required", resp.Options.PublicKey.UserVerification)
+	})
+
+	t.Run("finish_unknown_ceremony_400", func(t *testing.T) {
+		id := uuid.New()
+		passkeyRepo.On("TakeCeremony", mock.Anything, id).Return(nil, sql.ErrNoRows).Once()
+		body, _ := json.Marshal(map[string]any{"ceremony_id": id, "credential": map[string]string{"id": "x"}})
+		rr := httptest.NewRecorder()
+
+		h.FinishPasskeyLogin(rr, httptest.NewRequest(http.MethodPost, "/auth/passkey/login/finish", bytes.NewBuffer(body)))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("not_configured_404", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		plain := NewAuthHandler(service.NewAuthService(userRepo, "super-secret"))
+
+		plain.BeginPasskeyLogin(rr, httptest.NewRequest(http.MethodPost, "/auth/passkey/login/begin", nil))
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/dvprokofiev/seating-generator-api/internal/service"
+)
+
+type passwordResetRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
+
+// ResetPassword sets a new password with the token from the link an
+// operator's forced reset emailed to the user.
+func (h *AuthHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var req passwordResetRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		sendError(w, http.StatusBadRequest, "Validation failed "+err.Error())
+		return
+	}
+
+	if err := h.authService.ResetPassword(r.Context(), req.Token, req.Password); err != nil {
+		var policyErr *service.PasswordPolicyError
+		switch {
+		case errors.As(err, &policyErr):
+			sendJSON(w, http.StatusBadRequest, passwordPolicyResponse{
+				Error:      "Password does not meet the password policy",
+				Violations: policyErr.Violations,
+			})
+		case errors.Is(err, service.ErrPasswordUnchanged):
+			sendError(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, service.ErrInvalidPasswordResetLink):
+			sendError(w, http.StatusUnauthorized, err.Error())
+		case errors.Is(err, service.ErrAccountDisabled):
+			sendError(w, http.StatusForbidden, err.Error())
+		case errors.Is(err, service.ErrPasswordResetsNotConfigured):
+			sendError(w, http.StatusNotFound, err.Error())
+		default:
+			log.Printf("Password reset error: %v", err)
+			sendError(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
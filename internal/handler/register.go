@@ -10,7 +10,12 @@ import (
 
 type registerRequest struct {
 	Email    string `json:"email" validate:"required,email"`
-	Password string `json:"password" validate:"required,min=8"`
+	Password string `json:"password" validate:"required"`
+}
+
+type passwordPolicyResponse struct {
+	Error      string                      `json:"error"`
+	Violations []service.PasswordViolation `json:"violations"`
 }
 
 func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
@@ -23,9 +28,16 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 
 	err := h.authService.Register(r.Context(), req.Email, req.Password)
 	if err != nil {
+		var policyErr *service.PasswordPolicyError
 		switch {
-		case errors.Is(err, service.ErrInvalidEmail), errors.Is(err, service.ErrPasswordTooShort):
-			sendError(w, http.StatusBadRequest, "Incorrect email or password too short")
+		case errors.As(err, &policyErr):
+			sendJSON(w, http.StatusBadRequest, passwordPolicyResponse{
+				Error:      "Password does not meet the password policy",
+				Violations: policyErr.Violations,
+			})
+
+		case errors.Is(err, service.ErrInvalidEmail):
+			sendError(w, http.StatusBadRequest, "Incorrect email")
 
 		case errors.Is(err, service.ErrUserAlreadyExists):
 			sendError(w, http.StatusConflict, "User with such email already exists")
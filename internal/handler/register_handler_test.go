@@ -92,6 +92,25 @@ func TestAuthHandler_Register_WithMockRepo(t *testing.T) {
 		assert.Equal(t, http.StatusBadRequest, rr.Code)
 	})
 
+	t.Run("policy_violations_400", func(t *testing.T) {
+		body, _ := json.Marshal(map[string]string{
+			"email":    "teacher@test.ru",
+			"password": "teacher",
+		})
+		req := httptest.NewRequest(http.MethodPost, "/auth/register", bytes.NewBuffer(body))
+		rr := httptest.NewRecorder()
+
+		h.Register(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		var resp passwordPolicyResponse
+		json.Unmarshal(rr.Body.Bytes(), &resp)
+		if assert.Len(t, resp.Violations, 2) {
+			assert.Equal(t, service.PasswordTooShort, resp.Violations[0].Code)
+			assert.Equal(t, service.PasswordMatchesEmail, resp.Violations[1].Code)
+		}
+	})
+
 	t.Run("db_error_500", func(t *testing.T) {
 		testEmail := "test@test.ru"
 		testPass := "password123"
@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/dvprokofiev/seating-generator-api/internal/service"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+func (h *AuthHandler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	sessions, err := h.authService.ListSessions(r.Context())
+	if err != nil {
+		sendSessionError(w, err)
+		return
+	}
+
+	sendJSON(w, http.StatusOK, sessions)
+}
+
+func (h *AuthHandler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "sessionID"))
+	if err != nil {
+		sendError(w, http.StatusBadRequest, "Invalid session id")
+		return
+	}
+
+	if err := h.authService.RevokeSession(r.Context(), id); err != nil {
+		sendSessionError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func sendSessionError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrUnauthenticated):
+		sendError(w, http.StatusUnauthorized, err.Error())
+	case errors.Is(err, service.ErrForbidden):
+		sendError(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, service.ErrSessionNotFound), errors.Is(err, service.ErrSessionsNotConfigured):
+		sendError(w, http.StatusNotFound, err.Error())
+	default:
+		log.Printf("Session error: %v", err)
+		sendError(w, http.StatusInternalServerError, "Internal server error")
+	}
+}
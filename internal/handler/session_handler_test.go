@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dvprokofiev/seating-generator-api/internal/models"
+	"github.com/dvprokofiev/seating-generator-api/internal/repository"
+	"github.com/dvprokofiev/seating-generator-api/internal/service"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthHandler_Sessions_WithMockRepo(t *testing.T) {
+	userRepo := repository.NewMockUserRepository(t)
+	sessionRepo := repository.NewMockSessionRepository(t)
+	authHandler := NewAuthHandler(service.NewAuthService(userRepo, "super-secret", service.WithSessions(sessionRepo)))
+
+	r := chi.NewRouter()
+	r.Use(RequestMeta)
+	r.Group(func(r chi.Router) {
+		r.Use(authHandler.Authenticate)
+		r.Get("/me/sessions", authHandler.ListSessions)
+		r.Delete("/me/sessions/{sessionID}", authHandler.RevokeSession)
+	})
+
+	var stored []models.Session
+	sessionRepo.On("Create", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		stored = append(stored, *args.Get(1).(*models.Session))
+	}).Return(nil)
+
+	user := &models.User{ID: uuid.New(), Email: "teacher@test.ru", Role: models.RoleTeacher}
+	classroomToken := loginAs(t, authHandler, userRepo, user)
+	homeToken := loginAs(t, authHandler, userRepo, user)
+	require.Len(t, stored, 2)
+	classroom, home := stored[0], stored[1]
+	// authenticated expects the session of homeToken to be checked once
+	authenticated := func() {
+		sessionRepo.On("Get", mock.Anything, home.ID).Return(&home, nil).Once()
+	}
+
+	send := func(method, path, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("list_200", func(t *testing.T) {
+		authenticated()
+		sessionRepo.On("ListByUser", mock.Anything, user.ID).Return(stored, nil).Once()
+
+		rr := send(http.MethodGet, "/me/sessions", homeToken)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var resp []map[string]any
+		json.Unmarshal(rr.Body.Bytes(), &resp)
+		require.Len(t, resp, 2)
+		assert.Equal(t, false, resp[0]["current"])
+		assert.Equal(t, true, resp[1]["current"])
+		assert.NotContains(t, resp[0], "UserID")
+	})
+
+	t.Run("invalid_id_400", func(t *testing.T) {
+		authenticated()
+		rr := send(http.MethodDelete, "/me/sessions/not-a-uuid", homeToken)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("unknown_session_404", func(t *testing.T) {
+		id := uuid.New()
+		authenticated()
+		sessionRepo.On("Delete", mock.Anything, user.ID, id).Return(repository.ErrSessionNotFound).Once()
+
+		rr := send(http.MethodDelete, "/me/sessions/"+id.String(), homeToken)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("revoke_204_then_token_401", func(t *testing.T) {
+		authenticated()
+		sessionRepo.On("Delete", mock.Anything, user.ID, classroom.ID).Return(nil).Once()
+
+		rr := send(http.MethodDelete, "/me/sessions/"+classroom.ID.String(), homeToken)
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+
+		sessionRepo.On("Get", mock.Anything, classroom.ID).Return(nil, sql.ErrNoRows).Once()
+		rr = send(http.MethodGet, "/me/sessions", classroomToken)
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+}
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// APIKeyPrefix starts every API key, so that keys are told apart from
+// access tokens and are easy to spot in leaked files.
+const APIKeyPrefix = "sg_"
+
+type APIKeyScope string
+
+const (
+	ScopePlansRead        APIKeyScope = "plans:read"
+	ScopePlansWrite       APIKeyScope = "plans:write"
+	ScopeOrganizationRead APIKeyScope = "organization:read"
+)
+
+func (s APIKeyScope) Valid() bool {
+	return s == ScopePlansRead || s == ScopePlansWrite || s == ScopeOrganizationRead
+}
+
+// APIKey is a long-lived credential a user creates for scripts. The key
+// reads "sg_<prefix>_<secret>"; Prefix identifies it and only a hash of the
+// secret is stored.
+type APIKey struct {
+	ID         uuid.UUID     `json:"id"`
+	UserID     uuid.UUID     `json:"-"`
+	Name       string        `json:"name"`
+	Prefix     string        `json:"prefix"`
+	SecretHash string        `json:"-"`
+	Scopes     []APIKeyScope `json:"scopes"`
+	CreatedAt  time.Time     `json:"created_at"`
+	ExpiresAt  *time.Time    `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time    `json:"last_used_at,omitempty"`
+}
@@ -0,0 +1,67 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type AuditEventType string
+
+const (
+	AuditLogin              AuditEventType = "auth.login"
+	AuditAccountLocked      AuditEventType = "auth.account_locked"
+	AuditRegister           AuditEventType = "auth.register"
+	AuditEmailVerify        AuditEventType = "auth.email_verify"
+	AuditMFAEnroll          AuditEventType = "auth.mfa_enroll"
+	AuditMFAVerify          AuditEventType = "auth.mfa_verify"
+	AuditPasskeyRegister    AuditEventType = "auth.passkey_register"
+	AuditPasskeyDelete      AuditEventType = "auth.passkey_delete"
+	AuditIdentityLink       AuditEventType = "auth.identity_link"
+	AuditAPIKeyCreate       AuditEventType = "auth.api_key_create"
+	AuditAPIKeyRevoke       AuditEventType = "auth.api_key_revoke"
+	AuditSessionRevoke      AuditEventType = "auth.session_revoke"
+	AuditPasswordReset      AuditEventType = "auth.password_reset"
+	AuditOrganizationCreate AuditEventType = "organization.create"
+	AuditMemberInvite       AuditEventType = "organization.member_invite"
+	AuditMemberAdd          AuditEventType = "organization.member_add"
+	AuditMemberRemove       AuditEventType = "organization.member_remove"
+	AuditMFARequirement     AuditEventType = "organization.mfa_requirement"
+	AuditOIDCProviderSet    AuditEventType = "organization.oidc_provider_set"
+	AuditOIDCProviderDelete AuditEventType = "organization.oidc_provider_delete"
+	AuditUserDisable        AuditEventType = "admin.user_disable"
+	AuditUserEnable         AuditEventType = "admin.user_enable"
+	AuditUserResetPassword  AuditEventType = "admin.user_reset_password"
+	AuditUserVerify         AuditEventType = "admin.user_verify"
+	AuditImpersonate        AuditEventType = "admin.impersonate"
+)
+
+type AuditEvent struct {
+	ID             int64             `json:"id"`
+	OccurredAt     time.Time         `json:"occurred_at"`
+	Event          AuditEventType    `json:"event"`
+	Success        bool              `json:"success"`
+	ActorID        *uuid.UUID        `json:"actor_id,omitempty"`
+	OrganizationID *uuid.UUID        `json:"organization_id,omitempty"`
+	TargetType     string            `json:"target_type,omitempty"`
+	TargetID       string            `json:"target_id,omitempty"`
+	Email          string            `json:"email,omitempty"`
+	IPAddress      string            `json:"ip_address,omitempty"`
+	UserAgent      string            `json:"user_agent,omitempty"`
+	Details        map[string]string `json:"details,omitempty"`
+}
+
+// AuditFilter selects audit events, newest first. Without an
+// OrganizationID it matches events of every organization and of users who
+// belong to none. BeforeID is the pagination cursor: only events with a
+// smaller id match.
+type AuditFilter struct {
+	OrganizationID *uuid.UUID
+	ActorID        *uuid.UUID
+	Email          string
+	Event          AuditEventType
+	From           *time.Time
+	To             *time.Time
+	BeforeID       int64
+	Limit          int
+}
@@ -0,0 +1,12 @@
+package models
+
+// MFASettings is a user's TOTP state. Secret is set once enrollment starts
+// and Enabled once it is confirmed. LastStep is the most recent TOTP time
+// step accepted, so a code cannot be used twice. Required reports whether
+// the user's organization enforces MFA.
+type MFASettings struct {
+	Secret   string
+	Enabled  bool
+	LastStep int64
+	Required bool
+}
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OIDCProvider is the identity provider an organization signs its members
+// in with. EmailDomain, when set, restricts which email addresses may be
+// linked or registered through it.
+type OIDCProvider struct {
+	OrganizationID uuid.UUID `json:"organization_id"`
+	Issuer         string    `json:"issuer"`
+	ClientID       string    `json:"client_id"`
+	ClientSecret   string    `json:"-"`
+	EmailDomain    string    `json:"email_domain,omitempty"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// OIDCLoginState keeps the server side of an authorization code flow between
+// the redirect to the provider and the callback.
+type OIDCLoginState struct {
+	State          string
+	OrganizationID uuid.UUID
+	Nonce          string
+	CodeVerifier   string
+	ExpiresAt      time.Time
+}
+
+// ExternalIdentity links an account at an identity provider, named by the
+// issuer and the subject of its ID tokens, to a user.
+type ExternalIdentity struct {
+	Issuer    string
+	Subject   string
+	UserID    uuid.UUID
+	CreatedAt time.Time
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Organization struct {
+	ID         uuid.UUID `json:"id"`
+	Name       string    `json:"name"`
+	RequireMFA bool      `json:"require_mfa"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Invitation asks whoever owns Email to join an organization. It takes
+// effect only when they accept it while signed in with that verified email.
+type Invitation struct {
+	ID               uuid.UUID  `json:"id"`
+	OrganizationID   uuid.UUID  `json:"organization_id"`
+	OrganizationName string     `json:"organization_name,omitempty"`
+	Email            string     `json:"email"`
+	Role             Role       `json:"role"`
+	InvitedBy        *uuid.UUID `json:"invited_by,omitempty"`
+	ExpiresAt        time.Time  `json:"expires_at"`
+	CreatedAt        time.Time  `json:"created_at"`
+}
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Passkey is a WebAuthn credential registered by a user. Credential holds
+// the JSON encoded credential record, including the public key and the
+// signature counter.
+type Passkey struct {
+	ID           uuid.UUID  `json:"id"`
+	UserID       uuid.UUID  `json:"-"`
+	CredentialID []byte     `json:"-"`
+	Name         string     `json:"name"`
+	Credential   []byte     `json:"-"`
+	CreatedAt    time.Time  `json:"created_at"`
+	LastUsedAt   *time.Time `json:"last_used_at,omitempty"`
+}
+
+type WebAuthnCeremonyKind string
+
+const (
+	CeremonyRegistration WebAuthnCeremonyKind = "registration"
+	CeremonyLogin        WebAuthnCeremonyKind = "login"
+)
+
+// WebAuthnCeremony keeps the server side of a registration or login between
+// its begin and finish requests. Session holds the JSON encoded challenge
+// data. UserID is nil for a login where the user is not known up front.
+type WebAuthnCeremony struct {
+	ID        uuid.UUID
+	UserID    *uuid.UUID
+	Kind      WebAuthnCeremonyKind
+	Session   []byte
+	ExpiresAt time.Time
+}
@@ -6,10 +6,38 @@ import (
 	"github.com/google/uuid"
 )
 
+type Role string
+
+const (
+	RoleTeacher     Role = "teacher"
+	RoleSchoolAdmin Role = "school_admin"
+)
+
+func (r Role) Valid() bool {
+	return r == RoleTeacher || r == RoleSchoolAdmin
+}
+
+// User is an account. IsOperator marks the people running the service, who
+// may manage every account. A disabled user cannot sign in, and neither can
+// a user with PasswordResetRequired until they set a new password through
+// the emailed reset link.
 type User struct {
-	ID           uuid.UUID `json:"id"`
-	Email        string    `json:"email"`
-	PasswordHash string    `json:"-"`
-	CreatedAt    time.Time `json:"created_at"`
-	IsVerified   bool      `json:"is_verified"`
+	ID                    uuid.UUID  `json:"id"`
+	Email                 string     `json:"email"`
+	PasswordHash          string     `json:"-"`
+	OrganizationID        *uuid.UUID `json:"organization_id,omitempty"`
+	Role                  Role       `json:"role"`
+	CreatedAt             time.Time  `json:"created_at"`
+	IsVerified            bool       `json:"is_verified"`
+	IsOperator            bool       `json:"-"`
+	DisabledAt            *time.Time `json:"disabled_at,omitempty"`
+	PasswordResetRequired bool       `json:"password_reset_required,omitempty"`
+}
+
+// UserFilter selects users whose email starts with EmailPrefix, ordered by
+// email. After is the pagination cursor: only emails after it match.
+type UserFilter struct {
+	EmailPrefix string
+	After       string
+	Limit       int
 }
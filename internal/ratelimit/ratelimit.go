@@ -0,0 +1,88 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Limit allows Burst events at once, refilled evenly over Per.
+type Limit struct {
+	Burst int
+	Per   time.Duration
+}
+
+func (l Limit) ratePerSecond() float64 {
+	return float64(l.Burst) / l.Per.Seconds()
+}
+
+// Store keeps token buckets. MemoryStore is enough for a single instance;
+// replicas that must share limits need a Store backed by a shared database.
+type Store interface {
+	// Take consumes one token from the bucket for key. When the bucket is
+	// empty it reports false and how long until a token is available.
+	Take(ctx context.Context, key string, limit Limit) (ok bool, retryAfter time.Duration, err error)
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	limit   Limit
+}
+
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	now       func() time.Time
+	lastSweep time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+func (s *MemoryStore) Take(_ context.Context, key string, limit Limit) (bool, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), updated: now}
+		s.buckets[key] = b
+	}
+	b.limit = limit
+
+	elapsed := now.Sub(b.updated).Seconds()
+	b.tokens = math.Min(float64(limit.Burst), b.tokens+elapsed*limit.ratePerSecond())
+	b.updated = now
+
+	if b.tokens < 1 {
+		wait := (1 - b.tokens) / limit.ratePerSecond()
+		return false, time.Duration(math.Ceil(wait * float64(time.Second))), nil
+	}
+
+	b.tokens--
+	return true, 0, nil
+}
+
+// sweep drops buckets that have refilled completely, since they are
+// indistinguishable from absent ones. It runs at most once a minute.
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+
+	for key, b := range s.buckets {
+		if now.Sub(b.updated) >= b.limit.Per {
+			delete(s.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryStore_Take(t *testing.T) {
+	ctx := context.Background()
+	limit := Limit{Burst: 3, Per: time.Minute}
+
+	t.Run("burst_then_refuse", func(t *testing.T) {
+		store := NewMemoryStore()
+		now := time.Now()
+		store.now = func() time.Time { return now }
+
+		for i := 0; i < 3; i++ {
+			ok, _, err := store.Take(ctx, "k", limit)
+			assert.NoError(t, err)
+			assert.True(t, ok)
+		}
+
+		ok, retryAfter, err := store.Take(ctx, "k", limit)
+		assert.NoError(t, err)
+		assert.False(t, ok)
+		assert.Equal(t, 20*time.Second, retryAfter)
+	})
+
+	t.Run("refills_over_time", func(t *testing.T) {
+		store := NewMemoryStore()
+		now := time.Now()
+		store.now = func() time.Time { return now }
+
+		for i := 0; i < 3; i++ {
+			store.Take(ctx, "k", limit)
+		}
+
+		now = now.Add(20 * time.Second)
+		ok, _, _ := store.Take(ctx, "k", limit)
+		assert.True(t, ok)
+
+		ok, _, _ = store.Take(ctx, "k", limit)
+		assert.False(t, ok)
+	})
+
+	t.Run("keys_are_independent", func(t *testing.T) {
+		store := NewMemoryStore()
+
+		for i := 0; i < 3; i++ {
+			store.Take(ctx, "a", limit)
+		}
+
+		ok, _, _ := store.Take(ctx, "b", limit)
+		assert.True(t, ok)
+	})
+
+	t.Run("full_buckets_are_swept", func(t *testing.T) {
+		store := NewMemoryStore()
+		now := time.Now()
+		store.now = func() time.Time { return now }
+
+		store.Take(ctx, "a", limit)
+		store.Take(ctx, "b", limit)
+
+		now = now.Add(2 * time.Minute)
+		store.Take(ctx, "c", limit)
+
+		assert.Len(t, store.buckets, 1)
+	})
+}
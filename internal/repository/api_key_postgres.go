@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/dvprokofiev/seating-generator-api/internal/models"
+	"github.com/google/uuid"
+)
+
+var ErrAPIKeyNotFound = errors.New("API key not found")
+
+type APIKeyPostgres struct {
+	db *sql.DB
+}
+
+func (r *APIKeyPostgres) Create(ctx context.Context, key *models.APIKey) error {
+	scopes, err := json.Marshal(key.Scopes)
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT INTO api_keys (id, user_id, name, prefix, secret_hash, scopes, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+	_, err = r.db.ExecContext(ctx, query, key.ID, key.UserID, key.Name, key.Prefix, key.SecretHash, scopes, key.CreatedAt, key.ExpiresAt)
+	return err
+}
+
+func (r *APIKeyPostgres) ListByUser(ctx context.Context, userID uuid.UUID) ([]models.APIKey, error) {
+	query := `SELECT id, user_id, name, prefix, secret_hash, scopes, created_at, expires_at, last_used_at
+		FROM api_keys WHERE user_id = $1 ORDER BY created_at`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []models.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, *key)
+	}
+	return keys, rows.Err()
+}
+
+func (r *APIKeyPostgres) GetByPrefix(ctx context.Context, prefix string) (*models.APIKey, error) {
+	query := `SELECT id, user_id, name, prefix, secret_hash, scopes, created_at, expires_at, last_used_at
+		FROM api_keys WHERE prefix = $1`
+
+	return scanAPIKey(r.db.QueryRowContext(ctx, query, prefix))
+}
+
+func (r *APIKeyPostgres) UpdateLastUsed(ctx context.Context, id uuid.UUID, usedAt time.Time) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE api_keys SET last_used_at = $1 WHERE id = $2`, usedAt, id)
+	return err
+}
+
+func (r *APIKeyPostgres) Delete(ctx context.Context, userID, id uuid.UUID) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM api_keys WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrAPIKeyNotFound
+	}
+
+	return nil
+}
+
+// DeleteByUser revokes all keys of the user.
+func (r *APIKeyPostgres) DeleteByUser(ctx context.Context, userID uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM api_keys WHERE user_id = $1`, userID)
+	return err
+}
+
+func scanAPIKey(row rowScanner) (*models.APIKey, error) {
+	var key models.APIKey
+	var scopes []byte
+	var expiresAt, lastUsed sql.NullTime
+
+	err := row.Scan(&key.ID, &key.UserID, &key.Name, &key.Prefix, &key.SecretHash, &scopes, &key.CreatedAt, &expiresAt, &lastUsed)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(scopes, &key.Scopes); err != nil {
+		return nil, err
+	}
+	if expiresAt.Valid {
+		key.ExpiresAt = &expiresAt.Time
+	}
+	if lastUsed.Valid {
+		key.LastUsedAt = &lastUsed.Time
+	}
+	return &key, nil
+}
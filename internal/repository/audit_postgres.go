@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/dvprokofiev/seating-generator-api/internal/models"
+	"github.com/google/uuid"
+)
+
+type AuditPostgres struct {
+	db *sql.DB
+}
+
+func (r *AuditPostgres) Append(ctx context.Context, e *models.AuditEvent) error {
+	var details []byte
+	if len(e.Details) > 0 {
+		var err error
+		if details, err = json.Marshal(e.Details); err != nil {
+			return err
+		}
+	}
+
+	query := `INSERT INTO audit_log
+		(occurred_at, event, success, actor_id, organization_id, target_type, target_id, email, ip_address, user_agent, details)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id`
+
+	return r.db.QueryRowContext(ctx, query,
+		e.OccurredAt, e.Event, e.Success, e.ActorID, e.OrganizationID,
+		e.TargetType, e.TargetID, e.Email, e.IPAddress, e.UserAgent, details,
+	).Scan(&e.ID)
+}
+
+func (r *AuditPostgres) List(ctx context.Context, f models.AuditFilter) ([]models.AuditEvent, error) {
+	query := `SELECT id, occurred_at, event, success, actor_id, organization_id,
+		target_type, target_id, email, ip_address, user_agent, details
+		FROM audit_log WHERE TRUE`
+	var args []any
+
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	if f.OrganizationID != nil {
+		query += " AND organization_id = " + arg(*f.OrganizationID)
+	}
+	if f.ActorID != nil {
+		query += " AND actor_id = " + arg(*f.ActorID)
+	}
+	if f.Email != "" {
+		query += " AND email = " + arg(f.Email)
+	}
+	if f.Event != "" {
+		query += " AND event = " + arg(f.Event)
+	}
+	if f.From != nil {
+		query += " AND occurred_at >= " + arg(*f.From)
+	}
+	if f.To != nil {
+		query += " AND occurred_at < " + arg(*f.To)
+	}
+	if f.BeforeID > 0 {
+		query += " AND id < " + arg(f.BeforeID)
+	}
+	query += " ORDER BY id DESC LIMIT " + arg(f.Limit)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []models.AuditEvent{}
+	for rows.Next() {
+		var e models.AuditEvent
+		var actorID, orgID uuid.NullUUID
+		var details []byte
+
+		err := rows.Scan(&e.ID, &e.OccurredAt, &e.Event, &e.Success, &actorID, &orgID,
+			&e.TargetType, &e.TargetID, &e.Email, &e.IPAddress, &e.UserAgent, &details)
+		if err != nil {
+			return nil, err
+		}
+		if actorID.Valid {
+			e.ActorID = &actorID.UUID
+		}
+		if orgID.Valid {
+			e.OrganizationID = &orgID.UUID
+		}
+		if len(details) > 0 {
+			if err := json.Unmarshal(details, &e.Details); err != nil {
+				return nil, err
+			}
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+type LoginFailurePostgres struct {
+	db *sql.DB
+}
+
+// LockedUntil returns when the lock on email ends, or nil if it is not
+// locked.
+func (r *LoginFailurePostgres) LockedUntil(ctx context.Context, email string) (*time.Time, error) {
+	query := `SELECT locked_until FROM login_failures
+		WHERE email = $1 AND locked_until > NOW()`
+
+	var lockedUntil time.Time
+	err := r.db.QueryRowContext(ctx, query, email).Scan(&lockedUntil)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &lockedUntil, nil
+}
+
+// Increment counts a failed login for email. Failures before forgetBefore
+// no longer count. When the count reaches maxAttempts the email is locked
+// until lockUntil and the count starts over; the returned flag reports
+// whether this call locked it. Forgotten rows of other emails are dropped.
+func (r *LoginFailurePostgres) Increment(ctx context.Context, email string, maxAttempts int, lockUntil, forgetBefore time.Time) (bool, error) {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM login_failures
+		WHERE last_failed_at < $1 AND (locked_until IS NULL OR locked_until < NOW())`, forgetBefore)
+	if err != nil {
+		return false, err
+	}
+
+	query := `INSERT INTO login_failures AS f (email, failed_attempts, last_failed_at, locked_until)
+		VALUES ($1, CASE WHEN $2 <= 1 THEN 0 ELSE 1 END, NOW(), CASE WHEN $2 <= 1 THEN $3::timestamptz END)
+		ON CONFLICT (email) DO UPDATE SET
+			failed_attempts = CASE WHEN f.failed_attempts + 1 >= $2 THEN 0 ELSE f.failed_attempts + 1 END,
+			last_failed_at = NOW(),
+			locked_until = CASE WHEN f.failed_attempts + 1 >= $2 THEN $3 ELSE f.locked_until END
+		RETURNING failed_attempts = 0`
+
+	var locked bool
+	if err := r.db.QueryRowContext(ctx, query, email, maxAttempts, lockUntil).Scan(&locked); err != nil {
+		return false, err
+	}
+	return locked, nil
+}
+
+func (r *LoginFailurePostgres) Reset(ctx context.Context, email string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM login_failures WHERE email = $1`, email)
+	return err
+}
@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/dvprokofiev/seating-generator-api/internal/models"
+)
+
+type MagicLinkPostgres struct {
+	db *sql.DB
+}
+
+// Save stores a link, replacing any earlier link of the same user so that
+// only the latest email works.
+func (r *MagicLinkPostgres) Save(ctx context.Context, link *models.MagicLink) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM magic_links WHERE expires_at < NOW()`); err != nil {
+		return err
+	}
+
+	query := `INSERT INTO magic_links (id, user_id, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id) DO UPDATE SET id = EXCLUDED.id, token_hash = EXCLUDED.token_hash,
+			expires_at = EXCLUDED.expires_at, created_at = EXCLUDED.created_at`
+	_, err := r.db.ExecContext(ctx, query, link.ID, link.UserID, link.TokenHash, link.ExpiresAt, link.CreatedAt)
+	return err
+}
+
+// Take removes and returns an unexpired link, so that each link can be used
+// only once. It returns sql.ErrNoRows otherwise.
+func (r *MagicLinkPostgres) Take(ctx context.Context, tokenHash string) (*models.MagicLink, error) {
+	var link models.MagicLink
+	query := `DELETE FROM magic_links WHERE token_hash = $1 AND expires_at > NOW()
+		RETURNING id, user_id, token_hash, expires_at, created_at`
+
+	err := r.db.QueryRowContext(ctx, query, tokenHash).Scan(&link.ID, &link.UserID, &link.TokenHash, &link.ExpiresAt, &link.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
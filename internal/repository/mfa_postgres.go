@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/dvprokofiev/seating-generator-api/internal/models"
+	"github.com/google/uuid"
+)
+
+var ErrMFAAlreadyEnabled = errors.New("MFA is already enabled")
+
+type MFAPostgres struct {
+	db *sql.DB
+}
+
+func (r *MFAPostgres) Get(ctx context.Context, userID uuid.UUID) (*models.MFASettings, error) {
+	var m models.MFASettings
+	var secret sql.NullString
+	query := `SELECT u.mfa_secret, u.mfa_enabled, u.mfa_last_step, COALESCE(o.require_mfa, FALSE)
+		FROM users u LEFT JOIN organizations o ON o.id = u.organization_id
+		WHERE u.id = $1`
+
+	err := r.db.QueryRowContext(ctx, query, userID).Scan(&secret, &m.Enabled, &m.LastStep, &m.Required)
+	if err != nil {
+		return nil, err
+	}
+	m.Secret = secret.String
+	return &m, nil
+}
+
+// SetPendingSecret stores a new secret awaiting confirmation. It refuses to
+// replace the secret of a confirmed enrollment.
+func (r *MFAPostgres) SetPendingSecret(ctx context.Context, userID uuid.UUID, secret string) error {
+	query := `UPDATE users SET mfa_secret = $1, mfa_last_step = 0 WHERE id = $2 AND NOT mfa_enabled`
+
+	res, err := r.db.ExecContext(ctx, query, secret, userID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrMFAAlreadyEnabled
+	}
+
+	return nil
+}
+
+// Enable confirms the pending enrollment, records step as used and replaces
+// any previous recovery codes, all in one transaction.
+func (r *MFAPostgres) Enable(ctx context.Context, userID uuid.UUID, step int64, recoveryCodeHashes []string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `UPDATE users SET mfa_enabled = TRUE, mfa_last_step = $1
+		WHERE id = $2 AND NOT mfa_enabled AND mfa_secret IS NOT NULL`
+	res, err := tx.ExecContext(ctx, query, step, userID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrMFAAlreadyEnabled
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM mfa_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	for _, hash := range recoveryCodeHashes {
+		query = `INSERT INTO mfa_recovery_codes (user_id, code_hash) VALUES ($1, $2)`
+		if _, err := tx.ExecContext(ctx, query, userID, hash); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// ConsumeStep marks a TOTP time step as used. It reports false if that step
+// or a later one was already used, which makes every code single-use even
+// under concurrent requests.
+func (r *MFAPostgres) ConsumeStep(ctx context.Context, userID uuid.UUID, step int64) (bool, error) {
+	query := `UPDATE users SET mfa_last_step = $1 WHERE id = $2 AND mfa_enabled AND mfa_last_step < $1`
+
+	res, err := r.db.ExecContext(ctx, query, step, userID)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows == 1, nil
+}
+
+// UseRecoveryCode marks an unused recovery code as used and reports whether
+// there was one.
+func (r *MFAPostgres) UseRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string) (bool, error) {
+	query := `UPDATE mfa_recovery_codes SET used_at = NOW()
+		WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL`
+
+	res, err := r.db.ExecContext(ctx, query, userID, codeHash)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows == 1, nil
+}
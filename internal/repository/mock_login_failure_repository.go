@@ -0,0 +1,105 @@
+// Code generated by mockery v2.53.6. DO NOT EDIT.
+
+package repository
+
+import (
+	context "context"
+	time "time"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// MockLoginFailureRepository is an autogenerated mock type for the LoginFailureRepository type
+type MockLoginFailureRepository struct {
+	mock.Mock
+}
+
+// Increment provides a mock function with given fields: ctx, email, maxAttempts, lockUntil, forgetBefore
+func (_m *MockLoginFailureRepository) Increment(ctx context.Context, email string, maxAttempts int, lockUntil time.Time, forgetBefore time.Time) (bool, error) {
+	ret := _m.Called(ctx, email, maxAttempts, lockUntil, forgetBefore)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Increment")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int, time.Time, time.Time) (bool, error)); ok {
+		return rf(ctx, email, maxAttempts, lockUntil, forgetBefore)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, int, time.Time, time.Time) bool); ok {
+		r0 = rf(ctx, email, maxAttempts, lockUntil, forgetBefore)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, int, time.Time, time.Time) error); ok {
+		r1 = rf(ctx, email, maxAttempts, lockUntil, forgetBefore)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// LockedUntil provides a mock function with given fields: ctx, email
+func (_m *MockLoginFailureRepository) LockedUntil(ctx context.Context, email string) (*time.Time, error) {
+	ret := _m.Called(ctx, email)
+
+	if len(ret) == 0 {
+		panic("no return value specified for LockedUntil")
+	}
+
+	var r0 *time.Time
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*time.Time, error)); ok {
+		return rf(ctx, email)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *time.Time); ok {
+		r0 = rf(ctx, email)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*time.Time)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, email)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Reset provides a mock function with given fields: ctx, email
+func (_m *MockLoginFailureRepository) Reset(ctx context.Context, email string) error {
+	ret := _m.Called(ctx, email)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Reset")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, email)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewMockLoginFailureRepository creates a new instance of MockLoginFailureRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockLoginFailureRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockLoginFailureRepository {
+	mock := &MockLoginFailureRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
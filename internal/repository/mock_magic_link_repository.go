@@ -0,0 +1,77 @@
+// Code generated by mockery v2.53.6. DO NOT EDIT.
+
+package repository
+
+import (
+	context "context"
+
+	models "github.com/dvprokofiev/seating-generator-api/internal/models"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// MockMagicLinkRepository is an autogenerated mock type for the MagicLinkRepository type
+type MockMagicLinkRepository struct {
+	mock.Mock
+}
+
+// Save provides a mock function with given fields: ctx, link
+func (_m *MockMagicLinkRepository) Save(ctx context.Context, link *models.MagicLink) error {
+	ret := _m.Called(ctx, link)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Save")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *models.MagicLink) error); ok {
+		r0 = rf(ctx, link)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Take provides a mock function with given fields: ctx, tokenHash
+func (_m *MockMagicLinkRepository) Take(ctx context.Context, tokenHash string) (*models.MagicLink, error) {
+	ret := _m.Called(ctx, tokenHash)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Take")
+	}
+
+	var r0 *models.MagicLink
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*models.MagicLink, error)); ok {
+		return rf(ctx, tokenHash)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *models.MagicLink); ok {
+		r0 = rf(ctx, tokenHash)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.MagicLink)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, tokenHash)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewMockMagicLinkRepository creates a new instance of MockMagicLinkRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockMagicLinkRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockMagicLinkRepository {
+	mock := &MockMagicLinkRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,193 @@
+// Code generated by mockery v2.53.6. DO NOT EDIT.
+
+package repository
+
+import (
+	context "context"
+
+	models "github.com/dvprokofiev/seating-generator-api/internal/models"
+	mock "github.com/stretchr/testify/mock"
+
+	uuid "github.com/google/uuid"
+)
+
+// MockOIDCRepository is an autogenerated mock type for the OIDCRepository type
+type MockOIDCRepository struct {
+	mock.Mock
+}
+
+// CreateUserWithIdentity provides a mock function with given fields: ctx, user, identity
+func (_m *MockOIDCRepository) CreateUserWithIdentity(ctx context.Context, user *models.User, identity *models.ExternalIdentity) error {
+	ret := _m.Called(ctx, user, identity)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateUserWithIdentity")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *models.User, *models.ExternalIdentity) error); ok {
+		r0 = rf(ctx, user, identity)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteProvider provides a mock function with given fields: ctx, orgID
+func (_m *MockOIDCRepository) DeleteProvider(ctx context.Context, orgID uuid.UUID) error {
+	ret := _m.Called(ctx, orgID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteProvider")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) error); ok {
+		r0 = rf(ctx, orgID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetIdentity provides a mock function with given fields: ctx, issuer, subject
+func (_m *MockOIDCRepository) GetIdentity(ctx context.Context, issuer string, subject string) (*models.ExternalIdentity, error) {
+	ret := _m.Called(ctx, issuer, subject)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetIdentity")
+	}
+
+	var r0 *models.ExternalIdentity
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (*models.ExternalIdentity, error)); ok {
+		return rf(ctx, issuer, subject)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *models.ExternalIdentity); ok {
+		r0 = rf(ctx, issuer, subject)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.ExternalIdentity)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, issuer, subject)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetProvider provides a mock function with given fields: ctx, orgID
+func (_m *MockOIDCRepository) GetProvider(ctx context.Context, orgID uuid.UUID) (*models.OIDCProvider, error) {
+	ret := _m.Called(ctx, orgID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetProvider")
+	}
+
+	var r0 *models.OIDCProvider
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) (*models.OIDCProvider, error)); ok {
+		return rf(ctx, orgID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) *models.OIDCProvider); ok {
+		r0 = rf(ctx, orgID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.OIDCProvider)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, orgID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SaveLoginState provides a mock function with given fields: ctx, s
+func (_m *MockOIDCRepository) SaveLoginState(ctx context.Context, s *models.OIDCLoginState) error {
+	ret := _m.Called(ctx, s)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveLoginState")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *models.OIDCLoginState) error); ok {
+		r0 = rf(ctx, s)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SaveProvider provides a mock function with given fields: ctx, p
+func (_m *MockOIDCRepository) SaveProvider(ctx context.Context, p *models.OIDCProvider) error {
+	ret := _m.Called(ctx, p)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveProvider")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *models.OIDCProvider) error); ok {
+		r0 = rf(ctx, p)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// TakeLoginState provides a mock function with given fields: ctx, state
+func (_m *MockOIDCRepository) TakeLoginState(ctx context.Context, state string) (*models.OIDCLoginState, error) {
+	ret := _m.Called(ctx, state)
+
+	if len(ret) == 0 {
+		panic("no return value specified for TakeLoginState")
+	}
+
+	var r0 *models.OIDCLoginState
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*models.OIDCLoginState, error)); ok {
+		return rf(ctx, state)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *models.OIDCLoginState); ok {
+		r0 = rf(ctx, state)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.OIDCLoginState)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, state)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewMockOIDCRepository creates a new instance of MockOIDCRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockOIDCRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockOIDCRepository {
+	mock := &MockOIDCRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
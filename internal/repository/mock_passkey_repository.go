@@ -0,0 +1,195 @@
+// Code generated by mockery v2.53.6. DO NOT EDIT.
+
+package repository
+
+import (
+	context "context"
+
+	models "github.com/dvprokofiev/seating-generator-api/internal/models"
+	mock "github.com/stretchr/testify/mock"
+
+	time "time"
+
+	uuid "github.com/google/uuid"
+)
+
+// MockPasskeyRepository is an autogenerated mock type for the PasskeyRepository type
+type MockPasskeyRepository struct {
+	mock.Mock
+}
+
+// Create provides a mock function with given fields: ctx, pk
+func (_m *MockPasskeyRepository) Create(ctx context.Context, pk *models.Passkey) error {
+	ret := _m.Called(ctx, pk)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Create")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *models.Passkey) error); ok {
+		r0 = rf(ctx, pk)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Delete provides a mock function with given fields: ctx, userID, id
+func (_m *MockPasskeyRepository) Delete(ctx context.Context, userID uuid.UUID, id uuid.UUID) error {
+	ret := _m.Called(ctx, userID, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Delete")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, uuid.UUID) error); ok {
+		r0 = rf(ctx, userID, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetByCredentialID provides a mock function with given fields: ctx, credentialID
+func (_m *MockPasskeyRepository) GetByCredentialID(ctx context.Context, credentialID []byte) (*models.Passkey, error) {
+	ret := _m.Called(ctx, credentialID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetByCredentialID")
+	}
+
+	var r0 *models.Passkey
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []byte) (*models.Passkey, error)); ok {
+		return rf(ctx, credentialID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []byte) *models.Passkey); ok {
+		r0 = rf(ctx, credentialID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Passkey)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []byte) error); ok {
+		r1 = rf(ctx, credentialID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListByUser provides a mock function with given fields: ctx, userID
+func (_m *MockPasskeyRepository) ListByUser(ctx context.Context, userID uuid.UUID) ([]models.Passkey, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListByUser")
+	}
+
+	var r0 []models.Passkey
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) ([]models.Passkey, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) []models.Passkey); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Passkey)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SaveCeremony provides a mock function with given fields: ctx, c
+func (_m *MockPasskeyRepository) SaveCeremony(ctx context.Context, c *models.WebAuthnCeremony) error {
+	ret := _m.Called(ctx, c)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveCeremony")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *models.WebAuthnCeremony) error); ok {
+		r0 = rf(ctx, c)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// TakeCeremony provides a mock function with given fields: ctx, id
+func (_m *MockPasskeyRepository) TakeCeremony(ctx context.Context, id uuid.UUID) (*models.WebAuthnCeremony, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for TakeCeremony")
+	}
+
+	var r0 *models.WebAuthnCeremony
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) (*models.WebAuthnCeremony, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) *models.WebAuthnCeremony); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.WebAuthnCeremony)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateAfterLogin provides a mock function with given fields: ctx, id, credential, usedAt
+func (_m *MockPasskeyRepository) UpdateAfterLogin(ctx context.Context, id uuid.UUID, credential []byte, usedAt time.Time) error {
+	ret := _m.Called(ctx, id, credential, usedAt)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateAfterLogin")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, []byte, time.Time) error); ok {
+		r0 = rf(ctx, id, credential, usedAt)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewMockPasskeyRepository creates a new instance of MockPasskeyRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockPasskeyRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockPasskeyRepository {
+	mock := &MockPasskeyRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,107 @@
+// Code generated by mockery v2.53.6. DO NOT EDIT.
+
+package repository
+
+import (
+	context "context"
+
+	models "github.com/dvprokofiev/seating-generator-api/internal/models"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// MockPasswordResetRepository is an autogenerated mock type for the PasswordResetRepository type
+type MockPasswordResetRepository struct {
+	mock.Mock
+}
+
+// Get provides a mock function with given fields: ctx, tokenHash
+func (_m *MockPasswordResetRepository) Get(ctx context.Context, tokenHash string) (*models.PasswordReset, error) {
+	ret := _m.Called(ctx, tokenHash)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Get")
+	}
+
+	var r0 *models.PasswordReset
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*models.PasswordReset, error)); ok {
+		return rf(ctx, tokenHash)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *models.PasswordReset); ok {
+		r0 = rf(ctx, tokenHash)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.PasswordReset)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, tokenHash)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Save provides a mock function with given fields: ctx, reset
+func (_m *MockPasswordResetRepository) Save(ctx context.Context, reset *models.PasswordReset) error {
+	ret := _m.Called(ctx, reset)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Save")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *models.PasswordReset) error); ok {
+		r0 = rf(ctx, reset)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Take provides a mock function with given fields: ctx, tokenHash
+func (_m *MockPasswordResetRepository) Take(ctx context.Context, tokenHash string) (*models.PasswordReset, error) {
+	ret := _m.Called(ctx, tokenHash)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Take")
+	}
+
+	var r0 *models.PasswordReset
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*models.PasswordReset, error)); ok {
+		return rf(ctx, tokenHash)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *models.PasswordReset); ok {
+		r0 = rf(ctx, tokenHash)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.PasswordReset)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, tokenHash)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewMockPasswordResetRepository creates a new instance of MockPasswordResetRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockPasswordResetRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockPasswordResetRepository {
+	mock := &MockPasswordResetRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/dvprokofiev/seating-generator-api/internal/models"
+	"github.com/google/uuid"
+)
+
+var ErrIdentityLinked = errors.New("External identity is already linked to a user")
+
+type OIDCPostgres struct {
+	db *sql.DB
+}
+
+func (r *OIDCPostgres) GetProvider(ctx context.Context, orgID uuid.UUID) (*models.OIDCProvider, error) {
+	var p models.OIDCProvider
+	query := `SELECT organization_id, issuer, client_id, client_secret, email_domain, updated_at
+		FROM oidc_providers WHERE organization_id = $1`
+
+	err := r.db.QueryRowContext(ctx, query, orgID).
+		Scan(&p.OrganizationID, &p.Issuer, &p.ClientID, &p.ClientSecret, &p.EmailDomain, &p.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// SaveProvider creates or replaces the organization's provider.
+func (r *OIDCPostgres) SaveProvider(ctx context.Context, p *models.OIDCProvider) error {
+	query := `INSERT INTO oidc_providers (organization_id, issuer, client_id, client_secret, email_domain, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (organization_id) DO UPDATE SET issuer = EXCLUDED.issuer, client_id = EXCLUDED.client_id,
+			client_secret = EXCLUDED.client_secret, email_domain = EXCLUDED.email_domain, updated_at = EXCLUDED.updated_at`
+
+	_, err := r.db.ExecContext(ctx, query, p.OrganizationID, p.Issuer, p.ClientID, p.ClientSecret, p.EmailDomain, p.UpdatedAt)
+	return err
+}
+
+func (r *OIDCPostgres) DeleteProvider(ctx context.Context, orgID uuid.UUID) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM oidc_providers WHERE organization_id = $1`, orgID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// SaveLoginState stores a started login and clears out expired ones.
+func (r *OIDCPostgres) SaveLoginState(ctx context.Context, s *models.OIDCLoginState) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM oidc_login_states WHERE expires_at < NOW()`); err != nil {
+		return err
+	}
+
+	query := `INSERT INTO oidc_login_states (state, organization_id, nonce, code_verifier, expires_at) VALUES ($1, $2, $3, $4, $5)`
+	_, err := r.db.ExecContext(ctx, query, s.State, s.OrganizationID, s.Nonce, s.CodeVerifier, s.ExpiresAt)
+	return err
+}
+
+// TakeLoginState removes and returns an unexpired login, so that each
+// authorization response can be redeemed only once. It returns
+// sql.ErrNoRows otherwise.
+func (r *OIDCPostgres) TakeLoginState(ctx context.Context, state string) (*models.OIDCLoginState, error) {
+	var s models.OIDCLoginState
+	query := `DELETE FROM oidc_login_states WHERE state = $1 AND expires_at > NOW()
+		RETURNING state, organization_id, nonce, code_verifier, expires_at`
+
+	err := r.db.QueryRowContext(ctx, query, state).Scan(&s.State, &s.OrganizationID, &s.Nonce, &s.CodeVerifier, &s.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *OIDCPostgres) GetIdentity(ctx context.Context, issuer, subject string) (*models.ExternalIdentity, error) {
+	var id models.ExternalIdentity
+	query := `SELECT issuer, subject, user_id, created_at FROM user_identities WHERE issuer = $1 AND subject = $2`
+
+	err := r.db.QueryRowContext(ctx, query, issuer, subject).Scan(&id.Issuer, &id.Subject, &id.UserID, &id.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+// CreateUserWithIdentity registers a new user, with a verified email, and
+// links the identity to them in one transaction. It never touches an
+// existing account: a taken email is refused with ErrDuplicateEmail and a
+// taken identity with ErrIdentityLinked.
+func (r *OIDCPostgres) CreateUserWithIdentity(ctx context.Context, user *models.User, identity *models.ExternalIdentity) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO users (id, email, password_hash, organization_id, role, created_at, is_verified)
+		VALUES ($1, $2, $3, $4, $5, $6, TRUE)`
+	_, err = tx.ExecContext(ctx, query, user.ID, user.Email, user.PasswordHash, user.OrganizationID, user.Role, user.CreatedAt)
+	if err != nil {
+		if strings.Contains(err.Error(), "unique constraint") || strings.Contains(err.Error(), "23505") {
+			return ErrDuplicateEmail
+		}
+		return err
+	}
+
+	query = `INSERT INTO user_identities (issuer, subject, user_id, created_at) VALUES ($1, $2, $3, $4)`
+	_, err = tx.ExecContext(ctx, query, identity.Issuer, identity.Subject, user.ID, identity.CreatedAt)
+	if err != nil {
+		if strings.Contains(err.Error(), "unique constraint") || strings.Contains(err.Error(), "23505") {
+			return ErrIdentityLinked
+		}
+		return err
+	}
+
+	return tx.Commit()
+}
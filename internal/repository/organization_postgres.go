@@ -0,0 +1,201 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/dvprokofiev/seating-generator-api/internal/models"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrMembershipConflict = errors.New("User already belongs to an organization")
+	ErrMemberNotFound     = errors.New("Member not found")
+)
+
+type OrganizationPostgres struct {
+	db *sql.DB
+}
+
+// Create inserts the organization and makes adminID its first school admin
+// in one transaction, so an organization never exists without an admin.
+func (r *OrganizationPostgres) Create(ctx context.Context, org *models.Organization, adminID uuid.UUID) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO organizations (id, name, created_at) VALUES ($1, $2, $3)`
+	if _, err := tx.ExecContext(ctx, query, org.ID, org.Name, org.CreatedAt); err != nil {
+		return err
+	}
+
+	query = `UPDATE users SET organization_id = $1, role = $2 WHERE id = $3 AND organization_id IS NULL`
+	res, err := tx.ExecContext(ctx, query, org.ID, models.RoleSchoolAdmin, adminID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrMembershipConflict
+	}
+
+	return tx.Commit()
+}
+
+func (r *OrganizationPostgres) GetByID(ctx context.Context, id uuid.UUID) (*models.Organization, error) {
+	var o models.Organization
+	query := `SELECT id, name, require_mfa, created_at FROM organizations WHERE id = $1`
+
+	err := r.db.QueryRowContext(ctx, query, id).Scan(&o.ID, &o.Name, &o.RequireMFA, &o.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
+func (r *OrganizationPostgres) ListMembers(ctx context.Context, orgID uuid.UUID) ([]models.User, error) {
+	query := `SELECT id, email, role, COALESCE(is_verified, FALSE), created_at
+		FROM users WHERE organization_id = $1 ORDER BY email`
+
+	rows, err := r.db.QueryContext(ctx, query, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := []models.User{}
+	for rows.Next() {
+		u := models.User{OrganizationID: &orgID}
+		if err := rows.Scan(&u.ID, &u.Email, &u.Role, &u.IsVerified, &u.CreatedAt); err != nil {
+			return nil, err
+		}
+		members = append(members, u)
+	}
+	return members, rows.Err()
+}
+
+func (r *OrganizationPostgres) RemoveMember(ctx context.Context, orgID, userID uuid.UUID) error {
+	query := `UPDATE users SET organization_id = NULL, role = $1 WHERE id = $2 AND organization_id = $3`
+
+	res, err := r.db.ExecContext(ctx, query, models.RoleTeacher, userID, orgID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrMemberNotFound
+	}
+
+	return nil
+}
+
+func (r *OrganizationPostgres) SetRequireMFA(ctx context.Context, orgID uuid.UUID, required bool) error {
+	query := `UPDATE organizations SET require_mfa = $1 WHERE id = $2`
+
+	res, err := r.db.ExecContext(ctx, query, required, orgID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// SaveInvitation stores the invitation, replacing an earlier one for the
+// same email so that a repeated invite renews its role and expiry.
+func (r *OrganizationPostgres) SaveInvitation(ctx context.Context, inv *models.Invitation) error {
+	query := `INSERT INTO organization_invitations (id, organization_id, email, role, invited_by, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (organization_id, email) DO UPDATE
+		SET id = EXCLUDED.id, role = EXCLUDED.role, invited_by = EXCLUDED.invited_by,
+			expires_at = EXCLUDED.expires_at, created_at = EXCLUDED.created_at`
+
+	_, err := r.db.ExecContext(ctx, query, inv.ID, inv.OrganizationID, inv.Email, inv.Role, inv.InvitedBy, inv.ExpiresAt, inv.CreatedAt)
+	return err
+}
+
+// ListInvitations returns the unexpired invitations sent to email, newest
+// first.
+func (r *OrganizationPostgres) ListInvitations(ctx context.Context, email string) ([]models.Invitation, error) {
+	query := `SELECT i.id, i.organization_id, o.name, i.email, i.role, i.invited_by, i.expires_at, i.created_at
+		FROM organization_invitations i JOIN organizations o ON o.id = i.organization_id
+		WHERE i.email = $1 AND i.expires_at > $2 ORDER BY i.created_at DESC`
+
+	rows, err := r.db.QueryContext(ctx, query, email, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	invitations := []models.Invitation{}
+	for rows.Next() {
+		var inv models.Invitation
+		err := rows.Scan(&inv.ID, &inv.OrganizationID, &inv.OrganizationName, &inv.Email, &inv.Role,
+			&inv.InvitedBy, &inv.ExpiresAt, &inv.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		invitations = append(invitations, inv)
+	}
+	return invitations, rows.Err()
+}
+
+// AcceptInvitation redeems an unexpired invitation sent to email and makes
+// userID a member with the invited role, in one transaction. A missing,
+// expired or someone else's invitation is sql.ErrNoRows; a user who already
+// belongs to an organization gets ErrMembershipConflict and keeps the
+// invitation.
+func (r *OrganizationPostgres) AcceptInvitation(ctx context.Context, id, userID uuid.UUID, email string) (*models.Invitation, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var inv models.Invitation
+	query := `DELETE FROM organization_invitations WHERE id = $1 AND email = $2 AND expires_at > $3
+		RETURNING id, organization_id, email, role, invited_by, expires_at, created_at`
+	err = tx.QueryRowContext(ctx, query, id, email, time.Now().UTC()).Scan(&inv.ID, &inv.OrganizationID,
+		&inv.Email, &inv.Role, &inv.InvitedBy, &inv.ExpiresAt, &inv.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	query = `UPDATE users SET organization_id = $1, role = $2 WHERE id = $3 AND organization_id IS NULL`
+	res, err := tx.ExecContext(ctx, query, inv.OrganizationID, inv.Role, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if rows == 0 {
+		return nil, ErrMembershipConflict
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &inv, nil
+}
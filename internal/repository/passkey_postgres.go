@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/dvprokofiev/seating-generator-api/internal/models"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrPasskeyNotFound     = errors.New("Passkey not found")
+	ErrDuplicateCredential = errors.New("Passkey is already registered")
+)
+
+type PasskeyPostgres struct {
+	db *sql.DB
+}
+
+func (r *PasskeyPostgres) Create(ctx context.Context, pk *models.Passkey) error {
+	query := `INSERT INTO passkeys (id, user_id, credential_id, name, credential, created_at) VALUES ($1, $2, $3, $4, $5, $6)`
+
+	_, err := r.db.ExecContext(ctx, query, pk.ID, pk.UserID, pk.CredentialID, pk.Name, pk.Credential, pk.CreatedAt)
+	if err != nil {
+		if strings.Contains(err.Error(), "unique constraint") || strings.Contains(err.Error(), "23505") {
+			return ErrDuplicateCredential
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *PasskeyPostgres) ListByUser(ctx context.Context, userID uuid.UUID) ([]models.Passkey, error) {
+	query := `SELECT id, user_id, credential_id, name, credential, created_at, last_used_at
+		FROM passkeys WHERE user_id = $1 ORDER BY created_at`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	passkeys := []models.Passkey{}
+	for rows.Next() {
+		pk, err := scanPasskey(rows)
+		if err != nil {
+			return nil, err
+		}
+		passkeys = append(passkeys, *pk)
+	}
+	return passkeys, rows.Err()
+}
+
+func (r *PasskeyPostgres) GetByCredentialID(ctx context.Context, credentialID []byte) (*models.Passkey, error) {
+	query := `SELECT id, user_id, credential_id, name, credential, created_at, last_used_at
+		FROM passkeys WHERE credential_id = $1`
+
+	return scanPasskey(r.db.QueryRowContext(ctx, query, credentialID))
+}
+
+// UpdateAfterLogin stores the credential record as updated by a successful
+// login, which carries the new signature counter.
+func (r *PasskeyPostgres) UpdateAfterLogin(ctx context.Context, id uuid.UUID, credential []byte, usedAt time.Time) error {
+	query := `UPDATE passkeys SET credential = $1, last_used_at = $2 WHERE id = $3`
+
+	_, err := r.db.ExecContext(ctx, query, credential, usedAt, id)
+	return err
+}
+
+func (r *PasskeyPostgres) Delete(ctx context.Context, userID, id uuid.UUID) error {
+	query := `DELETE FROM passkeys WHERE id = $1 AND user_id = $2`
+
+	res, err := r.db.ExecContext(ctx, query, id, userID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrPasskeyNotFound
+	}
+
+	return nil
+}
+
+// SaveCeremony stores a started ceremony and clears out expired ones.
+func (r *PasskeyPostgres) SaveCeremony(ctx context.Context, c *models.WebAuthnCeremony) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM webauthn_ceremonies WHERE expires_at < NOW()`); err != nil {
+		return err
+	}
+
+	query := `INSERT INTO webauthn_ceremonies (id, user_id, kind, session, expires_at) VALUES ($1, $2, $3, $4, $5)`
+	_, err := r.db.ExecContext(ctx, query, c.ID, c.UserID, c.Kind, c.Session, c.ExpiresAt)
+	return err
+}
+
+// TakeCeremony removes and returns an unexpired ceremony, so that each
+// challenge can be answered only once. It returns sql.ErrNoRows otherwise.
+func (r *PasskeyPostgres) TakeCeremony(ctx context.Context, id uuid.UUID) (*models.WebAuthnCeremony, error) {
+	var c models.WebAuthnCeremony
+	var userID uuid.NullUUID
+	query := `DELETE FROM webauthn_ceremonies WHERE id = $1 AND expires_at > NOW()
+		RETURNING id, user_id, kind, session, expires_at`
+
+	err := r.db.QueryRowContext(ctx, query, id).Scan(&c.ID, &userID, &c.Kind, &c.Session, &c.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	if userID.Valid {
+		c.UserID = &userID.UUID
+	}
+	return &c, nil
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanPasskey(row rowScanner) (*models.Passkey, error) {
+	var pk models.Passkey
+	var lastUsed sql.NullTime
+
+	err := row.Scan(&pk.ID, &pk.UserID, &pk.CredentialID, &pk.Name, &pk.Credential, &pk.CreatedAt, &lastUsed)
+	if err != nil {
+		return nil, err
+	}
+	if lastUsed.Valid {
+		pk.LastUsedAt = &lastUsed.Time
+	}
+	return &pk, nil
+}
@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/dvprokofiev/seating-generator-api/internal/models"
+)
+
+type PasswordResetPostgres struct {
+	db *sql.DB
+}
+
+// Save stores a link, replacing any earlier link of the same user so that
+// only the latest email works.
+func (r *PasswordResetPostgres) Save(ctx context.Context, reset *models.PasswordReset) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM password_resets WHERE expires_at < NOW()`); err != nil {
+		return err
+	}
+
+	query := `INSERT INTO password_resets (id, user_id, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id) DO UPDATE SET id = EXCLUDED.id, token_hash = EXCLUDED.token_hash,
+			expires_at = EXCLUDED.expires_at, created_at = EXCLUDED.created_at`
+	_, err := r.db.ExecContext(ctx, query, reset.ID, reset.UserID, reset.TokenHash, reset.ExpiresAt, reset.CreatedAt)
+	return err
+}
+
+// Get returns an unexpired link without using it up. It returns
+// sql.ErrNoRows otherwise.
+func (r *PasswordResetPostgres) Get(ctx context.Context, tokenHash string) (*models.PasswordReset, error) {
+	var reset models.PasswordReset
+	query := `SELECT id, user_id, token_hash, expires_at, created_at
+		FROM password_resets WHERE token_hash = $1 AND expires_at > NOW()`
+
+	err := r.db.QueryRowContext(ctx, query, tokenHash).Scan(&reset.ID, &reset.UserID, &reset.TokenHash, &reset.ExpiresAt, &reset.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &reset, nil
+}
+
+// Take removes and returns an unexpired link, so that each link can be used
+// only once. It returns sql.ErrNoRows otherwise.
+func (r *PasswordResetPostgres) Take(ctx context.Context, tokenHash string) (*models.PasswordReset, error) {
+	var reset models.PasswordReset
+	query := `DELETE FROM password_resets WHERE token_hash = $1 AND expires_at > NOW()
+		RETURNING id, user_id, token_hash, expires_at, created_at`
+
+	err := r.db.QueryRowContext(ctx, query, tokenHash).Scan(&reset.ID, &reset.UserID, &reset.TokenHash, &reset.ExpiresAt, &reset.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &reset, nil
+}
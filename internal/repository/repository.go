@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"time"
 
 	"github.com/dvprokofiev/seating-generator-api/internal/models"
 	"github.com/google/uuid"
@@ -13,15 +14,139 @@ import (
 type UserRepository interface {
 	Create(ctx context.Context, user *models.User) error
 	GetByEmail(ctx context.Context, email string) (*models.User, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*models.User, error)
 	UpdateVerified(ctx context.Context, userID uuid.UUID, isVerified bool) error
+	UpdatePassword(ctx context.Context, userID uuid.UUID, passwordHash string) error
+	Search(ctx context.Context, filter models.UserFilter) ([]models.User, error)
+	SetDisabled(ctx context.Context, userID uuid.UUID, disabledAt *time.Time) error
+	SetPasswordResetRequired(ctx context.Context, userID uuid.UUID, required bool) error
+}
+
+//go:generate mockery --name=LoginFailureRepository --inpackage --case=snake
+
+type LoginFailureRepository interface {
+	LockedUntil(ctx context.Context, email string) (*time.Time, error)
+	Increment(ctx context.Context, email string, maxAttempts int, lockUntil, forgetBefore time.Time) (bool, error)
+	Reset(ctx context.Context, email string) error
+}
+
+//go:generate mockery --name=OrganizationRepository --inpackage --case=snake
+
+type OrganizationRepository interface {
+	Create(ctx context.Context, org *models.Organization, adminID uuid.UUID) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Organization, error)
+	ListMembers(ctx context.Context, orgID uuid.UUID) ([]models.User, error)
+	RemoveMember(ctx context.Context, orgID, userID uuid.UUID) error
+	SetRequireMFA(ctx context.Context, orgID uuid.UUID, required bool) error
+	SaveInvitation(ctx context.Context, inv *models.Invitation) error
+	ListInvitations(ctx context.Context, email string) ([]models.Invitation, error)
+	AcceptInvitation(ctx context.Context, id, userID uuid.UUID, email string) (*models.Invitation, error)
+}
+
+//go:generate mockery --name=MFARepository --inpackage --case=snake
+
+type MFARepository interface {
+	Get(ctx context.Context, userID uuid.UUID) (*models.MFASettings, error)
+	SetPendingSecret(ctx context.Context, userID uuid.UUID, secret string) error
+	Enable(ctx context.Context, userID uuid.UUID, step int64, recoveryCodeHashes []string) error
+	ConsumeStep(ctx context.Context, userID uuid.UUID, step int64) (bool, error)
+	UseRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string) (bool, error)
+}
+
+//go:generate mockery --name=AuditRepository --inpackage --case=snake
+
+type AuditRepository interface {
+	Append(ctx context.Context, event *models.AuditEvent) error
+	List(ctx context.Context, filter models.AuditFilter) ([]models.AuditEvent, error)
+}
+
+//go:generate mockery --name=PasskeyRepository --inpackage --case=snake
+
+type PasskeyRepository interface {
+	Create(ctx context.Context, pk *models.Passkey) error
+	ListByUser(ctx context.Context, userID uuid.UUID) ([]models.Passkey, error)
+	GetByCredentialID(ctx context.Context, credentialID []byte) (*models.Passkey, error)
+	UpdateAfterLogin(ctx context.Context, id uuid.UUID, credential []byte, usedAt time.Time) error
+	Delete(ctx context.Context, userID, id uuid.UUID) error
+	SaveCeremony(ctx context.Context, c *models.WebAuthnCeremony) error
+	TakeCeremony(ctx context.Context, id uuid.UUID) (*models.WebAuthnCeremony, error)
+}
+
+//go:generate mockery --name=OIDCRepository --inpackage --case=snake
+
+type OIDCRepository interface {
+	GetProvider(ctx context.Context, orgID uuid.UUID) (*models.OIDCProvider, error)
+	SaveProvider(ctx context.Context, p *models.OIDCProvider) error
+	DeleteProvider(ctx context.Context, orgID uuid.UUID) error
+	SaveLoginState(ctx context.Context, s *models.OIDCLoginState) error
+	TakeLoginState(ctx context.Context, state string) (*models.OIDCLoginState, error)
+	GetIdentity(ctx context.Context, issuer, subject string) (*models.ExternalIdentity, error)
+	CreateUserWithIdentity(ctx context.Context, user *models.User, identity *models.ExternalIdentity) error
+}
+
+//go:generate mockery --name=APIKeyRepository --inpackage --case=snake
+
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *models.APIKey) error
+	ListByUser(ctx context.Context, userID uuid.UUID) ([]models.APIKey, error)
+	GetByPrefix(ctx context.Context, prefix string) (*models.APIKey, error)
+	UpdateLastUsed(ctx context.Context, id uuid.UUID, usedAt time.Time) error
+	Delete(ctx context.Context, userID, id uuid.UUID) error
+	DeleteByUser(ctx context.Context, userID uuid.UUID) error
+}
+
+//go:generate mockery --name=SessionRepository --inpackage --case=snake
+
+type SessionRepository interface {
+	Create(ctx context.Context, s *models.Session) error
+	Get(ctx context.Context, id uuid.UUID) (*models.Session, error)
+	ListByUser(ctx context.Context, userID uuid.UUID) ([]models.Session, error)
+	UpdateLastSeen(ctx context.Context, id uuid.UUID, seenAt time.Time) error
+	Delete(ctx context.Context, userID, id uuid.UUID) error
+	DeleteByUser(ctx context.Context, userID uuid.UUID) error
+}
+
+//go:generate mockery --name=MagicLinkRepository --inpackage --case=snake
+
+type MagicLinkRepository interface {
+	Save(ctx context.Context, link *models.MagicLink) error
+	Take(ctx context.Context, tokenHash string) (*models.MagicLink, error)
+}
+
+//go:generate mockery --name=PasswordResetRepository --inpackage --case=snake
+
+type PasswordResetRepository interface {
+	Save(ctx context.Context, reset *models.PasswordReset) error
+	Get(ctx context.Context, tokenHash string) (*models.PasswordReset, error)
+	Take(ctx context.Context, tokenHash string) (*models.PasswordReset, error)
 }
 
 type Repository struct {
-	Users UserRepository
+	Users          UserRepository
+	LoginFailures  LoginFailureRepository
+	Organizations  OrganizationRepository
+	Audit          AuditRepository
+	MFA            MFARepository
+	Passkeys       PasskeyRepository
+	OIDC           OIDCRepository
+	APIKeys        APIKeyRepository
+	Sessions       SessionRepository
+	MagicLinks     MagicLinkRepository
+	PasswordResets PasswordResetRepository
 }
 
 func NewRepository(db *sql.DB) *Repository {
 	return &Repository{
-		Users: &UserPostgres{db: db},
+		Users:          &UserPostgres{db: db},
+		LoginFailures:  &LoginFailurePostgres{db: db},
+		Organizations:  &OrganizationPostgres{db: db},
+		Audit:          &AuditPostgres{db: db},
+		MFA:            &MFAPostgres{db: db},
+		Passkeys:       &PasskeyPostgres{db: db},
+		OIDC:           &OIDCPostgres{db: db},
+		APIKeys:        &APIKeyPostgres{db: db},
+		Sessions:       &SessionPostgres{db: db},
+		MagicLinks:     &MagicLinkPostgres{db: db},
+		PasswordResets: &PasswordResetPostgres{db: db},
 	}
 }
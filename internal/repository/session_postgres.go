@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/dvprokofiev/seating-generator-api/internal/models"
+	"github.com/google/uuid"
+)
+
+var ErrSessionNotFound = errors.New("Session not found")
+
+type SessionPostgres struct {
+	db *sql.DB
+}
+
+// Create stores a new session and drops the user's expired ones.
+func (r *SessionPostgres) Create(ctx context.Context, s *models.Session) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM sessions WHERE user_id = $1 AND expires_at < NOW()`, s.UserID)
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT INTO sessions (id, user_id, user_agent, ip_address, created_at, last_seen_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	_, err = r.db.ExecContext(ctx, query, s.ID, s.UserID, s.UserAgent, s.IPAddress, s.CreatedAt, s.LastSeenAt, s.ExpiresAt)
+	return err
+}
+
+// Get returns an unexpired session or sql.ErrNoRows.
+func (r *SessionPostgres) Get(ctx context.Context, id uuid.UUID) (*models.Session, error) {
+	query := `SELECT id, user_id, user_agent, ip_address, created_at, last_seen_at, expires_at
+		FROM sessions WHERE id = $1 AND expires_at > NOW()`
+
+	return scanSession(r.db.QueryRowContext(ctx, query, id))
+}
+
+// ListByUser returns the user's unexpired sessions, most recently used first.
+func (r *SessionPostgres) ListByUser(ctx context.Context, userID uuid.UUID) ([]models.Session, error) {
+	query := `SELECT id, user_id, user_agent, ip_address, created_at, last_seen_at, expires_at
+		FROM sessions WHERE user_id = $1 AND expires_at > NOW() ORDER BY last_seen_at DESC`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []models.Session{}
+	for rows.Next() {
+		s, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, *s)
+	}
+	return sessions, rows.Err()
+}
+
+func (r *SessionPostgres) UpdateLastSeen(ctx context.Context, id uuid.UUID, seenAt time.Time) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE sessions SET last_seen_at = $1 WHERE id = $2`, seenAt, id)
+	return err
+}
+
+func (r *SessionPostgres) Delete(ctx context.Context, userID, id uuid.UUID) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM sessions WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrSessionNotFound
+	}
+
+	return nil
+}
+
+// DeleteByUser signs the user out everywhere.
+func (r *SessionPostgres) DeleteByUser(ctx context.Context, userID uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM sessions WHERE user_id = $1`, userID)
+	return err
+}
+
+func scanSession(row rowScanner) (*models.Session, error) {
+	var s models.Session
+	err := row.Scan(&s.ID, &s.UserID, &s.UserAgent, &s.IPAddress, &s.CreatedAt, &s.LastSeenAt, &s.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
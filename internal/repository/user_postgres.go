@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"errors"
 	"strings"
+	"time"
 
 	"github.com/dvprokofiev/seating-generator-api/internal/models"
 	"github.com/google/uuid"
@@ -17,9 +18,9 @@ type UserPostgres struct {
 }
 
 func (r *UserPostgres) Create(ctx context.Context, user *models.User) error {
-	query := `INSERT INTO users (id, email, password_hash, created_at) VALUES ($1, $2, $3, $4)`
+	query := `INSERT INTO users (id, email, password_hash, organization_id, role, created_at) VALUES ($1, $2, $3, $4, $5, $6)`
 
-	_, err := r.db.ExecContext(ctx, query, user.ID, user.Email, user.PasswordHash, user.CreatedAt)
+	_, err := r.db.ExecContext(ctx, query, user.ID, user.Email, user.PasswordHash, user.OrganizationID, user.Role, user.CreatedAt)
 	if err != nil {
 		if strings.Contains(err.Error(), "unique constraint") || strings.Contains(err.Error(), "23505") {
 			return ErrDuplicateEmail
@@ -29,15 +30,67 @@ func (r *UserPostgres) Create(ctx context.Context, user *models.User) error {
 	return nil
 }
 
+const userColumns = `id, email, password_hash, organization_id, role, created_at, is_verified,
+	is_operator, disabled_at, password_reset_required`
+
 func (r *UserPostgres) GetByEmail(ctx context.Context, email string) (*models.User, error) {
-	var u models.User
-	query := `SELECT id, email, password_hash FROM users WHERE email = $1`
+	query := `SELECT ` + userColumns + ` FROM users WHERE email = $1`
 
-	err := r.db.QueryRowContext(ctx, query, email).Scan(&u.ID, &u.Email, &u.PasswordHash)
+	return scanUser(r.db.QueryRowContext(ctx, query, email))
+}
 
+func (r *UserPostgres) GetByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	query := `SELECT ` + userColumns + ` FROM users WHERE id = $1`
+
+	return scanUser(r.db.QueryRowContext(ctx, query, id))
+}
+
+// Search returns a page of users whose email starts with the filter's
+// prefix. The prefix is matched literally, so % and _ in it match only
+// themselves.
+func (r *UserPostgres) Search(ctx context.Context, filter models.UserFilter) ([]models.User, error) {
+	pattern := likeEscaper.Replace(filter.EmailPrefix) + "%"
+	query := `SELECT ` + userColumns + ` FROM users
+		WHERE email LIKE $1 AND email > $2 ORDER BY email LIMIT $3`
+
+	rows, err := r.db.QueryContext(ctx, query, pattern, filter.After, filter.Limit)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
+
+	users := []models.User{}
+	for rows.Next() {
+		u, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, *u)
+	}
+	return users, rows.Err()
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func scanUser(row rowScanner) (*models.User, error) {
+	var u models.User
+	var orgID uuid.NullUUID
+	var createdAt, disabledAt sql.NullTime
+	var isVerified sql.NullBool
+
+	err := row.Scan(&u.ID, &u.Email, &u.PasswordHash, &orgID, &u.Role, &createdAt, &isVerified,
+		&u.IsOperator, &disabledAt, &u.PasswordResetRequired)
+	if err != nil {
+		return nil, err
+	}
+	if orgID.Valid {
+		u.OrganizationID = &orgID.UUID
+	}
+	u.CreatedAt = createdAt.Time
+	u.IsVerified = isVerified.Bool
+	if disabledAt.Valid {
+		u.DisabledAt = &disabledAt.Time
+	}
 	return &u, nil
 }
 
@@ -59,3 +112,58 @@ func (r *UserPostgres) UpdateVerified(ctx context.Context, userID uuid.UUID, isV
 
 	return nil
 }
+
+func (r *UserPostgres) UpdatePassword(ctx context.Context, userID uuid.UUID, passwordHash string) error {
+	query := `UPDATE users SET password_hash = $1 WHERE id = $2`
+
+	res, err := r.db.ExecContext(ctx, query, passwordHash, userID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errors.New("User not found")
+	}
+
+	return nil
+}
+
+// SetDisabled disables the account as of disabledAt, or enables it again
+// when disabledAt is nil.
+func (r *UserPostgres) SetDisabled(ctx context.Context, userID uuid.UUID, disabledAt *time.Time) error {
+	res, err := r.db.ExecContext(ctx, `UPDATE users SET disabled_at = $1 WHERE id = $2`, disabledAt, userID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errors.New("User not found")
+	}
+
+	return nil
+}
+
+func (r *UserPostgres) SetPasswordResetRequired(ctx context.Context, userID uuid.UUID, required bool) error {
+	res, err := r.db.ExecContext(ctx, `UPDATE users SET password_reset_required = $1 WHERE id = $2`, required, userID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errors.New("User not found")
+	}
+
+	return nil
+}
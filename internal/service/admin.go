@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/dvprokofiev/seating-generator-api/internal/models"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrAccountDisabled   = errors.New("Account is disabled")
+	ErrCannotDisableSelf = errors.New("Operators cannot disable their own account")
+	ErrCannotImpersonate = errors.New("Operators cannot be impersonated")
+)
+
+const (
+	defaultUserPageSize = 50
+	maxUserPageSize     = 200
+)
+
+// managedUser resolves the target of an operator action.
+func (s *authService) managedUser(ctx context.Context, id uuid.UUID) (Principal, *models.User, error) {
+	p, err := authorize(ctx, s.policy, ActionUsersManage, uuid.Nil)
+	if err != nil {
+		return Principal{}, nil, err
+	}
+
+	user, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Principal{}, nil, ErrUserNotFound
+		}
+		return Principal{}, nil, err
+	}
+	return p, user, nil
+}
+
+// recordAdminAction audits an operator action under the organization of
+// the affected user, so that its school admins see it too.
+func (s *authService) recordAdminAction(ctx context.Context, event models.AuditEventType, user *models.User) {
+	s.audit.Record(ctx, models.AuditEvent{
+		Event:          event,
+		Success:        true,
+		OrganizationID: user.OrganizationID,
+		TargetType:     "user",
+		TargetID:       user.ID.String(),
+		Email:          user.Email,
+	})
+}
+
+// signOutEverywhere ends all sessions of the user. Without session
+// tracking, tokens already issued stay valid until ParseToken sees the
+// account change.
+func (s *authService) signOutEverywhere(ctx context.Context, userID uuid.UUID) {
+	if s.sessions == nil {
+		return
+	}
+	if err := s.sessions.DeleteByUser(ctx, userID); err != nil {
+		log.Printf("Session error: failed to sign out user %s: %v", userID, err)
+	}
+}
+
+// SearchUsers returns a page of users whose email starts with the filter's
+// prefix, ordered by email.
+func (s *authService) SearchUsers(ctx context.Context, filter models.UserFilter) ([]models.User, error) {
+	if _, err := authorize(ctx, s.policy, ActionUsersManage, uuid.Nil); err != nil {
+		return nil, err
+	}
+
+	filter.EmailPrefix = strings.ToLower(filter.EmailPrefix)
+	if filter.Limit <= 0 {
+		filter.Limit = defaultUserPageSize
+	}
+	if filter.Limit > maxUserPageSize {
+		filter.Limit = maxUserPageSize
+	}
+
+	return s.repo.Search(ctx, filter)
+}
+
+// SetUserDisabled disables or enables an account. A disabled user cannot
+// sign in, and their access tokens and API keys stop working.
+func (s *authService) SetUserDisabled(ctx context.Context, id uuid.UUID, disabled bool) (*models.User, error) {
+	p, user, err := s.managedUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if disabled && user.ID == p.UserID {
+		return nil, ErrCannotDisableSelf
+	}
+
+	event := models.AuditUserEnable
+	user.DisabledAt = nil
+	if disabled {
+		event = models.AuditUserDisable
+		now := time.Now().UTC()
+		user.DisabledAt = &now
+	}
+	if err := s.repo.SetDisabled(ctx, user.ID, user.DisabledAt); err != nil {
+		return nil, err
+	}
+	if disabled {
+		s.signOutEverywhere(ctx, user.ID)
+	}
+
+	s.recordAdminAction(ctx, event, user)
+	return user, nil
+}
+
+// ForcePasswordReset makes the user choose a new password before they can
+// sign in again, e.g. after their password leaked. It ends their sessions,
+// revokes their API keys and emails a reset link to their address, which
+// therefore has to be verified: knowing the old password is not enough to
+// finish the reset.
+func (s *authService) ForcePasswordReset(ctx context.Context, id uuid.UUID) error {
+	if s.passwordResets == nil || s.mailer == nil {
+		return ErrPasswordResetsNotConfigured
+	}
+	_, user, err := s.managedUser(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !user.IsVerified {
+		return ErrEmailNotVerified
+	}
+
+	if err := s.repo.SetPasswordResetRequired(ctx, user.ID, true); err != nil {
+		return err
+	}
+	s.signOutEverywhere(ctx, user.ID)
+	if s.apiKeys != nil {
+		if err := s.apiKeys.DeleteByUser(ctx, user.ID); err != nil {
+			return err
+		}
+	}
+	s.recordAdminAction(ctx, models.AuditUserResetPassword, user)
+
+	return s.sendPasswordResetLink(ctx, user)
+}
+
+// SetUserVerified marks the user's email address verified, e.g. after
+// support checked it by other means.
+func (s *authService) SetUserVerified(ctx context.Context, id uuid.UUID) error {
+	_, user, err := s.managedUser(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.UpdateVerified(ctx, user.ID, true); err != nil {
+		return err
+	}
+
+	s.recordAdminAction(ctx, models.AuditUserVerify, user)
+	return nil
+}
+
+// Impersonation is an access token an operator got to act as a user.
+// ExpiresIn is in seconds.
+type Impersonation struct {
+	Token     string `json:"token"`
+	ExpiresIn int    `json:"expires_in"`
+}
+
+// ImpersonateUser returns a short-lived access token that lets an operator
+// act as the user for support. The token names the operator, every audit
+// event made with it records them, and it cannot be used to manage the
+// user's credentials.
+func (s *authService) ImpersonateUser(ctx context.Context, id uuid.UUID) (*Impersonation, error) {
+	p, user, err := s.managedUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if user.IsOperator {
+		return nil, ErrCannotImpersonate
+	}
+
+	token, err := s.issueAccessToken(ctx, user, &p.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.recordAdminAction(ctx, models.AuditImpersonate, user)
+	return &Impersonation{Token: token, ExpiresIn: int(impersonationTokenTTL.Seconds())}, nil
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dvprokofiev/seating-generator-api/internal/models"
+	"github.com/dvprokofiev/seating-generator-api/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthService_Admin_Integration(t *testing.T) {
+	_, err := testDB.Exec("TRUNCATE users CASCADE")
+	require.NoError(t, err)
+
+	repos := repository.NewRepository(testDB)
+	mail := &recordingMailer{}
+	svc := NewAuthService(repos.Users, "test-secret", WithSessions(repos.Sessions), WithAPIKeys(repos.APIKeys),
+		WithMailer(mail), WithPasswordResets(repos.PasswordResets, "https://seating.test/password/reset"))
+	ctx := context.Background()
+
+	for _, email := range []string{"ops@seating.test", "a_b@school.test", "axb@school.test", "teacher@school.test"} {
+		require.NoError(t, svc.Register(ctx, email, "password123"))
+	}
+	_, err = testDB.Exec("UPDATE users SET is_operator = TRUE WHERE email = 'ops@seating.test'")
+	require.NoError(t, err)
+
+	result, err := svc.Login(ctx, "ops@seating.test", "password123")
+	require.NoError(t, err)
+	p, err := svc.ParseToken(ctx, result.Token)
+	require.NoError(t, err)
+	opsCtx := ContextWithPrincipal(ctx, *p)
+
+	teacher, err := repos.Users.GetByEmail(ctx, "teacher@school.test")
+	require.NoError(t, err)
+
+	t.Run("prefix_is_matched_literally", func(t *testing.T) {
+		users, err := svc.SearchUsers(opsCtx, models.UserFilter{EmailPrefix: "a_"})
+		require.NoError(t, err)
+		require.Len(t, users, 1)
+		assert.Equal(t, "a_b@school.test", users[0].Email)
+	})
+
+	t.Run("pagination_cursor", func(t *testing.T) {
+		first, err := svc.SearchUsers(opsCtx, models.UserFilter{Limit: 2})
+		require.NoError(t, err)
+		require.Len(t, first, 2)
+
+		rest, err := svc.SearchUsers(opsCtx, models.UserFilter{After: first[1].Email})
+		require.NoError(t, err)
+		assert.Len(t, rest, 2)
+		assert.Greater(t, rest[0].Email, first[1].Email)
+	})
+
+	t.Run("disabled_user_is_signed_out", func(t *testing.T) {
+		teacherLogin, err := svc.Login(ctx, teacher.Email, "password123")
+		require.NoError(t, err)
+
+		_, err = svc.SetUserDisabled(opsCtx, teacher.ID, true)
+		require.NoError(t, err)
+
+		_, err = svc.ParseToken(ctx, teacherLogin.Token)
+		assert.ErrorIs(t, err, ErrInvalidToken)
+		_, err = svc.Login(ctx, teacher.Email, "password123")
+		assert.ErrorIs(t, err, ErrAccountDisabled)
+
+		_, err = svc.SetUserDisabled(opsCtx, teacher.ID, false)
+		require.NoError(t, err)
+		_, err = svc.Login(ctx, teacher.Email, "password123")
+		assert.NoError(t, err)
+	})
+
+	t.Run("verify_and_force_reset", func(t *testing.T) {
+		teacherLogin, err := svc.Login(ctx, teacher.Email, "password123")
+		require.NoError(t, err)
+		teacherPrincipal, err := svc.ParseToken(ctx, teacherLogin.Token)
+		require.NoError(t, err)
+		_, err = svc.CreateAPIKey(ContextWithPrincipal(ctx, *teacherPrincipal), "sync", []models.APIKeyScope{models.ScopeOrganizationRead}, nil)
+		require.NoError(t, err)
+
+		assert.ErrorIs(t, svc.ForcePasswordReset(opsCtx, teacher.ID), ErrEmailNotVerified)
+		require.NoError(t, svc.SetUserVerified(opsCtx, teacher.ID))
+		require.NoError(t, svc.ForcePasswordReset(opsCtx, teacher.ID))
+
+		user, err := repos.Users.GetByID(ctx, teacher.ID)
+		require.NoError(t, err)
+		assert.True(t, user.IsVerified)
+		assert.True(t, user.PasswordResetRequired)
+		keys, err := repos.APIKeys.ListByUser(ctx, teacher.ID)
+		require.NoError(t, err)
+		assert.Empty(t, keys)
+
+		_, err = svc.ParseToken(ctx, teacherLogin.Token)
+		assert.ErrorIs(t, err, ErrInvalidToken)
+		_, err = svc.Login(ctx, teacher.Email, "password123")
+		assert.ErrorIs(t, err, ErrPasswordResetRequired)
+
+		token := magicLinkToken(t, mail)
+		require.NoError(t, svc.ResetPassword(ctx, token, "a fresh passphrase"))
+		assert.ErrorIs(t, svc.ResetPassword(ctx, token, "another passphrase"), ErrInvalidPasswordResetLink)
+		_, err = svc.Login(ctx, teacher.Email, "a fresh passphrase")
+		assert.NoError(t, err)
+	})
+
+	t.Run("impersonation_token_is_a_session_of_the_user", func(t *testing.T) {
+		impersonation, err := svc.ImpersonateUser(opsCtx, teacher.ID)
+		require.NoError(t, err)
+
+		got, err := svc.ParseToken(ctx, impersonation.Token)
+		require.NoError(t, err)
+		assert.Equal(t, teacher.ID, got.UserID)
+		assert.Equal(t, p.UserID, *got.ImpersonatorID)
+
+		sessions, err := repos.Sessions.ListByUser(ctx, teacher.ID)
+		require.NoError(t, err)
+		assert.NotEmpty(t, sessions)
+	})
+}
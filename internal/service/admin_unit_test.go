@@ -0,0 +1,248 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/dvprokofiev/seating-generator-api/internal/models"
+	"github.com/dvprokofiev/seating-generator-api/internal/repository"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthService_Admin_Unit(t *testing.T) {
+	ctx := context.Background()
+	orgID := uuid.New()
+	userRepo := new(repository.MockUserRepository)
+	sessionRepo := new(repository.MockSessionRepository)
+	apiKeyRepo := new(repository.MockAPIKeyRepository)
+	resetRepo := new(repository.MockPasswordResetRepository)
+	auditRepo := new(repository.MockAuditRepository)
+	mail := &recordingMailer{}
+	svc := NewAuthService(userRepo, "secret", WithSessions(sessionRepo), WithAPIKeys(apiKeyRepo),
+		WithMailer(mail), WithPasswordResets(resetRepo, "https://seating.test/password/reset"),
+		WithAuditLog(NewAuditService(auditRepo, NewRolePolicy())))
+	hash, err := svc.(*authService).hasher.Hash("password123")
+	require.NoError(t, err)
+
+	operator := &models.User{ID: uuid.New(), Email: "ops@seating.test", PasswordHash: hash, IsOperator: true}
+	teacher := &models.User{ID: uuid.New(), Email: "teacher@school.test", PasswordHash: hash, OrganizationID: &orgID, Role: models.RoleTeacher}
+	opsCtx := ContextWithPrincipal(ctx, Principal{UserID: operator.ID, IsOperator: true})
+	teacherCtx := ContextWithPrincipal(ctx, Principal{UserID: teacher.ID, OrganizationID: &orgID, Role: models.RoleTeacher})
+
+	// as returns a copy of teacher changed by update, as the repository
+	// would return it after an operator action.
+	as := func(update func(u *models.User)) *models.User {
+		u := *teacher
+		update(&u)
+		return &u
+	}
+	auditEvent := func(event models.AuditEventType) any {
+		return mock.MatchedBy(func(e *models.AuditEvent) bool { return e.Event == event })
+	}
+	assertExpectations := func(t *testing.T) {
+		userRepo.AssertExpectations(t)
+		sessionRepo.AssertExpectations(t)
+		apiKeyRepo.AssertExpectations(t)
+		resetRepo.AssertExpectations(t)
+		auditRepo.AssertExpectations(t)
+	}
+
+	t.Run("only_operators", func(t *testing.T) {
+		keyID := uuid.New()
+		keyCtx := ContextWithPrincipal(ctx, Principal{UserID: operator.ID, IsOperator: true, APIKeyID: &keyID})
+
+		_, err := svc.SearchUsers(teacherCtx, models.UserFilter{})
+		assert.ErrorIs(t, err, ErrForbidden)
+		_, err = svc.SearchUsers(keyCtx, models.UserFilter{})
+		assert.ErrorIs(t, err, ErrForbidden)
+		assert.ErrorIs(t, svc.SetUserVerified(teacherCtx, teacher.ID), ErrForbidden)
+		_, err = svc.SearchUsers(ctx, models.UserFilter{})
+		assert.ErrorIs(t, err, ErrUnauthenticated)
+		userRepo.AssertNotCalled(t, "Search", mock.Anything, mock.Anything)
+	})
+
+	t.Run("search_normalizes_filter", func(t *testing.T) {
+		page := []models.User{*teacher}
+		userRepo.On("Search", mock.Anything, models.UserFilter{EmailPrefix: "teacher", After: "a@school.test", Limit: 2}).Return(page, nil).Once()
+		userRepo.On("Search", mock.Anything, models.UserFilter{Limit: defaultUserPageSize}).Return(page, nil).Once()
+		userRepo.On("Search", mock.Anything, models.UserFilter{Limit: maxUserPageSize}).Return(page, nil).Once()
+
+		found, err := svc.SearchUsers(opsCtx, models.UserFilter{EmailPrefix: "Teacher", After: "a@school.test", Limit: 2})
+		require.NoError(t, err)
+		assert.Equal(t, page, found)
+		_, err = svc.SearchUsers(opsCtx, models.UserFilter{})
+		require.NoError(t, err)
+		_, err = svc.SearchUsers(opsCtx, models.UserFilter{Limit: 10000})
+		require.NoError(t, err)
+		assertExpectations(t)
+	})
+
+	t.Run("unknown_user", func(t *testing.T) {
+		id := uuid.New()
+		userRepo.On("GetByID", mock.Anything, id).Return(nil, sql.ErrNoRows).Once()
+
+		assert.ErrorIs(t, svc.ForcePasswordReset(opsCtx, id), ErrUserNotFound)
+		assertExpectations(t)
+	})
+
+	t.Run("disable_signs_out_and_blocks_login", func(t *testing.T) {
+		var event models.AuditEvent
+		userRepo.On("GetByID", mock.Anything, teacher.ID).Return(as(func(*models.User) {}), nil).Once()
+		userRepo.On("SetDisabled", mock.Anything, teacher.ID, mock.MatchedBy(func(disabledAt *time.Time) bool { return disabledAt != nil })).Return(nil).Once()
+		sessionRepo.On("DeleteByUser", mock.Anything, teacher.ID).Return(nil).Once()
+		auditRepo.On("Append", mock.Anything, auditEvent(models.AuditUserDisable)).Run(func(args mock.Arguments) {
+			event = *args.Get(1).(*models.AuditEvent)
+		}).Return(nil).Once()
+
+		user, err := svc.SetUserDisabled(opsCtx, teacher.ID, true)
+		require.NoError(t, err)
+		assert.NotNil(t, user.DisabledAt)
+		assert.Equal(t, operator.ID, *event.ActorID)
+		assert.Equal(t, orgID, *event.OrganizationID)
+		assert.Equal(t, teacher.ID.String(), event.TargetID)
+
+		disabled := as(func(u *models.User) { u.DisabledAt = user.DisabledAt })
+		userRepo.On("GetByEmail", mock.Anything, teacher.Email).Return(disabled, nil).Once()
+		auditRepo.On("Append", mock.Anything, auditEvent(models.AuditLogin)).Return(nil).Once()
+		_, err = svc.Login(ctx, teacher.Email, "password123")
+		assert.ErrorIs(t, err, ErrAccountDisabled)
+
+		untracked := NewAuthService(userRepo, "secret")
+		token, err := untracked.(*authService).issueToken(ctx, teacher)
+		require.NoError(t, err)
+		userRepo.On("GetByID", mock.Anything, teacher.ID).Return(disabled, nil).Once()
+		_, err = untracked.ParseToken(ctx, token)
+		assert.ErrorIs(t, err, ErrAccountDisabled)
+		assertExpectations(t)
+	})
+
+	t.Run("enable_keeps_sessions_alone", func(t *testing.T) {
+		disabledAt := time.Now()
+		userRepo.On("GetByID", mock.Anything, teacher.ID).Return(as(func(u *models.User) { u.DisabledAt = &disabledAt }), nil).Once()
+		userRepo.On("SetDisabled", mock.Anything, teacher.ID, (*time.Time)(nil)).Return(nil).Once()
+		auditRepo.On("Append", mock.Anything, auditEvent(models.AuditUserEnable)).Return(nil).Once()
+
+		user, err := svc.SetUserDisabled(opsCtx, teacher.ID, false)
+		require.NoError(t, err)
+		assert.Nil(t, user.DisabledAt)
+		assertExpectations(t)
+	})
+
+	t.Run("operator_cannot_disable_self", func(t *testing.T) {
+		userRepo.On("GetByID", mock.Anything, operator.ID).Return(operator, nil).Once()
+
+		_, err := svc.SetUserDisabled(opsCtx, operator.ID, true)
+		assert.ErrorIs(t, err, ErrCannotDisableSelf)
+		assertExpectations(t)
+	})
+
+	t.Run("forced_password_reset_needs_verified_email", func(t *testing.T) {
+		userRepo.On("GetByID", mock.Anything, teacher.ID).Return(as(func(*models.User) {}), nil).Once()
+
+		assert.ErrorIs(t, svc.ForcePasswordReset(opsCtx, teacher.ID), ErrEmailNotVerified)
+		userRepo.AssertNotCalled(t, "SetPasswordResetRequired", mock.Anything, teacher.ID, true)
+		assertExpectations(t)
+	})
+
+	t.Run("forced_password_reset", func(t *testing.T) {
+		verified := as(func(u *models.User) { u.IsVerified = true })
+		resetRequired := as(func(u *models.User) { u.IsVerified, u.PasswordResetRequired = true, true })
+		var saved models.PasswordReset
+		userRepo.On("GetByID", mock.Anything, teacher.ID).Return(verified, nil).Once()
+		userRepo.On("SetPasswordResetRequired", mock.Anything, teacher.ID, true).Return(nil).Once()
+		sessionRepo.On("DeleteByUser", mock.Anything, teacher.ID).Return(nil).Once()
+		apiKeyRepo.On("DeleteByUser", mock.Anything, teacher.ID).Return(nil).Once()
+		auditRepo.On("Append", mock.Anything, auditEvent(models.AuditUserResetPassword)).Return(nil).Once()
+		resetRepo.On("Save", mock.Anything, mock.MatchedBy(func(r *models.PasswordReset) bool {
+			return r.UserID == teacher.ID
+		})).Run(func(args mock.Arguments) {
+			saved = *args.Get(1).(*models.PasswordReset)
+		}).Return(nil).Once()
+		require.NoError(t, svc.ForcePasswordReset(opsCtx, teacher.ID))
+		require.Len(t, mail.sent, 1)
+		assert.Equal(t, teacher.Email, mail.sent[0].To)
+		token := magicLinkToken(t, mail)
+
+		// the old password no longer signs in
+		userRepo.On("GetByEmail", mock.Anything, teacher.Email).Return(resetRequired, nil).Once()
+		auditRepo.On("Append", mock.Anything, auditEvent(models.AuditLogin)).Return(nil).Once()
+		_, err := svc.Login(ctx, teacher.Email, "password123")
+		assert.ErrorIs(t, err, ErrPasswordResetRequired)
+
+		resetRepo.On("Get", mock.Anything, saved.TokenHash).Return(&saved, nil).Twice()
+		userRepo.On("GetByID", mock.Anything, teacher.ID).Return(resetRequired, nil).Twice()
+		assert.ErrorIs(t, svc.ResetPassword(ctx, token, "password123"), ErrPasswordUnchanged)
+
+		resetRepo.On("Take", mock.Anything, saved.TokenHash).Return(&saved, nil).Once()
+		userRepo.On("UpdatePassword", mock.Anything, teacher.ID, mock.AnythingOfType("string")).Return(nil).Once()
+		userRepo.On("SetPasswordResetRequired", mock.Anything, teacher.ID, false).Return(nil).Once()
+		auditRepo.On("Append", mock.Anything, auditEvent(models.AuditPasswordReset)).Return(nil).Once()
+		require.NoError(t, svc.ResetPassword(ctx, token, "a fresh passphrase"))
+
+		resetRepo.On("Get", mock.Anything, saved.TokenHash).Return(nil, sql.ErrNoRows).Once()
+		assert.ErrorIs(t, svc.ResetPassword(ctx, token, "another passphrase"), ErrInvalidPasswordResetLink)
+		assertExpectations(t)
+	})
+
+	t.Run("mark_verified", func(t *testing.T) {
+		var event models.AuditEvent
+		userRepo.On("GetByID", mock.Anything, teacher.ID).Return(as(func(*models.User) {}), nil).Once()
+		userRepo.On("UpdateVerified", mock.Anything, teacher.ID, true).Return(nil).Once()
+		auditRepo.On("Append", mock.Anything, auditEvent(models.AuditUserVerify)).Run(func(args mock.Arguments) {
+			event = *args.Get(1).(*models.AuditEvent)
+		}).Return(nil).Once()
+
+		require.NoError(t, svc.SetUserVerified(opsCtx, teacher.ID))
+		assert.Equal(t, operator.ID, *event.ActorID)
+		assertExpectations(t)
+	})
+
+	t.Run("impersonation", func(t *testing.T) {
+		var session models.Session
+		var event models.AuditEvent
+		userRepo.On("GetByID", mock.Anything, teacher.ID).Return(teacher, nil).Once()
+		sessionRepo.On("Create", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			session = *args.Get(1).(*models.Session)
+		}).Return(nil).Once()
+		auditRepo.On("Append", mock.Anything, auditEvent(models.AuditImpersonate)).Run(func(args mock.Arguments) {
+			event = *args.Get(1).(*models.AuditEvent)
+		}).Return(nil).Once()
+
+		impersonation, err := svc.ImpersonateUser(opsCtx, teacher.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 3600, impersonation.ExpiresIn)
+		assert.Equal(t, operator.ID, *event.ActorID)
+
+		sessionRepo.On("Get", mock.Anything, session.ID).Return(&session, nil).Once()
+		userRepo.On("GetByID", mock.Anything, teacher.ID).Return(teacher, nil).Once()
+		p, err := svc.ParseToken(ctx, impersonation.Token)
+		require.NoError(t, err)
+		assert.Equal(t, teacher.ID, p.UserID)
+		assert.Equal(t, operator.ID, *p.ImpersonatorID)
+		impersonatedCtx := ContextWithPrincipal(ctx, *p)
+
+		_, err = svc.ListSessions(impersonatedCtx)
+		assert.ErrorIs(t, err, ErrForbidden)
+
+		auditRepo.On("Append", mock.Anything, auditEvent(models.AuditMemberAdd)).Run(func(args mock.Arguments) {
+			event = *args.Get(1).(*models.AuditEvent)
+		}).Return(nil).Once()
+		svc.(*authService).audit.Record(impersonatedCtx, models.AuditEvent{Event: models.AuditMemberAdd, Success: true})
+		assert.Equal(t, teacher.ID, *event.ActorID)
+		assert.Equal(t, operator.ID.String(), event.Details["impersonator_id"])
+		assertExpectations(t)
+	})
+
+	t.Run("operators_cannot_be_impersonated", func(t *testing.T) {
+		userRepo.On("GetByID", mock.Anything, operator.ID).Return(operator, nil).Once()
+
+		_, err := svc.ImpersonateUser(opsCtx, operator.ID)
+		assert.ErrorIs(t, err, ErrCannotImpersonate)
+		assertExpectations(t)
+	})
+}
@@ -0,0 +1,216 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"log"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/dvprokofiev/seating-generator-api/internal/models"
+	"github.com/dvprokofiev/seating-generator-api/internal/repository"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrAPIKeysNotConfigured = errors.New("API keys are not available")
+	ErrInvalidAPIKey        = errors.New("Invalid or expired API key")
+	ErrInvalidAPIKeyName    = errors.New("API key name must be between 1 and 64 characters")
+	ErrInvalidAPIKeyScope   = errors.New("Unknown or missing API key scope")
+	ErrInvalidAPIKeyExpiry  = errors.New("API key expiry must be in the future")
+	ErrTooManyAPIKeys       = errors.New("Too many API keys, revoke an unused one first")
+	ErrAPIKeyNotFound       = errors.New("API key not found")
+)
+
+const (
+	maxAPIKeysPerUser = 20
+	maxAPIKeyNameLen  = 64
+	apiKeyPrefixLen   = 10
+
+	// apiKeyTouchInterval limits how often last_used_at is written for a
+	// key that is used in a tight loop.
+	apiKeyTouchInterval = time.Minute
+)
+
+// CreatedAPIKey is returned once, when a key is created. Key is the only
+// copy of the secret; it cannot be shown again.
+type CreatedAPIKey struct {
+	models.APIKey
+	Key string `json:"key"`
+}
+
+// WithAPIKeys enables personal API keys.
+func WithAPIKeys(repo repository.APIKeyRepository) AuthOption {
+	return func(s *authService) {
+		s.apiKeys = repo
+	}
+}
+
+func hashAPIKeySecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateAPIKey issues a key for the caller limited to the given scopes.
+// A nil expiresAt makes a key that is valid until revoked.
+func (s *authService) CreateAPIKey(ctx context.Context, name string, scopes []models.APIKeyScope, expiresAt *time.Time) (*CreatedAPIKey, error) {
+	if s.apiKeys == nil {
+		return nil, ErrAPIKeysNotConfigured
+	}
+	p, err := authorize(ctx, s.policy, ActionAccountManage, uuid.Nil)
+	if err != nil {
+		return nil, err
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxAPIKeyNameLen {
+		return nil, ErrInvalidAPIKeyName
+	}
+	if len(scopes) == 0 {
+		return nil, ErrInvalidAPIKeyScope
+	}
+	for _, scope := range scopes {
+		if !scope.Valid() {
+			return nil, ErrInvalidAPIKeyScope
+		}
+	}
+	now := time.Now().UTC()
+	if expiresAt != nil && !expiresAt.After(now) {
+		return nil, ErrInvalidAPIKeyExpiry
+	}
+
+	existing, err := s.apiKeys.ListByUser(ctx, p.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) >= maxAPIKeysPerUser {
+		return nil, ErrTooManyAPIKeys
+	}
+
+	scopes = slices.Clone(scopes)
+	slices.Sort(scopes)
+	prefix := strings.ToLower(rand.Text()[:apiKeyPrefixLen])
+	secret := strings.ToLower(rand.Text())
+
+	key := models.APIKey{
+		ID:         uuid.New(),
+		UserID:     p.UserID,
+		Name:       name,
+		Prefix:     prefix,
+		SecretHash: hashAPIKeySecret(secret),
+		Scopes:     slices.Compact(scopes),
+		CreatedAt:  now,
+		ExpiresAt:  expiresAt,
+	}
+	if err := s.apiKeys.Create(ctx, &key); err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(ctx, models.AuditEvent{
+		Event:      models.AuditAPIKeyCreate,
+		Success:    true,
+		TargetType: "api_key",
+		TargetID:   key.ID.String(),
+		Details:    map[string]string{"name": key.Name, "prefix": key.Prefix},
+	})
+	return &CreatedAPIKey{APIKey: key, Key: models.APIKeyPrefix + prefix + "_" + secret}, nil
+}
+
+func (s *authService) ListAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	if s.apiKeys == nil {
+		return nil, ErrAPIKeysNotConfigured
+	}
+	p, err := authorize(ctx, s.policy, ActionAccountManage, uuid.Nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.apiKeys.ListByUser(ctx, p.UserID)
+}
+
+func (s *authService) RevokeAPIKey(ctx context.Context, id uuid.UUID) error {
+	if s.apiKeys == nil {
+		return ErrAPIKeysNotConfigured
+	}
+	p, err := authorize(ctx, s.policy, ActionAccountManage, uuid.Nil)
+	if err != nil {
+		return err
+	}
+
+	if err := s.apiKeys.Delete(ctx, p.UserID, id); err != nil {
+		if errors.Is(err, repository.ErrAPIKeyNotFound) {
+			return ErrAPIKeyNotFound
+		}
+		return err
+	}
+
+	s.audit.Record(ctx, models.AuditEvent{
+		Event:      models.AuditAPIKeyRevoke,
+		Success:    true,
+		TargetType: "api_key",
+		TargetID:   id.String(),
+	})
+	return nil
+}
+
+// AuthenticateAPIKey resolves an API key to a principal with the key's
+// scopes. Membership and role are read from the user on every call, so a
+// key never outlives its owner's access.
+func (s *authService) AuthenticateAPIKey(ctx context.Context, key string) (*Principal, error) {
+	if s.apiKeys == nil {
+		return nil, ErrInvalidAPIKey
+	}
+
+	rest, ok := strings.CutPrefix(key, models.APIKeyPrefix)
+	if !ok {
+		return nil, ErrInvalidAPIKey
+	}
+	prefix, secret, ok := strings.Cut(rest, "_")
+	if !ok || len(prefix) != apiKeyPrefixLen {
+		return nil, ErrInvalidAPIKey
+	}
+
+	stored, err := s.apiKeys.GetByPrefix(ctx, prefix)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrInvalidAPIKey
+		}
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare([]byte(hashAPIKeySecret(secret)), []byte(stored.SecretHash)) != 1 {
+		return nil, ErrInvalidAPIKey
+	}
+	now := time.Now()
+	if stored.ExpiresAt != nil && now.After(*stored.ExpiresAt) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	user, err := s.repo.GetByID(ctx, stored.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkCanSignIn(user); err != nil {
+		return nil, err
+	}
+
+	if stored.LastUsedAt == nil || now.Sub(*stored.LastUsedAt) > apiKeyTouchInterval {
+		if err := s.apiKeys.UpdateLastUsed(ctx, stored.ID, now.UTC()); err != nil {
+			log.Printf("API key error: failed to update last use of %s: %v", stored.ID, err)
+		}
+	}
+
+	return &Principal{
+		UserID:         user.ID,
+		OrganizationID: user.OrganizationID,
+		Role:           user.Role,
+		APIKeyID:       &stored.ID,
+		Scopes:         stored.Scopes,
+	}, nil
+}
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dvprokofiev/seating-generator-api/internal/models"
+	"github.com/dvprokofiev/seating-generator-api/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthService_APIKeys_Integration(t *testing.T) {
+	_, err := testDB.Exec("TRUNCATE users CASCADE")
+	require.NoError(t, err)
+
+	repos := repository.NewRepository(testDB)
+	svc := NewAuthService(repos.Users, "test-secret", WithAPIKeys(repos.APIKeys))
+	ctx := context.Background()
+
+	require.NoError(t, svc.Register(ctx, "script@school.test", "password123"))
+	result, err := svc.Login(ctx, "script@school.test", "password123")
+	require.NoError(t, err)
+	p, err := svc.ParseToken(ctx, result.Token)
+	require.NoError(t, err)
+	userCtx := ContextWithPrincipal(ctx, *p)
+
+	expiresAt := time.Now().Add(30 * 24 * time.Hour).UTC().Truncate(time.Second)
+	created, err := svc.CreateAPIKey(userCtx, "Bulk generation", []models.APIKeyScope{models.ScopePlansWrite, models.ScopePlansRead}, &expiresAt)
+	require.NoError(t, err)
+
+	t.Run("key_authenticates_and_records_use", func(t *testing.T) {
+		got, err := svc.AuthenticateAPIKey(ctx, created.Key)
+		require.NoError(t, err)
+		assert.Equal(t, p.UserID, got.UserID)
+		assert.Equal(t, []models.APIKeyScope{models.ScopePlansRead, models.ScopePlansWrite}, got.Scopes)
+
+		keys, err := svc.ListAPIKeys(userCtx)
+		require.NoError(t, err)
+		require.Len(t, keys, 1)
+		assert.NotNil(t, keys[0].LastUsedAt)
+		assert.True(t, expiresAt.Equal(*keys[0].ExpiresAt))
+	})
+
+	t.Run("revoked_key_stops_working", func(t *testing.T) {
+		require.NoError(t, svc.RevokeAPIKey(userCtx, created.ID))
+
+		_, err := svc.AuthenticateAPIKey(ctx, created.Key)
+		assert.ErrorIs(t, err, ErrInvalidAPIKey)
+	})
+}
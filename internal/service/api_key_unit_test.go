@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dvprokofiev/seating-generator-api/internal/models"
+	"github.com/dvprokofiev/seating-generator-api/internal/repository"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthService_APIKeys_Unit(t *testing.T) {
+	ctx := context.Background()
+	orgID := uuid.New()
+	user := &models.User{ID: uuid.New(), Email: "it@school.test", OrganizationID: &orgID, Role: models.RoleSchoolAdmin}
+
+	userRepo := new(repository.MockUserRepository)
+	apiKeyRepo := new(repository.MockAPIKeyRepository)
+	svc := NewAuthService(userRepo, "secret", WithAPIKeys(apiKeyRepo))
+	userCtx := ContextWithPrincipal(ctx, Principal{UserID: user.ID, OrganizationID: &orgID, Role: user.Role})
+
+	var stored models.APIKey
+	apiKeyRepo.On("ListByUser", mock.Anything, user.ID).Return([]models.APIKey{}, nil).Once()
+	apiKeyRepo.On("Create", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		stored = *args.Get(1).(*models.APIKey)
+	}).Return(nil).Once()
+	created, err := svc.CreateAPIKey(userCtx, " Nightly export ",
+		[]models.APIKeyScope{models.ScopePlansRead, models.ScopeOrganizationRead, models.ScopePlansRead}, nil)
+	require.NoError(t, err)
+	apiKeyRepo.AssertExpectations(t)
+
+	// authenticate expects key to be found by its prefix and its owner to
+	// be loaded once.
+	authenticate := func(key models.APIKey) {
+		apiKeyRepo.On("GetByPrefix", mock.Anything, key.Prefix).Return(&key, nil).Once()
+		userRepo.On("GetByID", mock.Anything, user.ID).Return(user, nil).Once()
+	}
+
+	t.Run("create_returns_secret_once", func(t *testing.T) {
+		assert.True(t, strings.HasPrefix(created.Key, models.APIKeyPrefix+created.Prefix+"_"))
+		assert.Equal(t, "Nightly export", created.Name)
+		assert.Equal(t, []models.APIKeyScope{models.ScopeOrganizationRead, models.ScopePlansRead}, created.Scopes)
+		assert.Equal(t, created.ID, stored.ID)
+		assert.NotContains(t, created.Key, stored.SecretHash)
+	})
+
+	t.Run("authenticate", func(t *testing.T) {
+		authenticate(stored)
+		apiKeyRepo.On("UpdateLastUsed", mock.Anything, stored.ID, mock.AnythingOfType("time.Time")).Return(nil).Once()
+
+		p, err := svc.AuthenticateAPIKey(ctx, created.Key)
+		require.NoError(t, err)
+
+		assert.Equal(t, user.ID, p.UserID)
+		assert.Equal(t, created.ID, *p.APIKeyID)
+		assert.True(t, p.HasScope(models.ScopePlansRead))
+		assert.False(t, p.HasScope(models.ScopePlansWrite))
+		apiKeyRepo.AssertExpectations(t)
+		userRepo.AssertExpectations(t)
+	})
+
+	t.Run("recent_use_not_written_again", func(t *testing.T) {
+		used := stored
+		usedAt := time.Now().Add(-apiKeyTouchInterval / 2)
+		used.LastUsedAt = &usedAt
+		authenticate(used)
+
+		_, err := svc.AuthenticateAPIKey(ctx, created.Key)
+		require.NoError(t, err)
+		apiKeyRepo.AssertExpectations(t)
+		userRepo.AssertExpectations(t)
+		apiKeyRepo.AssertNumberOfCalls(t, "UpdateLastUsed", 1)
+	})
+
+	t.Run("wrong_secret_rejected", func(t *testing.T) {
+		forged := created.Key[:len(created.Key)-4] + "aaaa"
+		apiKeyRepo.On("GetByPrefix", mock.Anything, stored.Prefix).Return(&stored, nil).Once()
+
+		_, err := svc.AuthenticateAPIKey(ctx, forged)
+		assert.ErrorIs(t, err, ErrInvalidAPIKey)
+		apiKeyRepo.AssertExpectations(t)
+	})
+
+	t.Run("malformed_key_rejected", func(t *testing.T) {
+		for _, key := range []string{"sg_", "sg_short_secret", "token", models.APIKeyPrefix + strings.Repeat("x", 10)} {
+			_, err := svc.AuthenticateAPIKey(ctx, key)
+			assert.ErrorIs(t, err, ErrInvalidAPIKey, key)
+		}
+		apiKeyRepo.AssertNumberOfCalls(t, "GetByPrefix", 3)
+	})
+
+	t.Run("expired_key_rejected", func(t *testing.T) {
+		expired := stored
+		past := time.Now().Add(-time.Minute)
+		expired.ExpiresAt = &past
+		apiKeyRepo.On("GetByPrefix", mock.Anything, stored.Prefix).Return(&expired, nil).Once()
+
+		_, err := svc.AuthenticateAPIKey(ctx, created.Key)
+		assert.ErrorIs(t, err, ErrInvalidAPIKey)
+		apiKeyRepo.AssertExpectations(t)
+	})
+
+	t.Run("disabled_owner_rejected", func(t *testing.T) {
+		disabledAt := time.Now()
+		disabled := *user
+		disabled.DisabledAt = &disabledAt
+		apiKeyRepo.On("GetByPrefix", mock.Anything, stored.Prefix).Return(&stored, nil).Once()
+		userRepo.On("GetByID", mock.Anything, user.ID).Return(&disabled, nil).Once()
+
+		_, err := svc.AuthenticateAPIKey(ctx, created.Key)
+		assert.ErrorIs(t, err, ErrAccountDisabled)
+		apiKeyRepo.AssertExpectations(t)
+		userRepo.AssertExpectations(t)
+	})
+
+	t.Run("scopes_limit_policy", func(t *testing.T) {
+		authenticate(stored)
+		apiKeyRepo.On("UpdateLastUsed", mock.Anything, stored.ID, mock.AnythingOfType("time.Time")).Return(nil).Once()
+		p, err := svc.AuthenticateAPIKey(ctx, created.Key)
+		require.NoError(t, err)
+		policy := NewRolePolicy()
+
+		assert.NoError(t, policy.Authorize(*p, ActionOrganizationRead, orgID))
+		assert.ErrorIs(t, policy.Authorize(*p, ActionOrganizationManageMembers, orgID), ErrForbidden)
+		assert.ErrorIs(t, policy.Authorize(*p, ActionAuditRead, orgID), ErrForbidden)
+	})
+
+	t.Run("api_key_cannot_manage_credentials", func(t *testing.T) {
+		keyCtx := ContextWithPrincipal(ctx, Principal{UserID: user.ID, OrganizationID: &orgID, Role: user.Role,
+			APIKeyID: &stored.ID, Scopes: stored.Scopes})
+
+		_, err := svc.CreateAPIKey(keyCtx, "Escalated", []models.APIKeyScope{models.ScopePlansWrite}, nil)
+		assert.ErrorIs(t, err, ErrForbidden)
+		_, err = svc.ListAPIKeys(keyCtx)
+		assert.ErrorIs(t, err, ErrForbidden)
+		assert.ErrorIs(t, svc.RevokeAPIKey(keyCtx, stored.ID), ErrForbidden)
+	})
+
+	t.Run("invalid_requests", func(t *testing.T) {
+		_, err := svc.CreateAPIKey(userCtx, "  ", []models.APIKeyScope{models.ScopePlansRead}, nil)
+		assert.ErrorIs(t, err, ErrInvalidAPIKeyName)
+		_, err = svc.CreateAPIKey(userCtx, "Admin", []models.APIKeyScope{"admin"}, nil)
+		assert.ErrorIs(t, err, ErrInvalidAPIKeyScope)
+		_, err = svc.CreateAPIKey(userCtx, "Admin", nil, nil)
+		assert.ErrorIs(t, err, ErrInvalidAPIKeyScope)
+		past := time.Now().Add(-time.Hour)
+		_, err = svc.CreateAPIKey(userCtx, "Old", []models.APIKeyScope{models.ScopePlansRead}, &past)
+		assert.ErrorIs(t, err, ErrInvalidAPIKeyExpiry)
+		apiKeyRepo.AssertNumberOfCalls(t, "Create", 1)
+	})
+
+	t.Run("too_many_keys", func(t *testing.T) {
+		apiKeyRepo.On("ListByUser", mock.Anything, user.ID).Return(make([]models.APIKey, maxAPIKeysPerUser), nil).Once()
+
+		_, err := svc.CreateAPIKey(userCtx, "One more", []models.APIKeyScope{models.ScopePlansRead}, nil)
+		assert.ErrorIs(t, err, ErrTooManyAPIKeys)
+		apiKeyRepo.AssertExpectations(t)
+	})
+
+	t.Run("revoke", func(t *testing.T) {
+		apiKeyRepo.On("Delete", mock.Anything, user.ID, stored.ID).Return(nil).Once()
+		apiKeyRepo.On("Delete", mock.Anything, user.ID, stored.ID).Return(repository.ErrAPIKeyNotFound).Once()
+
+		require.NoError(t, svc.RevokeAPIKey(userCtx, stored.ID))
+		assert.ErrorIs(t, svc.RevokeAPIKey(userCtx, stored.ID), ErrAPIKeyNotFound)
+		apiKeyRepo.AssertExpectations(t)
+	})
+}
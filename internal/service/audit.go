@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"log"
+	"maps"
+	"strings"
+	"time"
+
+	"github.com/dvprokofiev/seating-generator-api/internal/models"
+	"github.com/dvprokofiev/seating-generator-api/internal/repository"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultAuditPageSize = 50
+	maxAuditPageSize     = 200
+)
+
+type AuditService interface {
+	// Record appends an event, filling in the time, the client address and,
+	// unless already set, the actor from ctx. Events of an impersonated
+	// caller name the operator in Details. Failures are logged rather than
+	// returned so auditing never breaks the operation being audited.
+	Record(ctx context.Context, event models.AuditEvent)
+	// List returns events of the organization named by the filter to its
+	// admins. ListAll lets operators search the whole log, including
+	// events of users without an organization.
+	List(ctx context.Context, filter models.AuditFilter) ([]models.AuditEvent, error)
+	ListAll(ctx context.Context, filter models.AuditFilter) ([]models.AuditEvent, error)
+}
+
+type auditService struct {
+	repo   repository.AuditRepository
+	policy Policy
+}
+
+func NewAuditService(repo repository.AuditRepository, policy Policy) AuditService {
+	return &auditService{
+		repo:   repo,
+		policy: policy,
+	}
+}
+
+func (s *auditService) Record(ctx context.Context, event models.AuditEvent) {
+	event.OccurredAt = time.Now().UTC()
+
+	meta := RequestMetaFromContext(ctx)
+	event.IPAddress = meta.IPAddress
+	event.UserAgent = meta.UserAgent
+
+	if p, ok := PrincipalFromContext(ctx); ok {
+		if event.ActorID == nil {
+			event.ActorID = &p.UserID
+		}
+		if event.OrganizationID == nil {
+			event.OrganizationID = p.OrganizationID
+		}
+		if p.ImpersonatorID != nil {
+			event.Details = maps.Clone(event.Details)
+			if event.Details == nil {
+				event.Details = map[string]string{}
+			}
+			event.Details["impersonator_id"] = p.ImpersonatorID.String()
+		}
+	}
+
+	// the audited operation has already happened, so don't let its
+	// cancellation drop the record of it
+	if err := s.repo.Append(context.WithoutCancel(ctx), &event); err != nil {
+		log.Printf("Audit error: failed to record %s: %v", event.Event, err)
+	}
+}
+
+func (s *auditService) List(ctx context.Context, filter models.AuditFilter) ([]models.AuditEvent, error) {
+	if filter.OrganizationID == nil {
+		return nil, ErrForbidden
+	}
+	if _, err := authorize(ctx, s.policy, ActionAuditRead, *filter.OrganizationID); err != nil {
+		return nil, err
+	}
+	return s.list(ctx, filter)
+}
+
+func (s *auditService) ListAll(ctx context.Context, filter models.AuditFilter) ([]models.AuditEvent, error) {
+	if _, err := authorize(ctx, s.policy, ActionAuditReadAll, uuid.Nil); err != nil {
+		return nil, err
+	}
+	return s.list(ctx, filter)
+}
+
+func (s *auditService) list(ctx context.Context, filter models.AuditFilter) ([]models.AuditEvent, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultAuditPageSize
+	}
+	if filter.Limit > maxAuditPageSize {
+		filter.Limit = maxAuditPageSize
+	}
+
+	filter.Email = strings.ToLower(filter.Email)
+	return s.repo.List(ctx, filter)
+}
+
+type nopAuditService struct{}
+
+func (nopAuditService) Record(context.Context, models.AuditEvent) {}
+
+func (nopAuditService) List(context.Context, models.AuditFilter) ([]models.AuditEvent, error) {
+	return []models.AuditEvent{}, nil
+}
+
+func (nopAuditService) ListAll(context.Context, models.AuditFilter) ([]models.AuditEvent, error) {
+	return []models.AuditEvent{}, nil
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dvprokofiev/seating-generator-api/internal/models"
+	"github.com/dvprokofiev/seating-generator-api/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditService_Integration(t *testing.T) {
+	_, err := testDB.Exec("TRUNCATE users, organizations, audit_log CASCADE")
+	require.NoError(t, err)
+
+	repos := repository.NewRepository(testDB)
+	policy := NewRolePolicy()
+	auditSvc := NewAuditService(repos.Audit, policy)
+	authSvc := NewAuthService(repos.Users, "test-secret", WithAuditLog(auditSvc))
+	orgSvc := NewOrganizationService(repos.Organizations, repos.Users, policy, auditSvc)
+
+	ctx := ContextWithRequestMeta(context.Background(), RequestMeta{IPAddress: "198.51.100.4", UserAgent: "integration-test"})
+	require.NoError(t, authSvc.Register(ctx, "auditor@school.test", "password123"))
+
+	result, err := authSvc.Login(ctx, "auditor@school.test", "password123")
+	require.NoError(t, err)
+	p, err := authSvc.ParseToken(ctx, result.Token)
+	require.NoError(t, err)
+
+	org, err := orgSvc.Create(ContextWithPrincipal(ctx, *p), "Audited school")
+	require.NoError(t, err)
+
+	// log in again so the token carries the admin role
+	_, err = authSvc.Login(ctx, "auditor@school.test", "wrong-password")
+	require.ErrorIs(t, err, ErrInvalidCredentials)
+	result, err = authSvc.Login(ctx, "auditor@school.test", "password123")
+	require.NoError(t, err)
+	p, err = authSvc.ParseToken(ctx, result.Token)
+	require.NoError(t, err)
+	adminCtx := ContextWithPrincipal(ctx, *p)
+
+	t.Run("admin_sees_logins_with_client_info", func(t *testing.T) {
+		events, err := auditSvc.List(adminCtx, models.AuditFilter{OrganizationID: &org.ID, Event: models.AuditLogin})
+		require.NoError(t, err)
+		require.Len(t, events, 2)
+
+		assert.True(t, events[0].Success)
+		assert.False(t, events[1].Success)
+		assert.Equal(t, "198.51.100.4", events[0].IPAddress)
+		assert.Equal(t, "integration-test", events[0].UserAgent)
+	})
+
+	t.Run("pagination_cursor", func(t *testing.T) {
+		first, err := auditSvc.List(adminCtx, models.AuditFilter{OrganizationID: &org.ID, Limit: 1})
+		require.NoError(t, err)
+		require.Len(t, first, 1)
+
+		rest, err := auditSvc.List(adminCtx, models.AuditFilter{OrganizationID: &org.ID, BeforeID: first[0].ID})
+		require.NoError(t, err)
+		for _, e := range rest {
+			assert.Less(t, e.ID, first[0].ID)
+		}
+	})
+
+	t.Run("operator_sees_events_without_organization", func(t *testing.T) {
+		_, err := authSvc.Login(ctx, "nobody@school.test", "password123")
+		require.ErrorIs(t, err, ErrInvalidCredentials)
+
+		_, err = testDB.Exec("UPDATE users SET is_operator = TRUE WHERE email = 'auditor@school.test'")
+		require.NoError(t, err)
+		p, err := authSvc.ParseToken(ctx, result.Token)
+		require.NoError(t, err)
+
+		events, err := auditSvc.ListAll(ContextWithPrincipal(ctx, *p), models.AuditFilter{Email: "nobody@school.test"})
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		assert.Nil(t, events[0].OrganizationID)
+
+		_, err = auditSvc.ListAll(adminCtx, models.AuditFilter{})
+		assert.ErrorIs(t, err, ErrForbidden)
+	})
+
+	t.Run("log_is_append_only", func(t *testing.T) {
+		_, err := testDB.Exec("UPDATE audit_log SET success = TRUE")
+		assert.Error(t, err)
+
+		_, err = testDB.Exec("DELETE FROM audit_log")
+		assert.Error(t, err)
+	})
+}
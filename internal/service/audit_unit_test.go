@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/dvprokofiev/seating-generator-api/internal/models"
+	"github.com/dvprokofiev/seating-generator-api/internal/repository"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestAuditService_Unit(t *testing.T) {
+	orgID := uuid.New()
+	adminID := uuid.New()
+	meta := RequestMeta{IPAddress: "203.0.113.7", UserAgent: "Mozilla/5.0"}
+
+	t.Run("record_fills_request_meta_and_actor", func(t *testing.T) {
+		auditRepo := new(repository.MockAuditRepository)
+		svc := NewAuditService(auditRepo, NewRolePolicy())
+
+		ctx := ContextWithRequestMeta(context.Background(), meta)
+		ctx = ContextWithPrincipal(ctx, Principal{UserID: adminID, OrganizationID: &orgID, Role: models.RoleSchoolAdmin})
+
+		auditRepo.On("Append", mock.Anything, mock.MatchedBy(func(e *models.AuditEvent) bool {
+			return e.IPAddress == meta.IPAddress && e.UserAgent == meta.UserAgent &&
+				*e.ActorID == adminID && *e.OrganizationID == orgID && !e.OccurredAt.IsZero()
+		})).Return(nil).Once()
+
+		svc.Record(ctx, models.AuditEvent{Event: models.AuditMemberRemove, Success: true})
+
+		auditRepo.AssertExpectations(t)
+	})
+
+	t.Run("record_swallows_repository_errors", func(t *testing.T) {
+		auditRepo := new(repository.MockAuditRepository)
+		svc := NewAuditService(auditRepo, NewRolePolicy())
+
+		auditRepo.On("Append", mock.Anything, mock.Anything).Return(sql.ErrConnDone).Once()
+
+		assert.NotPanics(t, func() {
+			svc.Record(context.Background(), models.AuditEvent{Event: models.AuditLogin})
+		})
+	})
+
+	t.Run("admin_lists_own_organization", func(t *testing.T) {
+		auditRepo := new(repository.MockAuditRepository)
+		svc := NewAuditService(auditRepo, NewRolePolicy())
+		ctx := ContextWithPrincipal(context.Background(), Principal{UserID: adminID, OrganizationID: &orgID, Role: models.RoleSchoolAdmin})
+
+		auditRepo.On("List", mock.Anything, models.AuditFilter{OrganizationID: &orgID, Limit: maxAuditPageSize}).
+			Return([]models.AuditEvent{{ID: 1}}, nil).Once()
+
+		events, err := svc.List(ctx, models.AuditFilter{OrganizationID: &orgID, Limit: 10000})
+
+		assert.NoError(t, err)
+		assert.Len(t, events, 1)
+		auditRepo.AssertExpectations(t)
+	})
+
+	t.Run("teacher_cannot_list", func(t *testing.T) {
+		auditRepo := new(repository.MockAuditRepository)
+		svc := NewAuditService(auditRepo, NewRolePolicy())
+		ctx := ContextWithPrincipal(context.Background(), Principal{UserID: uuid.New(), OrganizationID: &orgID, Role: models.RoleTeacher})
+
+		_, err := svc.List(ctx, models.AuditFilter{OrganizationID: &orgID})
+
+		assert.ErrorIs(t, err, ErrForbidden)
+		auditRepo.AssertNotCalled(t, "List", mock.Anything, mock.Anything)
+	})
+
+	t.Run("operator_lists_events_without_organization", func(t *testing.T) {
+		auditRepo := new(repository.MockAuditRepository)
+		svc := NewAuditService(auditRepo, NewRolePolicy())
+		ctx := ContextWithPrincipal(context.Background(), Principal{UserID: uuid.New(), IsOperator: true})
+
+		auditRepo.On("List", mock.Anything, models.AuditFilter{Email: "ghost@test.ru", Limit: defaultAuditPageSize}).
+			Return([]models.AuditEvent{{ID: 1}}, nil).Once()
+
+		events, err := svc.ListAll(ctx, models.AuditFilter{Email: "Ghost@test.ru"})
+
+		assert.NoError(t, err)
+		assert.Len(t, events, 1)
+		auditRepo.AssertExpectations(t)
+	})
+
+	t.Run("admin_cannot_list_all", func(t *testing.T) {
+		auditRepo := new(repository.MockAuditRepository)
+		svc := NewAuditService(auditRepo, NewRolePolicy())
+		ctx := ContextWithPrincipal(context.Background(), Principal{UserID: adminID, OrganizationID: &orgID, Role: models.RoleSchoolAdmin})
+
+		_, err := svc.ListAll(ctx, models.AuditFilter{})
+		assert.ErrorIs(t, err, ErrForbidden)
+		_, err = svc.List(ctx, models.AuditFilter{})
+		assert.ErrorIs(t, err, ErrForbidden)
+		auditRepo.AssertNotCalled(t, "List", mock.Anything, mock.Anything)
+	})
+
+	t.Run("login_outcomes_are_recorded", func(t *testing.T) {
+		userRepo := new(repository.MockUserRepository)
+		auditRepo := new(repository.MockAuditRepository)
+		svc := NewAuthService(userRepo, "secret", WithAuditLog(NewAuditService(auditRepo, NewRolePolicy())))
+		ctx := ContextWithRequestMeta(context.Background(), meta)
+
+		hash, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.DefaultCost)
+		user := &models.User{ID: uuid.New(), Email: "teacher@test.ru", PasswordHash: string(hash), OrganizationID: &orgID}
+
+		userRepo.On("GetByEmail", mock.Anything, "teacher@test.ru").Return(user, nil).Twice()
+		userRepo.On("GetByEmail", mock.Anything, "ghost@test.ru").Return(nil, sql.ErrNoRows).Once()
+
+		auditRepo.On("Append", mock.Anything, mock.MatchedBy(func(e *models.AuditEvent) bool {
+			return e.Event == models.AuditLogin && e.Success && *e.ActorID == user.ID && e.IPAddress == meta.IPAddress
+		})).Return(nil).Once()
+		auditRepo.On("Append", mock.Anything, mock.MatchedBy(func(e *models.AuditEvent) bool {
+			return e.Event == models.AuditLogin && !e.Success && e.ActorID != nil && *e.ActorID == user.ID
+		})).Return(nil).Once()
+		auditRepo.On("Append", mock.Anything, mock.MatchedBy(func(e *models.AuditEvent) bool {
+			return e.Event == models.AuditLogin && !e.Success && e.ActorID == nil && e.Email == "ghost@test.ru"
+		})).Return(nil).Once()
+
+		_, err := svc.Login(ctx, "teacher@test.ru", "password123")
+		assert.NoError(t, err)
+		_, err = svc.Login(ctx, "teacher@test.ru", "wrong-password")
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+		_, err = svc.Login(ctx, "ghost@test.ru", "password123")
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+
+		auditRepo.AssertExpectations(t)
+	})
+
+	t.Run("registration_is_recorded", func(t *testing.T) {
+		userRepo := new(repository.MockUserRepository)
+		auditRepo := new(repository.MockAuditRepository)
+		svc := NewAuthService(userRepo, "secret", WithAuditLog(NewAuditService(auditRepo, NewRolePolicy())))
+
+		userRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.User")).Return(nil).Once()
+		auditRepo.On("Append", mock.Anything, mock.MatchedBy(func(e *models.AuditEvent) bool {
+			return e.Event == models.AuditRegister && e.Success && e.ActorID != nil && e.Email == "new@test.ru"
+		})).Return(nil).Once()
+
+		err := svc.Register(context.Background(), "New@test.ru", "password123")
+
+		assert.NoError(t, err)
+		auditRepo.AssertExpectations(t)
+	})
+}
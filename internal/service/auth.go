@@ -3,31 +3,139 @@ package service
 import (
 	"context"
 	"errors"
+	"sync"
+	"time"
 
+	"github.com/dvprokofiev/seating-generator-api/internal/mailer"
+	"github.com/dvprokofiev/seating-generator-api/internal/models"
 	"github.com/dvprokofiev/seating-generator-api/internal/repository"
+	"github.com/dvprokofiev/seating-generator-api/internal/signing"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
 )
 
 var (
 	ErrInvalidCredentials = errors.New("Invalid credentials")
 	ErrInternal           = errors.New("Internal server error")
-	ErrPasswordTooShort   = errors.New("Password is less then 8 symbols length")
+	ErrPasswordTooShort   = errors.New("Password is too short")
 	ErrInvalidEmail       = errors.New("Invalid email")
 	ErrUserAlreadyExists  = errors.New("User with such email address already exists")
 )
 
 type AuthService interface {
-	Login(ctx context.Context, email, password string) (string, error)
+	Login(ctx context.Context, email, password string) (*LoginResult, error)
 	Register(ctx context.Context, email, password string) error
+	ParseToken(ctx context.Context, token string) (*Principal, error)
+	PublicKeys() signing.JWKS
+	BeginMFAEnrollment(ctx context.Context, challengeToken string) (*MFAEnrollment, error)
+	ConfirmMFAEnrollment(ctx context.Context, challengeToken, code string) (*MFAConfirmation, error)
+	VerifyMFA(ctx context.Context, challengeToken, code string) (string, error)
+	BeginPasskeyRegistration(ctx context.Context) (*PasskeyCeremony, error)
+	FinishPasskeyRegistration(ctx context.Context, ceremonyID uuid.UUID, response []byte, name string) (*models.Passkey, error)
+	ListPasskeys(ctx context.Context) ([]models.Passkey, error)
+	DeletePasskey(ctx context.Context, id uuid.UUID) error
+	BeginPasskeyLogin(ctx context.Context) (*PasskeyCeremony, error)
+	FinishPasskeyLogin(ctx context.Context, ceremonyID uuid.UUID, response []byte) (string, error)
+	BeginOIDCLogin(ctx context.Context, orgID uuid.UUID) (string, error)
+	FinishOIDCLogin(ctx context.Context, state, code string) (string, error)
+	CreateAPIKey(ctx context.Context, name string, scopes []models.APIKeyScope, expiresAt *time.Time) (*CreatedAPIKey, error)
+	ListAPIKeys(ctx context.Context) ([]models.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id uuid.UUID) error
+	AuthenticateAPIKey(ctx context.Context, key string) (*Principal, error)
+	RequestMagicLink(ctx context.Context, email string) error
+	FinishMagicLinkLogin(ctx context.Context, token string) (*LoginResult, error)
+	ListSessions(ctx context.Context) ([]ActiveSession, error)
+	RevokeSession(ctx context.Context, id uuid.UUID) error
+	ResetPassword(ctx context.Context, token, newPassword string) error
+	SearchUsers(ctx context.Context, filter models.UserFilter) ([]models.User, error)
+	SetUserDisabled(ctx context.Context, id uuid.UUID, disabled bool) (*models.User, error)
+	ForcePasswordReset(ctx context.Context, id uuid.UUID) error
+	SetUserVerified(ctx context.Context, id uuid.UUID) error
+	ImpersonateUser(ctx context.Context, id uuid.UUID) (*Impersonation, error)
+	Close(ctx context.Context) error
 }
 
 type authService struct {
 	repo      repository.UserRepository
 	jwtSecret []byte
+	keys      TokenKeys
+	audit     AuditService
+	policy    Policy
+	hasher    PasswordHasher
+	dummyHash func() string
+
+	passwordPolicy PasswordPolicy
+
+	protection *LoginProtection
+	mailer     mailer.Mailer
+	mfa        *mfaConfig
+	passkeys   *passkeyConfig
+	oidc       *oidcConfig
+	apiKeys    repository.APIKeyRepository
+	sessions   repository.SessionRepository
+	magicLinks *magicLinkConfig
+
+	passwordResets *passwordResetConfig
+}
+
+type AuthOption func(*authService)
+
+// WithPasswordHasher sets how new passwords are hashed. On login, hashes
+// that the hasher reports as outdated are replaced transparently.
+func WithPasswordHasher(h PasswordHasher) AuthOption {
+	return func(s *authService) {
+		s.hasher = h
+	}
+}
+
+// WithAuditLog makes the service record logins and registrations.
+func WithAuditLog(a AuditService) AuthOption {
+	return func(s *authService) {
+		s.audit = a
+	}
+}
+
+// WithPolicy sets the policy that decides who may manage their account
+// and act as an operator. It defaults to NewRolePolicy.
+func WithPolicy(p Policy) AuthOption {
+	return func(s *authService) {
+		s.policy = p
+	}
+}
+
+func NewAuthService(repo repository.UserRepository, secret string, opts ...AuthOption) AuthService {
+	s := &authService{
+		repo:           repo,
+		jwtSecret:      []byte(secret),
+		audit:          nopAuditService{},
+		policy:         NewRolePolicy(),
+		passwordPolicy: DefaultPasswordPolicy(),
+		hasher: NewRehashingHasher(
+			NewBcryptHasher(bcrypt.DefaultCost),
+			NewArgon2idHasher(DefaultArgon2idParams),
+		),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.magicLinks != nil {
+		s.magicLinks.start(s.deliverMagicLink)
+	}
+	s.dummyHash = sync.OnceValue(func() string {
+		hash, err := s.hasher.Hash("timing-equalization-only")
+		if err != nil {
+			panic(err)
+		}
+		return hash
+	})
+	return s
 }
 
-func NewAuthService(repo repository.UserRepository, secret string) AuthService {
-	return &authService{
-		repo:      repo,
-		jwtSecret: []byte(secret),
+// Close stops the background delivery of sign-in links. Links that were
+// already requested are still sent unless ctx is done first.
+func (s *authService) Close(ctx context.Context) error {
+	if s.magicLinks == nil {
+		return nil
 	}
+	return s.magicLinks.close(ctx)
 }
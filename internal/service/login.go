@@ -4,48 +4,131 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"log"
 	"net/mail"
 	"strings"
-	"time"
 
-	"github.com/golang-jwt/jwt/v5"
-	"golang.org/x/crypto/bcrypt"
+	"github.com/dvprokofiev/seating-generator-api/internal/models"
 )
 
-func (s *authService) Login(ctx context.Context, email, password string) (string, error) {
+// LoginResult is the outcome of a login's first factor: the access token,
+// or, if the user has to pass a second factor first, the challenge for it.
+type LoginResult struct {
+	Token     string
+	Challenge *MFAChallenge
+}
+
+func (s *authService) Login(ctx context.Context, email, password string) (*LoginResult, error) {
 	if _, err := mail.ParseAddress(email); err != nil {
-		return "", ErrInvalidEmail
+		return nil, ErrInvalidEmail
 	}
-	if len(password) < 8 {
-		return "", ErrPasswordTooShort
+
+	email = strings.ToLower(email)
+	if err := s.checkLoginRate(ctx, email); err != nil {
+		return nil, err
 	}
+	lockout := s.checkLockout(ctx, email)
 
-	user, err := s.repo.GetByEmail(ctx, strings.ToLower(email))
+	user, err := s.repo.GetByEmail(ctx, email)
+	if errors.Is(err, sql.ErrNoRows) {
+		user, err = nil, nil
+	}
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return "", ErrInvalidCredentials
-		}
-		return "", err
+		return nil, err
+	}
+
+	// a locked email is refused before the password is checked, whether or
+	// not it belongs to an account
+	if lockout != nil {
+		s.recordLogin(ctx, email, user, false)
+		return nil, lockout
 	}
 	if user == nil {
-		return "", ErrInvalidCredentials
+		s.rejectUnknownUser(ctx, email, password)
+		return nil, ErrInvalidCredentials
 	}
 
-	err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password))
+	ok, err := s.hasher.Verify(user.PasswordHash, password)
 	if err != nil {
-		return "", ErrInvalidCredentials
+		log.Printf("Login error: cannot verify password hash of user %s: %v", user.ID, err)
+	}
+	if !ok {
+		s.recordLogin(ctx, email, user, false)
+		s.recordLoginFailure(ctx, email, user)
+		return nil, ErrInvalidCredentials
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"sub": user.ID,
-		"exp": time.Now().Add(time.Hour * 24).Unix(),
-		"iat": time.Now().Unix(),
-	})
+	s.upgradePasswordHash(ctx, user, password)
+	s.resetLoginFailures(ctx, email)
+
+	if err := checkCanSignIn(user); err != nil {
+		s.recordLogin(ctx, email, user, false)
+		return nil, err
+	}
+	return s.finishLogin(ctx, user)
+}
+
+// finishLogin completes a login whose first factor the user passed: it
+// asks for the second factor when one is needed and issues the access
+// token otherwise.
+func (s *authService) finishLogin(ctx context.Context, user *models.User) (*LoginResult, error) {
+	challenge, err := s.requireSecondFactor(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	if challenge != nil {
+		return &LoginResult{Challenge: challenge}, nil
+	}
 
-	tokenString, err := token.SignedString(s.jwtSecret)
+	token, err := s.issueToken(ctx, user)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	return tokenString, nil
+	s.recordLogin(ctx, user.Email, user, true)
+	return &LoginResult{Token: token}, nil
+}
+
+// rejectUnknownUser checks the password against a dummy hash made by the
+// current hasher, so that rejecting an unknown email takes as long as
+// rejecting a wrong password and response times do not reveal which emails
+// are registered. The failure counts towards a lockout like a wrong
+// password does.
+func (s *authService) rejectUnknownUser(ctx context.Context, email, password string) {
+	s.hasher.Verify(s.dummyHash(), password)
+	s.recordLogin(ctx, email, nil, false)
+	s.recordLoginFailure(ctx, email, nil)
+}
+
+// upgradePasswordHash re-hashes the just verified password if the stored
+// hash uses an outdated algorithm or cost. Failure only delays the upgrade
+// to a later login.
+func (s *authService) upgradePasswordHash(ctx context.Context, user *models.User, password string) {
+	if !s.hasher.NeedsRehash(user.PasswordHash) {
+		return
+	}
+
+	hash, err := s.hasher.Hash(password)
+	if err != nil {
+		log.Printf("Login error: failed to rehash password: %v", err)
+		return
+	}
+	if err := s.repo.UpdatePassword(ctx, user.ID, hash); err != nil {
+		log.Printf("Login error: failed to store rehashed password: %v", err)
+		return
+	}
+	user.PasswordHash = hash
+}
+
+func (s *authService) recordLogin(ctx context.Context, email string, user *models.User, success bool) {
+	event := models.AuditEvent{
+		Event:   models.AuditLogin,
+		Success: success,
+		Email:   email,
+	}
+	if user != nil {
+		event.ActorID = &user.ID
+		event.OrganizationID = user.OrganizationID
+	}
+	s.audit.Record(ctx, event)
 }
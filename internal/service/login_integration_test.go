@@ -78,10 +78,10 @@ func TestAuthService_Login_Integration(t *testing.T) {
 		testSvc.Register(ctx, email, password)
 		require.NoError(t, err)
 
-		token, err := testSvc.Login(ctx, email, password)
+		result, err := testSvc.Login(ctx, email, password)
 
 		assert.NoError(t, err)
-		assert.NotEmpty(t, token)
+		assert.NotEmpty(t, result.Token)
 	})
 
 	t.Run("fail_login_wrong_password", func(t *testing.T) {
@@ -92,28 +92,28 @@ func TestAuthService_Login_Integration(t *testing.T) {
 		testSvc.Register(ctx, email, password)
 		require.NoError(t, err)
 
-		token, err := testSvc.Login(ctx, email, "incorrect-password")
+		result, err := testSvc.Login(ctx, email, "incorrect-password")
 
 		assert.Error(t, err)
-		assert.Empty(t, token)
+		assert.Nil(t, result)
 		assert.ErrorIs(t, err, ErrInvalidCredentials)
 	})
 	t.Run("user_not_found", func(t *testing.T) {
 		ctx := context.Background()
-		token, err := testSvc.Login(ctx, "no-such-user@test.com", "password1234")
+		result, err := testSvc.Login(ctx, "no-such-user@test.com", "password1234")
 
 		assert.Error(t, err)
-		assert.Empty(t, token)
+		assert.Nil(t, result)
 		assert.ErrorIs(t, err, ErrInvalidCredentials)
 	})
 
 	t.Run("sql_injection_attempt", func(t *testing.T) {
 		ctx := context.Background()
 		maliciousEmail := "' OR 1=1; --"
-		token, err := testSvc.Login(ctx, maliciousEmail, "any")
+		result, err := testSvc.Login(ctx, maliciousEmail, "any")
 
 		assert.Error(t, err)
-		assert.Empty(t, token)
+		assert.Nil(t, result)
 	})
 
 	t.Run("case_sensitive_email", func(t *testing.T) {
@@ -124,8 +124,8 @@ func TestAuthService_Login_Integration(t *testing.T) {
 		testSvc.Register(ctx, email, pass)
 		require.NoError(t, err)
 
-		token, err := testSvc.Login(ctx, email, pass)
+		result, err := testSvc.Login(ctx, email, pass)
 		assert.NoError(t, err)
-		assert.NotEmpty(t, token)
+		assert.NotEmpty(t, result.Token)
 	})
 }
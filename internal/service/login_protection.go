@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/dvprokofiev/seating-generator-api/internal/mailer"
+	"github.com/dvprokofiev/seating-generator-api/internal/models"
+	"github.com/dvprokofiev/seating-generator-api/internal/ratelimit"
+	"github.com/dvprokofiev/seating-generator-api/internal/repository"
+)
+
+var (
+	ErrTooManyAttempts = errors.New("Too many login attempts")
+	ErrAccountLocked   = errors.New("Account is temporarily locked")
+)
+
+// RetryLaterError refuses a login for a limited time. It wraps either
+// ErrTooManyAttempts or ErrAccountLocked.
+type RetryLaterError struct {
+	Err        error
+	RetryAfter time.Duration
+}
+
+func (e *RetryLaterError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RetryLaterError) Unwrap() error {
+	return e.Err
+}
+
+// failureMemory is how long a failed login counts towards a lockout.
+const failureMemory = 24 * time.Hour
+
+// LoginProtection throttles login attempts per client IP and per email, and
+// locks an email for LockoutDuration after MaxFailures wrong passwords in a
+// row. Failures are counted in Failures by email, whether or not an account
+// exists, so that a lockout does not reveal which emails are registered.
+type LoginProtection struct {
+	Store           ratelimit.Store
+	Failures        repository.LoginFailureRepository
+	PerIP           ratelimit.Limit
+	PerEmail        ratelimit.Limit
+	MaxFailures     int
+	LockoutDuration time.Duration
+}
+
+func DefaultLoginProtection(store ratelimit.Store, failures repository.LoginFailureRepository) LoginProtection {
+	return LoginProtection{
+		Store:           store,
+		Failures:        failures,
+		PerIP:           ratelimit.Limit{Burst: 20, Per: time.Minute},
+		PerEmail:        ratelimit.Limit{Burst: 5, Per: time.Minute},
+		MaxFailures:     5,
+		LockoutDuration: 15 * time.Minute,
+	}
+}
+
+// WithLoginProtection enables rate limiting and account lockout on Login.
+func WithLoginProtection(p LoginProtection) AuthOption {
+	return func(s *authService) {
+		s.protection = &p
+	}
+}
+
+// WithMailer lets the service email users, e.g. about a locked account.
+func WithMailer(m mailer.Mailer) AuthOption {
+	return func(s *authService) {
+		s.mailer = m
+	}
+}
+
+func (s *authService) checkLoginRate(ctx context.Context, email string) error {
+	if s.protection == nil {
+		return nil
+	}
+
+	type bucket struct {
+		key   string
+		limit ratelimit.Limit
+	}
+	buckets := []bucket{{"login:email:" + email, s.protection.PerEmail}}
+	if ip := RequestMetaFromContext(ctx).IPAddress; ip != "" {
+		buckets = append(buckets, bucket{"login:ip:" + ip, s.protection.PerIP})
+	}
+
+	for _, b := range buckets {
+		ok, retryAfter, err := s.protection.Store.Take(ctx, b.key, b.limit)
+		if err != nil {
+			// an unavailable store must not lock everybody out
+			log.Printf("Rate limit error: %v", err)
+			continue
+		}
+		if !ok {
+			return &RetryLaterError{Err: ErrTooManyAttempts, RetryAfter: retryAfter}
+		}
+	}
+	return nil
+}
+
+// checkLockout refuses logins for an email that is locked. A failing
+// repository lets the login through, like the rate limit store.
+func (s *authService) checkLockout(ctx context.Context, email string) error {
+	if !s.lockoutEnabled() {
+		return nil
+	}
+
+	lockedUntil, err := s.protection.Failures.LockedUntil(ctx, email)
+	if err != nil {
+		log.Printf("Login error: failed to check lockout: %v", err)
+		return nil
+	}
+	if lockedUntil == nil {
+		return nil
+	}
+	return &RetryLaterError{Err: ErrAccountLocked, RetryAfter: time.Until(*lockedUntil)}
+}
+
+// recordLoginFailure counts a wrong password for email and locks it once
+// there were too many. user is nil for unknown emails, which are locked
+// the same way but notified to nobody.
+func (s *authService) recordLoginFailure(ctx context.Context, email string, user *models.User) {
+	if !s.lockoutEnabled() {
+		return
+	}
+
+	now := time.Now()
+	lockUntil := now.Add(s.protection.LockoutDuration).UTC()
+	locked, err := s.protection.Failures.Increment(ctx, email, s.protection.MaxFailures, lockUntil, now.Add(-failureMemory).UTC())
+	if err != nil {
+		log.Printf("Login error: failed to count failed login: %v", err)
+		return
+	}
+	if !locked {
+		return
+	}
+
+	event := models.AuditEvent{
+		Event:   models.AuditAccountLocked,
+		Success: true,
+		Email:   email,
+		Details: map[string]string{"locked_until": lockUntil.Format(time.RFC3339)},
+	}
+	if user != nil {
+		event.ActorID = &user.ID
+		event.OrganizationID = user.OrganizationID
+	}
+	s.audit.Record(ctx, event)
+
+	if user != nil && s.mailer != nil {
+		msg := mailer.Message{
+			To:      user.Email,
+			Subject: "Your account has been temporarily locked",
+			Body: fmt.Sprintf("We locked your account after %d failed sign-in attempts in a row.\n"+
+				"The last attempt came from %s.\n\n"+
+				"You can sign in again after %s UTC. If these attempts were not yours, "+
+				"please change your password once the lock expires.\n",
+				s.protection.MaxFailures, clientDescription(ctx), lockUntil.Format("2006-01-02 15:04")),
+		}
+		if err := s.mailer.Send(ctx, msg); err != nil {
+			log.Printf("Login error: failed to send lockout notification: %v", err)
+		}
+	}
+}
+
+func (s *authService) resetLoginFailures(ctx context.Context, email string) {
+	if !s.lockoutEnabled() {
+		return
+	}
+	if err := s.protection.Failures.Reset(ctx, email); err != nil {
+		log.Printf("Login error: failed to reset failed logins: %v", err)
+	}
+}
+
+func (s *authService) lockoutEnabled() bool {
+	return s.protection != nil && s.protection.Failures != nil && s.protection.MaxFailures > 0
+}
+
+func clientDescription(ctx context.Context) string {
+	meta := RequestMetaFromContext(ctx)
+	if meta.IPAddress == "" {
+		return "an unknown address"
+	}
+	return "IP address " + meta.IPAddress
+}
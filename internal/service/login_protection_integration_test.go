@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dvprokofiev/seating-generator-api/internal/ratelimit"
+	"github.com/dvprokofiev/seating-generator-api/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthService_LoginProtection_Integration(t *testing.T) {
+	_, err := testDB.Exec("TRUNCATE users, login_failures CASCADE")
+	require.NoError(t, err)
+
+	repos := repository.NewRepository(testDB)
+	mail := &recordingMailer{}
+	protection := DefaultLoginProtection(ratelimit.NewMemoryStore(), repos.LoginFailures)
+	protection.MaxFailures = 3
+	svc := NewAuthService(repos.Users, "test-secret", WithLoginProtection(protection), WithMailer(mail))
+
+	ctx := context.Background()
+	email := "lockme@test.com"
+	require.NoError(t, svc.Register(ctx, email, "password123"))
+
+	t.Run("locks_after_max_failures", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			_, err := svc.Login(ctx, email, "wrong-password")
+			assert.ErrorIs(t, err, ErrInvalidCredentials)
+		}
+		assert.Len(t, mail.sent, 1)
+
+		_, err := svc.Login(ctx, email, "password123")
+		assert.ErrorIs(t, err, ErrAccountLocked)
+	})
+
+	t.Run("success_after_lock_expires_resets_counter", func(t *testing.T) {
+		_, err := testDB.Exec("UPDATE login_failures SET locked_until = $1 WHERE email = $2", time.Now().Add(-time.Second), email)
+		require.NoError(t, err)
+
+		_, err = svc.Login(ctx, email, "password123")
+		require.NoError(t, err)
+
+		var count int
+		err = testDB.QueryRow("SELECT COUNT(*) FROM login_failures WHERE email = $1", email).Scan(&count)
+		require.NoError(t, err)
+		assert.Zero(t, count)
+	})
+
+	t.Run("unknown_email_locks_without_mail", func(t *testing.T) {
+		sent := len(mail.sent)
+		for i := 0; i < 3; i++ {
+			_, err := svc.Login(ctx, "nobody@test.com", "password123")
+			assert.ErrorIs(t, err, ErrInvalidCredentials)
+		}
+
+		_, err := svc.Login(ctx, "nobody@test.com", "password123")
+		assert.ErrorIs(t, err, ErrAccountLocked)
+		assert.Len(t, mail.sent, sent)
+	})
+}
@@ -0,0 +1,176 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/dvprokofiev/seating-generator-api/internal/mailer"
+	"github.com/dvprokofiev/seating-generator-api/internal/models"
+	"github.com/dvprokofiev/seating-generator-api/internal/ratelimit"
+	"github.com/dvprokofiev/seating-generator-api/internal/repository"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type recordingMailer struct {
+	sent []mailer.Message
+	// delivered, if set, is signalled after each message, for tests whose
+	// mail is sent in the background.
+	delivered chan struct{}
+}
+
+func (m *recordingMailer) Send(_ context.Context, msg mailer.Message) error {
+	m.sent = append(m.sent, msg)
+	if m.delivered != nil {
+		m.delivered <- struct{}{}
+	}
+	return nil
+}
+
+func TestAuthService_LoginProtection_Unit(t *testing.T) {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.DefaultCost)
+	protection := DefaultLoginProtection(ratelimit.NewMemoryStore(), nil)
+	protection.PerEmail = ratelimit.Limit{Burst: 2, Per: time.Minute}
+
+	t.Run("per_email_rate_limit", func(t *testing.T) {
+		mockRepo := new(repository.MockUserRepository)
+		p := protection
+		p.Store = ratelimit.NewMemoryStore()
+		svc := NewAuthService(mockRepo, "secret", WithLoginProtection(p))
+
+		mockRepo.On("GetByEmail", mock.Anything, "ghost@test.ru").Return(nil, nil).Twice()
+
+		for i := 0; i < 2; i++ {
+			_, err := svc.Login(context.Background(), "ghost@test.ru", "password123")
+			assert.ErrorIs(t, err, ErrInvalidCredentials)
+		}
+
+		_, err := svc.Login(context.Background(), "Ghost@test.ru", "password123")
+
+		var retryLater *RetryLaterError
+		assert.ErrorAs(t, err, &retryLater)
+		assert.ErrorIs(t, err, ErrTooManyAttempts)
+		assert.Greater(t, retryLater.RetryAfter, time.Duration(0))
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("per_ip_rate_limit", func(t *testing.T) {
+		mockRepo := new(repository.MockUserRepository)
+		p := protection
+		p.Store = ratelimit.NewMemoryStore()
+		p.PerIP = ratelimit.Limit{Burst: 1, Per: time.Minute}
+		svc := NewAuthService(mockRepo, "secret", WithLoginProtection(p))
+		ctx := ContextWithRequestMeta(context.Background(), RequestMeta{IPAddress: "203.0.113.9"})
+
+		mockRepo.On("GetByEmail", mock.Anything, "one@test.ru").Return(nil, nil).Once()
+
+		_, err := svc.Login(ctx, "one@test.ru", "password123")
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+
+		_, err = svc.Login(ctx, "two@test.ru", "password123")
+		assert.ErrorIs(t, err, ErrTooManyAttempts)
+	})
+
+	t.Run("lockout_notifies_user", func(t *testing.T) {
+		mockRepo := new(repository.MockUserRepository)
+		failures := new(repository.MockLoginFailureRepository)
+		mail := &recordingMailer{}
+		p := protection
+		p.Store = ratelimit.NewMemoryStore()
+		p.Failures = failures
+		svc := NewAuthService(mockRepo, "secret", WithLoginProtection(p), WithMailer(mail))
+		user := &models.User{ID: uuid.New(), Email: "teacher@test.ru", PasswordHash: string(hash)}
+
+		failures.On("LockedUntil", mock.Anything, "teacher@test.ru").Return(nil, nil).Once()
+		mockRepo.On("GetByEmail", mock.Anything, "teacher@test.ru").Return(user, nil).Once()
+		failures.On("Increment", mock.Anything, "teacher@test.ru", p.MaxFailures,
+			mock.AnythingOfType("time.Time"), mock.AnythingOfType("time.Time")).Return(true, nil).Once()
+
+		_, err := svc.Login(context.Background(), "teacher@test.ru", "wrong-password")
+
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+		assert.Len(t, mail.sent, 1)
+		assert.Equal(t, "teacher@test.ru", mail.sent[0].To)
+		mockRepo.AssertExpectations(t)
+		failures.AssertExpectations(t)
+	})
+
+	t.Run("unknown_email_is_locked_alike", func(t *testing.T) {
+		mockRepo := new(repository.MockUserRepository)
+		failures := new(repository.MockLoginFailureRepository)
+		mail := &recordingMailer{}
+		p := protection
+		p.Store = ratelimit.NewMemoryStore()
+		p.Failures = failures
+		svc := NewAuthService(mockRepo, "secret", WithLoginProtection(p), WithMailer(mail))
+		lockedUntil := time.Now().Add(10 * time.Minute)
+
+		failures.On("LockedUntil", mock.Anything, "ghost@test.ru").Return(nil, nil).Once()
+		mockRepo.On("GetByEmail", mock.Anything, "ghost@test.ru").Return(nil, sql.ErrNoRows).Twice()
+		failures.On("Increment", mock.Anything, "ghost@test.ru", p.MaxFailures,
+			mock.AnythingOfType("time.Time"), mock.AnythingOfType("time.Time")).Return(true, nil).Once()
+		failures.On("LockedUntil", mock.Anything, "ghost@test.ru").Return(&lockedUntil, nil).Once()
+
+		_, err := svc.Login(context.Background(), "ghost@test.ru", "password123")
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+
+		_, err = svc.Login(context.Background(), "ghost@test.ru", "password123")
+
+		var retryLater *RetryLaterError
+		assert.ErrorAs(t, err, &retryLater)
+		assert.ErrorIs(t, err, ErrAccountLocked)
+		assert.InDelta(t, 10*time.Minute, retryLater.RetryAfter, float64(time.Minute))
+		assert.Empty(t, mail.sent)
+		mockRepo.AssertExpectations(t)
+		failures.AssertExpectations(t)
+	})
+
+	t.Run("locked_account_refuses_correct_password", func(t *testing.T) {
+		mockRepo := new(repository.MockUserRepository)
+		failures := new(repository.MockLoginFailureRepository)
+		p := protection
+		p.Store = ratelimit.NewMemoryStore()
+		p.Failures = failures
+		svc := NewAuthService(mockRepo, "secret", WithLoginProtection(p))
+		lockedUntil := time.Now().Add(10 * time.Minute)
+		user := &models.User{ID: uuid.New(), Email: "locked@test.ru", PasswordHash: string(hash)}
+
+		failures.On("LockedUntil", mock.Anything, "locked@test.ru").Return(&lockedUntil, nil).Once()
+		mockRepo.On("GetByEmail", mock.Anything, "locked@test.ru").Return(user, nil).Once()
+
+		result, err := svc.Login(context.Background(), "locked@test.ru", "password123")
+
+		var retryLater *RetryLaterError
+		assert.Nil(t, result)
+		assert.ErrorAs(t, err, &retryLater)
+		assert.ErrorIs(t, err, ErrAccountLocked)
+		assert.InDelta(t, 10*time.Minute, retryLater.RetryAfter, float64(time.Minute))
+		mockRepo.AssertExpectations(t)
+		failures.AssertExpectations(t)
+	})
+
+	t.Run("successful_login_resets_failures", func(t *testing.T) {
+		mockRepo := new(repository.MockUserRepository)
+		failures := new(repository.MockLoginFailureRepository)
+		p := protection
+		p.Store = ratelimit.NewMemoryStore()
+		p.Failures = failures
+		svc := NewAuthService(mockRepo, "secret", WithLoginProtection(p))
+		user := &models.User{ID: uuid.New(), Email: "back@test.ru", PasswordHash: string(hash)}
+
+		failures.On("LockedUntil", mock.Anything, "back@test.ru").Return(nil, nil).Once()
+		mockRepo.On("GetByEmail", mock.Anything, "back@test.ru").Return(user, nil).Once()
+		failures.On("Reset", mock.Anything, "back@test.ru").Return(nil).Once()
+
+		result, err := svc.Login(context.Background(), "back@test.ru", "password123")
+
+		assert.NoError(t, err)
+		assert.NotEmpty(t, result.Token)
+		mockRepo.AssertExpectations(t)
+		failures.AssertExpectations(t)
+	})
+}
@@ -2,7 +2,11 @@ package service
 
 import (
 	"context"
+	"database/sql"
+	"math"
+	"slices"
 	"testing"
+	"time"
 
 	"github.com/dvprokofiev/seating-generator-api/internal/models"
 	"github.com/dvprokofiev/seating-generator-api/internal/repository"
@@ -31,10 +35,10 @@ func TestAuthService_Login_UUID(t *testing.T) {
 
 		mockRepo.On("GetByEmail", mock.Anything, email).Return(existingUser, nil).Once()
 
-		token, err := svc.Login(context.Background(), email, pass)
+		result, err := svc.Login(context.Background(), email, pass)
 
 		assert.NoError(t, err)
-		assert.NotEmpty(t, token)
+		assert.NotEmpty(t, result.Token)
 		mockRepo.AssertExpectations(t)
 	})
 	t.Run("wrong_email", func(t *testing.T) {
@@ -42,10 +46,10 @@ func TestAuthService_Login_UUID(t *testing.T) {
 		mockRepo.On("GetByEmail", mock.Anything, email).
 			Return(nil, assert.AnError).Once()
 
-		token, err := svc.Login(context.Background(), email, "any-password")
+		result, err := svc.Login(context.Background(), email, "any-password")
 
 		assert.Error(t, err)
-		assert.Empty(t, token)
+		assert.Nil(t, result)
 		mockRepo.AssertExpectations(t)
 	})
 	t.Run("wrong_password", func(t *testing.T) {
@@ -61,25 +65,31 @@ func TestAuthService_Login_UUID(t *testing.T) {
 		}
 		mockRepo.On("GetByEmail", mock.Anything, email).Return(existingUser, nil).Once()
 
-		token, err := svc.Login(context.Background(), email, wrongPassword)
+		result, err := svc.Login(context.Background(), email, wrongPassword)
 		assert.Error(t, err)
-		assert.Empty(t, token)
+		assert.Nil(t, result)
 
 		mockRepo.AssertExpectations(t)
 	})
-	t.Run("short_password", func(t *testing.T) {
+	t.Run("short_password_allowed_by_policy", func(t *testing.T) {
 		localMock := new(repository.MockUserRepository)
-		localSvc := NewAuthService(localMock, "secret")
+		policy := DefaultPasswordPolicy()
+		policy.MinLength = 6
+		localSvc := NewAuthService(localMock, "secret", WithPasswordPolicy(policy))
 
 		email := "test@test.ru"
-		shortPass := "12345"
+		shortPass := "x7#kQ2"
+		localMock.On("Create", mock.Anything, mock.AnythingOfType("*models.User")).Run(func(args mock.Arguments) {
+			user := args.Get(1).(*models.User)
+			localMock.On("GetByEmail", mock.Anything, email).Return(user, nil).Once()
+		}).Return(nil).Once()
 
-		token, err := localSvc.Login(context.Background(), email, shortPass)
+		assert.NoError(t, localSvc.Register(context.Background(), email, shortPass))
+		result, err := localSvc.Login(context.Background(), email, shortPass)
 
-		assert.Error(t, err)
-		assert.Empty(t, token)
-		assert.ErrorIs(t, err, ErrPasswordTooShort)
-		localMock.AssertNotCalled(t, "GetByEmail", mock.Anything, mock.Anything)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, result.Token)
+		localMock.AssertExpectations(t)
 	})
 	t.Run("incorrect_email", func(t *testing.T) {
 		localMock := new(repository.MockUserRepository)
@@ -88,11 +98,62 @@ func TestAuthService_Login_UUID(t *testing.T) {
 		email := "not_an_email"
 		shortPass := "12345678"
 
-		token, err := localSvc.Login(context.Background(), email, shortPass)
+		result, err := localSvc.Login(context.Background(), email, shortPass)
 
 		assert.Error(t, err)
-		assert.Empty(t, token)
+		assert.Nil(t, result)
 		assert.ErrorIs(t, err, ErrInvalidEmail)
 		localMock.AssertNotCalled(t, "GetByEmail", mock.Anything, mock.Anything)
 	})
 }
+
+// TestAuthService_Login_TimingSafe checks that rejecting an unknown email
+// costs as much as rejecting a wrong password for a registered one, so the
+// response time cannot be used to enumerate accounts.
+func TestAuthService_Login_TimingSafe(t *testing.T) {
+	if testing.Short() {
+		t.Skip("timing comparison runs bcrypt dozens of times")
+	}
+
+	const samples = 15
+	const tolerance = 0.25
+
+	mockRepo := new(repository.MockUserRepository)
+	svc := NewAuthService(mockRepo, "secret")
+
+	hash, _ := bcrypt.GenerateFromPassword([]byte("correct-password"), bcrypt.DefaultCost)
+	known := &models.User{ID: uuid.New(), Email: "known@test.ru", PasswordHash: string(hash)}
+
+	mockRepo.On("GetByEmail", mock.Anything, "known@test.ru").Return(known, nil)
+	mockRepo.On("GetByEmail", mock.Anything, "unknown@test.ru").Return(nil, sql.ErrNoRows)
+
+	measure := func(email string) time.Duration {
+		start := time.Now()
+		_, err := svc.Login(context.Background(), email, "wrong-password")
+		elapsed := time.Since(start)
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+		return elapsed
+	}
+
+	// warm up, including the lazily generated dummy hash
+	measure("known@test.ru")
+	measure("unknown@test.ru")
+
+	var knownTimes, unknownTimes []time.Duration
+	for i := 0; i < samples; i++ {
+		knownTimes = append(knownTimes, measure("known@test.ru"))
+		unknownTimes = append(unknownTimes, measure("unknown@test.ru"))
+	}
+
+	knownMedian, unknownMedian := median(knownTimes), median(unknownTimes)
+	diff := math.Abs(float64(knownMedian-unknownMedian)) / float64(max(knownMedian, unknownMedian))
+
+	assert.Lessf(t, diff, tolerance,
+		"median login time for a known email is %v but %v for an unknown one", knownMedian, unknownMedian)
+}
+
+func median(d []time.Duration) time.Duration {
+	sorted := slices.Clone(d)
+	slices.Sort(sorted)
+	return sorted[len(sorted)/2]
+}
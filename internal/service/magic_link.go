@@ -0,0 +1,252 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/mail"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dvprokofiev/seating-generator-api/internal/mailer"
+	"github.com/dvprokofiev/seating-generator-api/internal/models"
+	"github.com/dvprokofiev/seating-generator-api/internal/repository"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrMagicLinksNotConfigured = errors.New("Sign-in links are not available")
+	ErrInvalidMagicLink        = errors.New("Invalid or expired sign-in link")
+	ErrMagicLinksBusy          = errors.New("Too many sign-in links are being sent, try again later")
+)
+
+const (
+	magicLinkTTL = 15 * time.Minute
+
+	// Links are sent by a fixed number of workers from a bounded queue, so
+	// a flood of requests cannot start unbounded work.
+	magicLinkWorkers     = 4
+	magicLinkQueueSize   = 256
+	magicLinkSendTimeout = 30 * time.Second
+)
+
+type magicLinkConfig struct {
+	repo repository.MagicLinkRepository
+	url  string
+
+	mu      sync.RWMutex
+	closed  bool
+	queue   chan magicLinkRequest
+	workers sync.WaitGroup
+}
+
+// magicLinkRequest is a link waiting to be sent. client describes who
+// asked for it, for the email.
+type magicLinkRequest struct {
+	email  string
+	client string
+}
+
+// start runs the workers that hand queued requests to send.
+func (c *magicLinkConfig) start(send func(magicLinkRequest)) {
+	c.queue = make(chan magicLinkRequest, magicLinkQueueSize)
+	for range magicLinkWorkers {
+		c.workers.Add(1)
+		go func() {
+			defer c.workers.Done()
+			for req := range c.queue {
+				send(req)
+			}
+		}()
+	}
+}
+
+// enqueue adds req to the queue without waiting. It reports false when the
+// queue is full or closed.
+func (c *magicLinkConfig) enqueue(req magicLinkRequest) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.closed {
+		return false
+	}
+
+	select {
+	case c.queue <- req:
+		return true
+	default:
+		return false
+	}
+}
+
+// close stops accepting requests and waits until the queued ones are sent
+// or ctx is done.
+func (c *magicLinkConfig) close(ctx context.Context) error {
+	c.mu.Lock()
+	if !c.closed {
+		c.closed = true
+		close(c.queue)
+	}
+	c.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		c.workers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// WithMagicLinks enables passwordless sign-in by email. The emailed link
+// is linkURL with the token added as the "token" query parameter; the page
+// behind it posts the token back to finish the login. Links are sent with
+// the mailer set by WithMailer.
+func WithMagicLinks(repo repository.MagicLinkRepository, linkURL string) AuthOption {
+	return func(s *authService) {
+		s.magicLinks = &magicLinkConfig{repo: repo, url: linkURL}
+	}
+}
+
+func hashMagicLinkToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// RequestMagicLink emails a sign-in link to the user with the given email.
+// It succeeds for unknown emails too, so that the response does not reveal
+// which emails are registered. For the same reason the link is stored and
+// mailed in the background: the response returns before the user lookup,
+// so it takes as long for unknown emails as for registered ones. When the
+// background queue is full, it fails with ErrMagicLinksBusy.
+func (s *authService) RequestMagicLink(ctx context.Context, email string) error {
+	if s.magicLinks == nil || s.mailer == nil {
+		return ErrMagicLinksNotConfigured
+	}
+	if _, err := mail.ParseAddress(email); err != nil {
+		return ErrInvalidEmail
+	}
+
+	email = strings.ToLower(email)
+	if err := s.checkLoginRate(ctx, email); err != nil {
+		return err
+	}
+
+	if !s.magicLinks.enqueue(magicLinkRequest{email: email, client: clientDescription(ctx)}) {
+		return ErrMagicLinksBusy
+	}
+	return nil
+}
+
+// deliverMagicLink sends a queued link, giving up after
+// magicLinkSendTimeout.
+func (s *authService) deliverMagicLink(req magicLinkRequest) {
+	ctx, cancel := context.WithTimeout(context.Background(), magicLinkSendTimeout)
+	defer cancel()
+
+	if err := s.sendMagicLink(ctx, req); err != nil {
+		log.Printf("Magic link error: failed to send sign-in link: %v", err)
+	}
+}
+
+// sendMagicLink stores a new link for the user with the requested email
+// and mails it. Unknown emails get nothing.
+func (s *authService) sendMagicLink(ctx context.Context, req magicLinkRequest) error {
+	user, err := s.repo.GetByEmail(ctx, req.email)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+
+	link, err := url.Parse(s.magicLinks.url)
+	if err != nil {
+		return err
+	}
+	token := rand.Text()
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+
+	now := time.Now().UTC()
+	err = s.magicLinks.repo.Save(ctx, &models.MagicLink{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		TokenHash: hashMagicLinkToken(token),
+		ExpiresAt: now.Add(magicLinkTTL),
+		CreatedAt: now,
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Your sign-in link",
+		Body: fmt.Sprintf("Open this link to sign in:\n\n%s\n\n"+
+			"The link works once and expires in %d minutes. It was requested from %s.\n"+
+			"If you did not ask to sign in, you can ignore this email.\n",
+			link, int(magicLinkTTL.Minutes()), req.client),
+	})
+}
+
+// FinishMagicLinkLogin redeems a token from a sign-in link and returns the
+// same result as Login. Opening the link proves the user owns the email
+// address, so the address is marked verified. The link replaces only the
+// password: users with TOTP still get an MFA challenge.
+func (s *authService) FinishMagicLinkLogin(ctx context.Context, token string) (*LoginResult, error) {
+	if s.magicLinks == nil {
+		return nil, ErrMagicLinksNotConfigured
+	}
+
+	link, err := s.magicLinks.repo.Take(ctx, hashMagicLinkToken(token))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrInvalidMagicLink
+		}
+		return nil, err
+	}
+
+	user, err := s.repo.GetByID(ctx, link.UserID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrInvalidMagicLink
+		}
+		return nil, err
+	}
+
+	if err := s.checkLockout(ctx, user.Email); err != nil {
+		s.recordLogin(ctx, user.Email, user, false)
+		return nil, err
+	}
+
+	if !user.IsVerified {
+		if err := s.repo.UpdateVerified(ctx, user.ID, true); err != nil {
+			return nil, err
+		}
+		user.IsVerified = true
+		s.audit.Record(ctx, models.AuditEvent{
+			Event:          models.AuditEmailVerify,
+			Success:        true,
+			ActorID:        &user.ID,
+			OrganizationID: user.OrganizationID,
+			TargetType:     "user",
+			TargetID:       user.ID.String(),
+			Email:          user.Email,
+			Details:        map[string]string{"method": "magic_link"},
+		})
+	}
+
+	return s.finishLogin(ctx, user)
+}
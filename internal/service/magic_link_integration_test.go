@@ -0,0 +1,66 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dvprokofiev/seating-generator-api/internal/models"
+	"github.com/dvprokofiev/seating-generator-api/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthService_MagicLink_Integration(t *testing.T) {
+	_, err := testDB.Exec("TRUNCATE users, audit_log CASCADE")
+	require.NoError(t, err)
+
+	repos := repository.NewRepository(testDB)
+	mail := linkMailer()
+	svc := NewAuthService(repos.Users, "test-secret", WithMailer(mail),
+		WithMagicLinks(repos.MagicLinks, "https://seating.test/login/link"),
+		WithAuditLog(NewAuditService(repos.Audit, NewRolePolicy())))
+	ctx := context.Background()
+
+	require.NoError(t, svc.Register(ctx, "linked@school.test", "password123"))
+	user, err := repos.Users.GetByEmail(ctx, "linked@school.test")
+	require.NoError(t, err)
+	require.False(t, user.IsVerified)
+
+	requestMagicLink(t, svc, mail, "linked@school.test")
+	first := magicLinkToken(t, mail)
+	requestMagicLink(t, svc, mail, "linked@school.test")
+	second := magicLinkToken(t, mail)
+
+	t.Run("only_latest_link_works", func(t *testing.T) {
+		_, err := svc.FinishMagicLinkLogin(ctx, first)
+		assert.ErrorIs(t, err, ErrInvalidMagicLink)
+	})
+
+	t.Run("link_logs_in_once_and_verifies_email", func(t *testing.T) {
+		result, err := svc.FinishMagicLinkLogin(ctx, second)
+		require.NoError(t, err)
+		p, err := svc.ParseToken(ctx, result.Token)
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, p.UserID)
+
+		user, err := repos.Users.GetByID(ctx, user.ID)
+		require.NoError(t, err)
+		assert.True(t, user.IsVerified)
+
+		events, err := repos.Audit.List(ctx, models.AuditFilter{Email: user.Email, Event: models.AuditEmailVerify, Limit: 10})
+		require.NoError(t, err)
+		assert.Len(t, events, 1)
+
+		_, err = svc.FinishMagicLinkLogin(ctx, second)
+		assert.ErrorIs(t, err, ErrInvalidMagicLink)
+	})
+
+	t.Run("expired_link_rejected", func(t *testing.T) {
+		requestMagicLink(t, svc, mail, "linked@school.test")
+		_, err := testDB.Exec("UPDATE magic_links SET expires_at = NOW() - INTERVAL '1 minute'")
+		require.NoError(t, err)
+
+		_, err = svc.FinishMagicLinkLogin(ctx, magicLinkToken(t, mail))
+		assert.ErrorIs(t, err, ErrInvalidMagicLink)
+	})
+}
@@ -0,0 +1,230 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dvprokofiev/seating-generator-api/internal/models"
+	"github.com/dvprokofiev/seating-generator-api/internal/repository"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// linkMailer records mail sent in the background.
+func linkMailer() *recordingMailer {
+	return &recordingMailer{delivered: make(chan struct{}, 1)}
+}
+
+// requestMagicLink asks for a link and waits until it has been mailed.
+func requestMagicLink(t *testing.T, svc AuthService, mail *recordingMailer, email string) {
+	t.Helper()
+	require.NoError(t, svc.RequestMagicLink(context.Background(), email))
+	select {
+	case <-mail.delivered:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no sign-in link mailed")
+	}
+}
+
+// magicLinkToken returns the token of the link in the last mail sent.
+func magicLinkToken(t *testing.T, m *recordingMailer) string {
+	t.Helper()
+	require.NotEmpty(t, m.sent)
+
+	for _, line := range strings.Split(m.sent[len(m.sent)-1].Body, "\n") {
+		if strings.HasPrefix(line, "https://") {
+			link, err := url.Parse(line)
+			require.NoError(t, err)
+			return link.Query().Get("token")
+		}
+	}
+	t.Fatal("no link in mail")
+	return ""
+}
+
+func TestAuthService_MagicLink_Unit(t *testing.T) {
+	ctx := context.Background()
+	orgID := uuid.New()
+	user := &models.User{ID: uuid.New(), Email: "teacher@school.test", OrganizationID: &orgID, Role: models.RoleTeacher}
+	const linkURL = "https://seating.test/login/link?lang=ru"
+
+	// saveLink expects one link to be stored for user and takes it back
+	// when redeemed.
+	saveLink := func(linkRepo *repository.MockMagicLinkRepository) *models.MagicLink {
+		saved := &models.MagicLink{}
+		linkRepo.On("Save", mock.Anything, mock.MatchedBy(func(l *models.MagicLink) bool {
+			return l.UserID == user.ID && l.ExpiresAt.After(time.Now())
+		})).Run(func(args mock.Arguments) {
+			*saved = *args.Get(1).(*models.MagicLink)
+		}).Return(nil).Once()
+		return saved
+	}
+
+	t.Run("unknown_email_sends_nothing", func(t *testing.T) {
+		userRepo := new(repository.MockUserRepository)
+		linkRepo := new(repository.MockMagicLinkRepository)
+		mail := linkMailer()
+		svc := NewAuthService(userRepo, "secret", WithMailer(mail), WithMagicLinks(linkRepo, linkURL))
+		userRepo.On("GetByEmail", mock.Anything, "ghost@school.test").Return(nil, sql.ErrNoRows).Once()
+
+		require.NoError(t, svc.RequestMagicLink(ctx, "ghost@school.test"))
+		require.NoError(t, svc.Close(ctx))
+
+		assert.Empty(t, mail.sent)
+		userRepo.AssertExpectations(t)
+		linkRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+	})
+
+	t.Run("link_logs_in_and_verifies_email", func(t *testing.T) {
+		unverified := *user
+		userRepo := new(repository.MockUserRepository)
+		linkRepo := new(repository.MockMagicLinkRepository)
+		auditRepo := new(repository.MockAuditRepository)
+		mail := linkMailer()
+		svc := NewAuthService(userRepo, "secret", WithMailer(mail), WithMagicLinks(linkRepo, linkURL),
+			WithAuditLog(NewAuditService(auditRepo, NewRolePolicy())))
+
+		userRepo.On("GetByEmail", mock.Anything, user.Email).Return(&unverified, nil).Once()
+		saved := saveLink(linkRepo)
+		requestMagicLink(t, svc, mail, "Teacher@School.test")
+		require.Len(t, mail.sent, 1)
+		assert.Equal(t, user.Email, mail.sent[0].To)
+		assert.Contains(t, mail.sent[0].Body, "lang=ru")
+
+		token := magicLinkToken(t, mail)
+		assert.Equal(t, hashMagicLinkToken(token), saved.TokenHash)
+		linkRepo.On("Take", mock.Anything, saved.TokenHash).Return(saved, nil).Once()
+		userRepo.On("GetByID", mock.Anything, user.ID).Return(&unverified, nil).Once()
+		userRepo.On("UpdateVerified", mock.Anything, user.ID, true).Return(nil).Once()
+		auditRepo.On("Append", mock.Anything, mock.MatchedBy(func(e *models.AuditEvent) bool {
+			return e.Event == models.AuditEmailVerify && e.TargetID == user.ID.String() && e.Details["method"] == "magic_link"
+		})).Return(nil).Once()
+		auditRepo.On("Append", mock.Anything, mock.MatchedBy(func(e *models.AuditEvent) bool {
+			return e.Event == models.AuditLogin && e.Success
+		})).Return(nil).Once()
+
+		result, err := svc.FinishMagicLinkLogin(ctx, token)
+		require.NoError(t, err)
+
+		userRepo.On("GetByID", mock.Anything, user.ID).Return(user, nil).Once()
+		p, err := svc.ParseToken(ctx, result.Token)
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, p.UserID)
+		assert.Equal(t, orgID, *p.OrganizationID)
+		userRepo.AssertExpectations(t)
+		linkRepo.AssertExpectations(t)
+		auditRepo.AssertExpectations(t)
+	})
+
+	t.Run("verified_email_not_verified_again", func(t *testing.T) {
+		verified := *user
+		verified.IsVerified = true
+		userRepo := new(repository.MockUserRepository)
+		linkRepo := new(repository.MockMagicLinkRepository)
+		mail := linkMailer()
+		svc := NewAuthService(userRepo, "secret", WithMailer(mail), WithMagicLinks(linkRepo, linkURL))
+
+		userRepo.On("GetByEmail", mock.Anything, user.Email).Return(&verified, nil).Once()
+		saved := saveLink(linkRepo)
+		requestMagicLink(t, svc, mail, user.Email)
+		linkRepo.On("Take", mock.Anything, saved.TokenHash).Return(saved, nil).Once()
+		userRepo.On("GetByID", mock.Anything, user.ID).Return(&verified, nil).Once()
+
+		_, err := svc.FinishMagicLinkLogin(ctx, magicLinkToken(t, mail))
+		require.NoError(t, err)
+		userRepo.AssertExpectations(t)
+		userRepo.AssertNotCalled(t, "UpdateVerified", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("used_or_replaced_link_refused", func(t *testing.T) {
+		linkRepo := new(repository.MockMagicLinkRepository)
+		svc := NewAuthService(new(repository.MockUserRepository), "secret", WithMailer(&recordingMailer{}),
+			WithMagicLinks(linkRepo, linkURL))
+		linkRepo.On("Take", mock.Anything, hashMagicLinkToken("used")).Return(nil, sql.ErrNoRows).Once()
+
+		_, err := svc.FinishMagicLinkLogin(ctx, "used")
+		assert.ErrorIs(t, err, ErrInvalidMagicLink)
+		linkRepo.AssertExpectations(t)
+	})
+
+	t.Run("mfa_still_required", func(t *testing.T) {
+		verified := *user
+		verified.IsVerified = true
+		userRepo := new(repository.MockUserRepository)
+		linkRepo := new(repository.MockMagicLinkRepository)
+		mfaRepo := new(repository.MockMFARepository)
+		mail := linkMailer()
+		svc := NewAuthService(userRepo, "secret", WithMailer(mail), WithMFA(mfaRepo, "Seating"),
+			WithMagicLinks(linkRepo, linkURL))
+
+		userRepo.On("GetByEmail", mock.Anything, user.Email).Return(&verified, nil).Once()
+		saved := saveLink(linkRepo)
+		requestMagicLink(t, svc, mail, user.Email)
+		linkRepo.On("Take", mock.Anything, saved.TokenHash).Return(saved, nil).Once()
+		userRepo.On("GetByID", mock.Anything, user.ID).Return(&verified, nil).Once()
+		mfaRepo.On("Get", mock.Anything, user.ID).Return(&models.MFASettings{Enabled: true}, nil).Once()
+
+		result, err := svc.FinishMagicLinkLogin(ctx, magicLinkToken(t, mail))
+
+		challenge := mfaChallenge(t, result, err)
+		assert.False(t, challenge.EnrollmentRequired)
+		userRepo.AssertExpectations(t)
+		mfaRepo.AssertExpectations(t)
+	})
+
+	t.Run("forced_password_reset_refused", func(t *testing.T) {
+		resetRequired := *user
+		resetRequired.IsVerified = true
+		resetRequired.PasswordResetRequired = true
+		userRepo := new(repository.MockUserRepository)
+		linkRepo := new(repository.MockMagicLinkRepository)
+		mail := linkMailer()
+		svc := NewAuthService(userRepo, "secret", WithMailer(mail), WithMagicLinks(linkRepo, linkURL))
+
+		userRepo.On("GetByEmail", mock.Anything, user.Email).Return(&resetRequired, nil).Once()
+		saved := saveLink(linkRepo)
+		requestMagicLink(t, svc, mail, user.Email)
+		linkRepo.On("Take", mock.Anything, saved.TokenHash).Return(saved, nil).Once()
+		userRepo.On("GetByID", mock.Anything, user.ID).Return(&resetRequired, nil).Once()
+
+		_, err := svc.FinishMagicLinkLogin(ctx, magicLinkToken(t, mail))
+		assert.ErrorIs(t, err, ErrPasswordResetRequired)
+		userRepo.AssertExpectations(t)
+	})
+
+	t.Run("full_or_closed_queue_refuses_links", func(t *testing.T) {
+		userRepo := new(repository.MockUserRepository)
+		svc := NewAuthService(userRepo, "secret", WithMailer(&recordingMailer{}),
+			WithMagicLinks(new(repository.MockMagicLinkRepository), linkURL))
+		release := make(chan struct{})
+		userRepo.On("GetByEmail", mock.Anything, "ghost@school.test").Run(func(mock.Arguments) {
+			<-release
+		}).Return(nil, sql.ErrNoRows)
+
+		var err error
+		for range magicLinkWorkers + magicLinkQueueSize + 1 {
+			if err = svc.RequestMagicLink(ctx, "ghost@school.test"); err != nil {
+				break
+			}
+		}
+		assert.ErrorIs(t, err, ErrMagicLinksBusy)
+
+		close(release)
+		require.NoError(t, svc.Close(ctx))
+		assert.ErrorIs(t, svc.RequestMagicLink(ctx, "ghost@school.test"), ErrMagicLinksBusy)
+	})
+
+	t.Run("not_configured", func(t *testing.T) {
+		plain := NewAuthService(new(repository.MockUserRepository), "secret", WithMailer(&recordingMailer{}))
+
+		assert.ErrorIs(t, plain.RequestMagicLink(ctx, user.Email), ErrMagicLinksNotConfigured)
+		_, err := plain.FinishMagicLinkLogin(ctx, "token")
+		assert.ErrorIs(t, err, ErrMagicLinksNotConfigured)
+	})
+}
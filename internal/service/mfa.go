@@ -0,0 +1,377 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"image/png"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/dvprokofiev/seating-generator-api/internal/models"
+	"github.com/dvprokofiev/seating-generator-api/internal/repository"
+	"github.com/google/uuid"
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+)
+
+var (
+	ErrMFANotConfigured  = errors.New("Two-factor authentication is not available")
+	ErrMFAAlreadyEnabled = errors.New("Two-factor authentication is already enabled")
+	ErrMFANotEnrolled    = errors.New("Two-factor enrollment has not been started")
+	ErrInvalidMFACode    = errors.New("Invalid authentication code")
+)
+
+const (
+	totpPeriod        = 30
+	recoveryCodeCount = 10
+	qrCodeSize        = 256
+)
+
+var totpOpts = totp.ValidateOpts{
+	Period:    totpPeriod,
+	Digits:    otp.DigitsSix,
+	Algorithm: otp.AlgorithmSHA1,
+}
+
+// MFAChallenge asks for a second factor before a login completes. Token is
+// a challenge token for VerifyMFA, or, if EnrollmentRequired is set because
+// the organization enforces MFA, for enrolling first.
+type MFAChallenge struct {
+	Token              string
+	EnrollmentRequired bool
+	ExpiresIn          time.Duration
+}
+
+type MFAEnrollment struct {
+	Secret string `json:"secret"`
+	URL    string `json:"otpauth_url"`
+	// QRCode is a PNG encoding URL.
+	QRCode []byte `json:"qr_code_png"`
+}
+
+type MFAConfirmation struct {
+	// RecoveryCodes are shown once; only their hashes are stored.
+	RecoveryCodes []string `json:"recovery_codes"`
+	// Token is the access token when the enrollment completed a login.
+	Token string `json:"token,omitempty"`
+}
+
+type mfaConfig struct {
+	repo   repository.MFARepository
+	issuer string
+}
+
+// WithMFA enables TOTP two-factor authentication. issuer is the name
+// authenticator apps show next to the account.
+func WithMFA(repo repository.MFARepository, issuer string) AuthOption {
+	return func(s *authService) {
+		s.mfa = &mfaConfig{repo: repo, issuer: issuer}
+	}
+}
+
+// requireSecondFactor returns the challenge the user has to pass before
+// getting an access token, or nil if they need none.
+func (s *authService) requireSecondFactor(ctx context.Context, user *models.User) (*MFAChallenge, error) {
+	if s.mfa == nil {
+		return nil, nil
+	}
+
+	settings, err := s.mfa.repo.Get(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	purpose := purposeMFA
+	switch {
+	case settings.Enabled:
+	case settings.Required:
+		purpose = purposeMFAEnroll
+	default:
+		return nil, nil
+	}
+
+	token, err := s.issueChallengeToken(user, purpose)
+	if err != nil {
+		return nil, err
+	}
+	return &MFAChallenge{
+		Token:              token,
+		EnrollmentRequired: purpose == purposeMFAEnroll,
+		ExpiresIn:          challengeTokenTTL,
+	}, nil
+}
+
+// mfaSubject resolves who is enrolling: the holder of an enrollment
+// challenge token from Login, or else the authenticated caller.
+func (s *authService) mfaSubject(ctx context.Context, challengeToken string) (uuid.UUID, error) {
+	if challengeToken != "" {
+		return s.parseChallengeToken(challengeToken, purposeMFAEnroll)
+	}
+	p, err := authorize(ctx, s.policy, ActionAccountManage, uuid.Nil)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return p.UserID, nil
+}
+
+// BeginMFAEnrollment generates a new TOTP secret. It only takes effect once
+// ConfirmMFAEnrollment sees a valid code for it.
+func (s *authService) BeginMFAEnrollment(ctx context.Context, challengeToken string) (*MFAEnrollment, error) {
+	if s.mfa == nil {
+		return nil, ErrMFANotConfigured
+	}
+	userID, err := s.mfaSubject(ctx, challengeToken)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.repo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      s.mfa.issuer,
+		AccountName: user.Email,
+		Period:      totpPeriod,
+		Digits:      totpOpts.Digits,
+		Algorithm:   totpOpts.Algorithm,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.mfa.repo.SetPendingSecret(ctx, userID, key.Secret()); err != nil {
+		if errors.Is(err, repository.ErrMFAAlreadyEnabled) {
+			return nil, ErrMFAAlreadyEnabled
+		}
+		return nil, err
+	}
+
+	img, err := key.Image(qrCodeSize, qrCodeSize)
+	if err != nil {
+		return nil, err
+	}
+	var qr bytes.Buffer
+	if err := png.Encode(&qr, img); err != nil {
+		return nil, err
+	}
+
+	return &MFAEnrollment{Secret: key.Secret(), URL: key.URL(), QRCode: qr.Bytes()}, nil
+}
+
+// ConfirmMFAEnrollment enables MFA once the user proves their authenticator
+// works, and returns fresh recovery codes. When the enrollment was forced
+// during login it also returns the access token for that login.
+func (s *authService) ConfirmMFAEnrollment(ctx context.Context, challengeToken, code string) (*MFAConfirmation, error) {
+	if s.mfa == nil {
+		return nil, ErrMFANotConfigured
+	}
+	userID, err := s.mfaSubject(ctx, challengeToken)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkMFARate(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	settings, err := s.mfa.repo.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if settings.Enabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	if settings.Secret == "" {
+		return nil, ErrMFANotEnrolled
+	}
+
+	step, ok := matchTOTP(settings.Secret, normalizeMFACode(code), time.Now())
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.mfa.repo.Enable(ctx, userID, step, hashes); err != nil {
+		if errors.Is(err, repository.ErrMFAAlreadyEnabled) {
+			return nil, ErrMFAAlreadyEnabled
+		}
+		return nil, err
+	}
+
+	user, err := s.repo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, models.AuditEvent{
+		Event:          models.AuditMFAEnroll,
+		Success:        true,
+		ActorID:        &user.ID,
+		OrganizationID: user.OrganizationID,
+		Email:          user.Email,
+	})
+
+	confirmation := &MFAConfirmation{RecoveryCodes: codes}
+	if challengeToken != "" {
+		confirmation.Token, err = s.issueToken(ctx, user)
+		if err != nil {
+			return nil, err
+		}
+		s.recordLogin(ctx, user.Email, user, true)
+	}
+	return confirmation, nil
+}
+
+// VerifyMFA completes a login started by Login. code is either the current
+// TOTP code or one of the unused recovery codes.
+func (s *authService) VerifyMFA(ctx context.Context, challengeToken, code string) (string, error) {
+	if s.mfa == nil {
+		return "", ErrMFANotConfigured
+	}
+	userID, err := s.parseChallengeToken(challengeToken, purposeMFA)
+	if err != nil {
+		return "", err
+	}
+	if err := s.checkMFARate(ctx, userID); err != nil {
+		return "", err
+	}
+
+	user, err := s.repo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", ErrInvalidToken
+		}
+		return "", err
+	}
+	settings, err := s.mfa.repo.Get(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	if !settings.Enabled {
+		return "", ErrInvalidToken
+	}
+
+	method, ok, err := s.checkSecondFactor(ctx, userID, settings, normalizeMFACode(code))
+	if err != nil {
+		return "", err
+	}
+	s.audit.Record(ctx, models.AuditEvent{
+		Event:          models.AuditMFAVerify,
+		Success:        ok,
+		ActorID:        &user.ID,
+		OrganizationID: user.OrganizationID,
+		Email:          user.Email,
+		Details:        map[string]string{"method": method},
+	})
+	if !ok {
+		return "", ErrInvalidMFACode
+	}
+
+	token, err := s.issueToken(ctx, user)
+	if err != nil {
+		return "", err
+	}
+	s.recordLogin(ctx, user.Email, user, true)
+	return token, nil
+}
+
+func (s *authService) checkSecondFactor(ctx context.Context, userID uuid.UUID, settings *models.MFASettings, code string) (string, bool, error) {
+	if len(code) == int(totpOpts.Digits) && isDigits(code) {
+		step, ok := matchTOTP(settings.Secret, code, time.Now())
+		if !ok {
+			return "totp", false, nil
+		}
+		// a code is valid for several steps, but each may be used once
+		fresh, err := s.mfa.repo.ConsumeStep(ctx, userID, step)
+		return "totp", fresh, err
+	}
+
+	ok, err := s.mfa.repo.UseRecoveryCode(ctx, userID, hashRecoveryCode(code))
+	return "recovery_code", ok, err
+}
+
+func (s *authService) checkMFARate(ctx context.Context, userID uuid.UUID) error {
+	if s.protection == nil {
+		return nil
+	}
+
+	ok, retryAfter, err := s.protection.Store.Take(ctx, "mfa:user:"+userID.String(), s.protection.PerEmail)
+	if err != nil {
+		log.Printf("Rate limit error: %v", err)
+		return nil
+	}
+	if !ok {
+		return &RetryLaterError{Err: ErrTooManyAttempts, RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+// matchTOTP accepts the code for the current time step or one step either
+// side to allow for clock drift, and returns the step it matched.
+func matchTOTP(secret, code string, now time.Time) (int64, bool) {
+	current := now.Unix() / totpPeriod
+	for _, step := range []int64{current, current - 1, current + 1} {
+		want, err := totp.GenerateCodeCustom(secret, time.Unix(step*totpPeriod, 0), totpOpts)
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(want), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+const recoveryCodeAlphabet = "abcdefghijklmnopqrstuvwxyz234567"
+
+// generateRecoveryCodes returns codes like "k3f9a-2hq7m" and their hashes.
+// The codes carry 50 random bits each, so a fast hash is enough to store
+// them and lets a code be looked up directly.
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		raw := make([]byte, 10)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, nil, err
+		}
+		for j, b := range raw {
+			raw[j] = recoveryCodeAlphabet[b%byte(len(recoveryCodeAlphabet))]
+		}
+		codes[i] = string(raw[:5]) + "-" + string(raw[5:])
+		hashes[i] = hashRecoveryCode(normalizeMFACode(codes[i]))
+	}
+	return codes, hashes, nil
+}
+
+func hashRecoveryCode(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
+// normalizeMFACode drops the separators users tend to type or paste.
+func normalizeMFACode(code string) string {
+	return strings.ToLower(strings.NewReplacer(" ", "", "-", "").Replace(code))
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
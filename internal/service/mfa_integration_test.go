@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dvprokofiev/seating-generator-api/internal/repository"
+	"github.com/pquerna/otp/totp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthService_MFA_Integration(t *testing.T) {
+	_, err := testDB.Exec("TRUNCATE users, organizations CASCADE")
+	require.NoError(t, err)
+
+	repos := repository.NewRepository(testDB)
+	svc := NewAuthService(repos.Users, "test-secret", WithMFA(repos.MFA, "Seating Generator"))
+	policy := NewRolePolicy()
+	orgSvc := NewOrganizationService(repos.Organizations, repos.Users, policy, NewAuditService(repos.Audit, policy))
+	ctx := context.Background()
+
+	require.NoError(t, svc.Register(ctx, "mfa-admin@school.test", "password123"))
+	result, err := svc.Login(ctx, "mfa-admin@school.test", "password123")
+	require.NoError(t, err)
+	p, err := svc.ParseToken(ctx, result.Token)
+	require.NoError(t, err)
+	org, err := orgSvc.Create(ContextWithPrincipal(ctx, *p), "Secure school")
+	require.NoError(t, err)
+
+	result, err = svc.Login(ctx, "mfa-admin@school.test", "password123")
+	require.NoError(t, err)
+	p, err = svc.ParseToken(ctx, result.Token)
+	require.NoError(t, err)
+	adminCtx := ContextWithPrincipal(ctx, *p)
+
+	_, err = orgSvc.SetMFARequired(adminCtx, org.ID, true)
+	require.NoError(t, err)
+
+	var recoveryCodes []string
+	var enrollmentCode string
+	t.Run("required_mfa_forces_enrollment", func(t *testing.T) {
+		result, err := svc.Login(ctx, "mfa-admin@school.test", "password123")
+		challenge := mfaChallenge(t, result, err)
+		require.True(t, challenge.EnrollmentRequired)
+
+		enrollment, err := svc.BeginMFAEnrollment(ctx, challenge.Token)
+		require.NoError(t, err)
+
+		enrollmentCode, _ = totp.GenerateCode(enrollment.Secret, time.Now())
+		confirmation, err := svc.ConfirmMFAEnrollment(ctx, challenge.Token, enrollmentCode)
+		require.NoError(t, err)
+		assert.NotEmpty(t, confirmation.Token)
+		recoveryCodes = confirmation.RecoveryCodes
+
+		var stored int
+		require.NoError(t, testDB.QueryRow("SELECT COUNT(*) FROM mfa_recovery_codes WHERE code_hash = $1", recoveryCodes[0]).Scan(&stored))
+		assert.Zero(t, stored, "recovery codes must be stored hashed")
+	})
+
+	t.Run("enrollment_code_cannot_be_replayed", func(t *testing.T) {
+		result, err := svc.Login(ctx, "mfa-admin@school.test", "password123")
+		challenge := mfaChallenge(t, result, err)
+		require.False(t, challenge.EnrollmentRequired)
+
+		_, err = svc.VerifyMFA(ctx, challenge.Token, enrollmentCode)
+		assert.ErrorIs(t, err, ErrInvalidMFACode)
+	})
+
+	t.Run("recovery_code_is_single_use", func(t *testing.T) {
+		result, err := svc.Login(ctx, "mfa-admin@school.test", "password123")
+		challenge := mfaChallenge(t, result, err)
+
+		_, err = svc.VerifyMFA(ctx, challenge.Token, recoveryCodes[0])
+		require.NoError(t, err)
+
+		_, err = svc.VerifyMFA(ctx, challenge.Token, recoveryCodes[0])
+		assert.ErrorIs(t, err, ErrInvalidMFACode)
+	})
+}
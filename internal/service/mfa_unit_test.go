@@ -0,0 +1,191 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dvprokofiev/seating-generator-api/internal/models"
+	"github.com/dvprokofiev/seating-generator-api/internal/repository"
+	"github.com/google/uuid"
+	"github.com/pquerna/otp/totp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const testTOTPSecret = "JBSWY3DPEHPK3PXP"
+
+// mfaChallenge returns the challenge a login answered with instead of an
+// access token.
+func mfaChallenge(t *testing.T, result *LoginResult, err error) *MFAChallenge {
+	t.Helper()
+	require.NoError(t, err)
+	require.NotNil(t, result.Challenge)
+	assert.Empty(t, result.Token)
+	return result.Challenge
+}
+
+func TestAuthService_MFA_Unit(t *testing.T) {
+	ctx := context.Background()
+	orgID := uuid.New()
+	hash, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.DefaultCost)
+	user := &models.User{
+		ID: uuid.New(), Email: "admin@test.ru", PasswordHash: string(hash),
+		OrganizationID: &orgID, Role: models.RoleSchoolAdmin,
+	}
+
+	newService := func() (AuthService, *repository.MockUserRepository, *repository.MockMFARepository) {
+		userRepo := new(repository.MockUserRepository)
+		mfaRepo := new(repository.MockMFARepository)
+		userRepo.On("GetByEmail", mock.Anything, user.Email).Return(user, nil).Maybe()
+		userRepo.On("GetByID", mock.Anything, user.ID).Return(user, nil).Maybe()
+		return NewAuthService(userRepo, "secret", WithMFA(mfaRepo, "Seating Generator")), userRepo, mfaRepo
+	}
+
+	t.Run("login_without_mfa_returns_access_token", func(t *testing.T) {
+		svc, _, mfaRepo := newService()
+		mfaRepo.On("Get", mock.Anything, user.ID).Return(&models.MFASettings{}, nil).Once()
+
+		result, err := svc.Login(ctx, user.Email, "password123")
+
+		require.NoError(t, err)
+		_, err = svc.ParseToken(ctx, result.Token)
+		assert.NoError(t, err)
+	})
+
+	t.Run("login_with_mfa_returns_challenge", func(t *testing.T) {
+		svc, _, mfaRepo := newService()
+		mfaRepo.On("Get", mock.Anything, user.ID).
+			Return(&models.MFASettings{Secret: testTOTPSecret, Enabled: true}, nil).Once()
+
+		result, err := svc.Login(ctx, user.Email, "password123")
+
+		challenge := mfaChallenge(t, result, err)
+		assert.False(t, challenge.EnrollmentRequired)
+
+		_, err = svc.ParseToken(ctx, challenge.Token)
+		assert.ErrorIs(t, err, ErrInvalidToken, "a challenge token must not work as an access token")
+	})
+
+	t.Run("totp_code_is_single_use", func(t *testing.T) {
+		svc, _, mfaRepo := newService()
+		settings := &models.MFASettings{Secret: testTOTPSecret, Enabled: true}
+		mfaRepo.On("Get", mock.Anything, user.ID).Return(settings, nil)
+		mfaRepo.On("ConsumeStep", mock.Anything, user.ID, mock.AnythingOfType("int64")).Return(true, nil).Once()
+		mfaRepo.On("ConsumeStep", mock.Anything, user.ID, mock.AnythingOfType("int64")).Return(false, nil).Once()
+
+		result, err := svc.Login(ctx, user.Email, "password123")
+		challenge := mfaChallenge(t, result, err)
+		code, err := totp.GenerateCode(testTOTPSecret, time.Now())
+		require.NoError(t, err)
+
+		token, err := svc.VerifyMFA(ctx, challenge.Token, code)
+		require.NoError(t, err)
+		p, err := svc.ParseToken(ctx, token)
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, p.UserID)
+
+		_, err = svc.VerifyMFA(ctx, challenge.Token, code)
+		assert.ErrorIs(t, err, ErrInvalidMFACode)
+	})
+
+	t.Run("wrong_totp_code", func(t *testing.T) {
+		svc, _, mfaRepo := newService()
+		mfaRepo.On("Get", mock.Anything, user.ID).Return(&models.MFASettings{Secret: testTOTPSecret, Enabled: true}, nil)
+
+		result, err := svc.Login(ctx, user.Email, "password123")
+		challenge := mfaChallenge(t, result, err)
+
+		_, err = svc.VerifyMFA(ctx, challenge.Token, "000000")
+
+		assert.ErrorIs(t, err, ErrInvalidMFACode)
+		mfaRepo.AssertNotCalled(t, "ConsumeStep", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("recovery_code", func(t *testing.T) {
+		svc, _, mfaRepo := newService()
+		mfaRepo.On("Get", mock.Anything, user.ID).Return(&models.MFASettings{Secret: testTOTPSecret, Enabled: true}, nil)
+		mfaRepo.On("UseRecoveryCode", mock.Anything, user.ID, hashRecoveryCode("abcdefghij")).Return(true, nil).Once()
+
+		result, err := svc.Login(ctx, user.Email, "password123")
+		challenge := mfaChallenge(t, result, err)
+
+		_, err = svc.VerifyMFA(ctx, challenge.Token, " ABCDE-fghij ")
+
+		assert.NoError(t, err)
+		mfaRepo.AssertExpectations(t)
+	})
+
+	t.Run("forced_enrollment_completes_login", func(t *testing.T) {
+		svc, _, mfaRepo := newService()
+		mfaRepo.On("Get", mock.Anything, user.ID).Return(&models.MFASettings{Required: true}, nil).Once()
+
+		result, err := svc.Login(ctx, user.Email, "password123")
+		challenge := mfaChallenge(t, result, err)
+		require.True(t, challenge.EnrollmentRequired)
+
+		_, err = svc.VerifyMFA(ctx, challenge.Token, "123456")
+		assert.ErrorIs(t, err, ErrInvalidToken, "an enrollment challenge cannot skip enrollment")
+
+		var secret string
+		mfaRepo.On("SetPendingSecret", mock.Anything, user.ID, mock.AnythingOfType("string")).
+			Run(func(args mock.Arguments) { secret = args.String(2) }).Return(nil).Once()
+
+		enrollment, err := svc.BeginMFAEnrollment(ctx, challenge.Token)
+		require.NoError(t, err)
+		assert.Equal(t, secret, enrollment.Secret)
+		assert.True(t, strings.HasPrefix(enrollment.URL, "otpauth://totp/Seating%20Generator:admin@test.ru?"))
+		assert.True(t, bytes.HasPrefix(enrollment.QRCode, []byte("\x89PNG")))
+
+		mfaRepo.On("Get", mock.Anything, user.ID).Return(&models.MFASettings{Secret: secret, Required: true}, nil).Once()
+		mfaRepo.On("Enable", mock.Anything, user.ID, mock.AnythingOfType("int64"), mock.MatchedBy(func(hashes []string) bool {
+			return len(hashes) == recoveryCodeCount
+		})).Return(nil).Once()
+
+		code, _ := totp.GenerateCode(secret, time.Now())
+		confirmation, err := svc.ConfirmMFAEnrollment(ctx, challenge.Token, code)
+
+		require.NoError(t, err)
+		assert.Len(t, confirmation.RecoveryCodes, recoveryCodeCount)
+		_, err = svc.ParseToken(ctx, confirmation.Token)
+		assert.NoError(t, err)
+		mfaRepo.AssertExpectations(t)
+	})
+
+	t.Run("confirm_rejects_wrong_code", func(t *testing.T) {
+		svc, _, mfaRepo := newService()
+		mfaRepo.On("Get", mock.Anything, user.ID).Return(&models.MFASettings{Secret: testTOTPSecret}, nil).Once()
+		principalCtx := ContextWithPrincipal(ctx, Principal{UserID: user.ID})
+
+		confirmation, err := svc.ConfirmMFAEnrollment(principalCtx, "", "000000")
+
+		assert.ErrorIs(t, err, ErrInvalidMFACode)
+		assert.Nil(t, confirmation)
+		mfaRepo.AssertNotCalled(t, "Enable", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("enrollment_requires_caller", func(t *testing.T) {
+		svc, _, _ := newService()
+
+		_, err := svc.BeginMFAEnrollment(ctx, "")
+
+		assert.ErrorIs(t, err, ErrUnauthenticated)
+	})
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, hashes, err := generateRecoveryCodes()
+	require.NoError(t, err)
+
+	seen := map[string]bool{}
+	for i, code := range codes {
+		assert.Regexp(t, `^[a-z2-7]{5}-[a-z2-7]{5}$`, code)
+		assert.Equal(t, hashRecoveryCode(normalizeMFACode(code)), hashes[i])
+		assert.False(t, seen[code])
+		seen[code] = true
+	}
+}
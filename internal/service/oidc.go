@@ -0,0 +1,357 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/dvprokofiev/seating-generator-api/internal/models"
+	"github.com/dvprokofiev/seating-generator-api/internal/repository"
+	"github.com/google/uuid"
+	"golang.org/x/oauth2"
+)
+
+var (
+	ErrOIDCNotConfigured    = errors.New("Single sign-on is not available")
+	ErrInvalidOIDCState     = errors.New("Sign-in request expired or was already used")
+	ErrOIDCLoginFailed      = errors.New("Sign-in with the identity provider failed")
+	ErrOIDCEmailNotVerified = errors.New("Identity provider did not confirm the email address")
+	ErrOIDCEmailNotAllowed  = errors.New("Email address is not allowed to sign in to this organization")
+	ErrOIDCAccountConflict  = errors.New("Account is not a member of this organization")
+	ErrOIDCIdentityLinked   = errors.New("Identity is already linked to another account")
+	ErrOIDCAccountExists    = errors.New("An account with this email already exists, sign in with it directly")
+	ErrOIDCUnavailable      = errors.New("Identity provider is not reachable")
+)
+
+const (
+	oidcLoginTTL    = 10 * time.Minute
+	oidcHTTPTimeout = 10 * time.Second
+
+	// oidcProviderCacheSize caps the discovered providers kept in memory.
+	oidcProviderCacheSize = 1024
+)
+
+type oidcConfig struct {
+	repo        repository.OIDCRepository
+	redirectURL string
+	client      *http.Client
+
+	mu        sync.Mutex
+	providers map[uuid.UUID]cachedOIDCProvider
+}
+
+type cachedOIDCProvider struct {
+	issuer   string
+	provider *oidc.Provider
+}
+
+// WithOIDC enables sign-in through the identity providers organizations
+// configure. redirectURL is registered with every provider as the callback;
+// the page behind it posts the code and state it receives to
+// FinishOIDCLogin.
+func WithOIDC(repo repository.OIDCRepository, redirectURL string) AuthOption {
+	return func(s *authService) {
+		s.oidc = &oidcConfig{
+			repo:        repo,
+			redirectURL: redirectURL,
+			client:      &http.Client{Timeout: oidcHTTPTimeout},
+			providers:   map[uuid.UUID]cachedOIDCProvider{},
+		}
+	}
+}
+
+// provider returns the discovered provider for the organization's issuer.
+// Discovery documents are cached per organization and fetched again when
+// the issuer changes; signing keys are refreshed by the provider itself
+// when an unknown key id shows up. Once the cache is full an arbitrary
+// entry makes room for the new one.
+func (c *oidcConfig) provider(ctx context.Context, cfg *models.OIDCProvider) (*oidc.Provider, error) {
+	c.mu.Lock()
+	cached, ok := c.providers[cfg.OrganizationID]
+	c.mu.Unlock()
+	if ok && cached.issuer == cfg.Issuer {
+		return cached.provider, nil
+	}
+
+	p, err := oidc.NewProvider(oidc.ClientContext(ctx, c.client), cfg.Issuer)
+	if err != nil {
+		log.Printf("OIDC error: discovery for %s failed: %v", cfg.Issuer, err)
+		return nil, ErrOIDCUnavailable
+	}
+
+	c.mu.Lock()
+	if _, ok := c.providers[cfg.OrganizationID]; !ok && len(c.providers) >= oidcProviderCacheSize {
+		for orgID := range c.providers {
+			delete(c.providers, orgID)
+			break
+		}
+	}
+	c.providers[cfg.OrganizationID] = cachedOIDCProvider{issuer: cfg.Issuer, provider: p}
+	c.mu.Unlock()
+	return p, nil
+}
+
+// forget drops the cached provider of an organization that no longer has
+// one configured.
+func (c *oidcConfig) forget(orgID uuid.UUID) {
+	c.mu.Lock()
+	delete(c.providers, orgID)
+	c.mu.Unlock()
+}
+
+func (c *oidcConfig) oauth2Config(p *oidc.Provider, cfg *models.OIDCProvider) *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     cfg.ClientID,
+		ClientSecret: cfg.ClientSecret,
+		Endpoint:     p.Endpoint(),
+		RedirectURL:  c.redirectURL,
+		Scopes:       []string{oidc.ScopeOpenID, "email", "profile"},
+	}
+}
+
+func (s *authService) oidcProvider(ctx context.Context, orgID uuid.UUID) (*models.OIDCProvider, error) {
+	cfg, err := s.oidc.repo.GetProvider(ctx, orgID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			s.oidc.forget(orgID)
+			return nil, ErrOIDCNotConfigured
+		}
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// BeginOIDCLogin starts an authorization code flow with PKCE against the
+// organization's identity provider and returns the URL to send the browser
+// to.
+func (s *authService) BeginOIDCLogin(ctx context.Context, orgID uuid.UUID) (string, error) {
+	if s.oidc == nil {
+		return "", ErrOIDCNotConfigured
+	}
+
+	cfg, err := s.oidcProvider(ctx, orgID)
+	if err != nil {
+		return "", err
+	}
+	provider, err := s.oidc.provider(ctx, cfg)
+	if err != nil {
+		return "", err
+	}
+
+	state := &models.OIDCLoginState{
+		State:          rand.Text(),
+		OrganizationID: orgID,
+		Nonce:          rand.Text(),
+		CodeVerifier:   oauth2.GenerateVerifier(),
+		ExpiresAt:      time.Now().Add(oidcLoginTTL).UTC(),
+	}
+	if err := s.oidc.repo.SaveLoginState(ctx, state); err != nil {
+		return "", err
+	}
+
+	return s.oidc.oauth2Config(provider, cfg).AuthCodeURL(state.State,
+		oauth2.S256ChallengeOption(state.CodeVerifier),
+		oidc.Nonce(state.Nonce),
+	), nil
+}
+
+// oidcClaims are the ID token claims used to find or create the user.
+type oidcClaims struct {
+	Email         string    `json:"email"`
+	EmailVerified claimBool `json:"email_verified"`
+}
+
+// claimBool accepts both true and "true", since some providers send
+// email_verified as a string.
+type claimBool bool
+
+func (b *claimBool) UnmarshalJSON(data []byte) error {
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	switch v := v.(type) {
+	case bool:
+		*b = claimBool(v)
+	case string:
+		*b = claimBool(v == "true")
+	}
+	return nil
+}
+
+// FinishOIDCLogin redeems the authorization code, validates the ID token and
+// returns the same access token as Login. The identity provider is trusted
+// to authenticate its users, so no TOTP code is asked for on top.
+func (s *authService) FinishOIDCLogin(ctx context.Context, state, code string) (string, error) {
+	if s.oidc == nil {
+		return "", ErrOIDCNotConfigured
+	}
+
+	login, err := s.oidc.repo.TakeLoginState(ctx, state)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", ErrInvalidOIDCState
+		}
+		return "", err
+	}
+
+	cfg, err := s.oidcProvider(ctx, login.OrganizationID)
+	if err != nil {
+		return "", err
+	}
+	provider, err := s.oidc.provider(ctx, cfg)
+	if err != nil {
+		return "", err
+	}
+
+	httpCtx := oidc.ClientContext(ctx, s.oidc.client)
+	token, err := s.oidc.oauth2Config(provider, cfg).Exchange(httpCtx, code, oauth2.VerifierOption(login.CodeVerifier))
+	if err != nil {
+		log.Printf("OIDC login rejected: code exchange with %s failed: %v", cfg.Issuer, err)
+		return "", ErrOIDCLoginFailed
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		log.Printf("OIDC login rejected: %s returned no ID token", cfg.Issuer)
+		return "", ErrOIDCLoginFailed
+	}
+
+	idToken, err := provider.Verifier(&oidc.Config{ClientID: cfg.ClientID}).Verify(httpCtx, rawIDToken)
+	if err != nil {
+		log.Printf("OIDC login rejected: %v", err)
+		return "", ErrOIDCLoginFailed
+	}
+	if idToken.Nonce != login.Nonce {
+		log.Printf("OIDC login rejected: nonce mismatch for issuer %s", cfg.Issuer)
+		return "", ErrOIDCLoginFailed
+	}
+
+	var claims oidcClaims
+	if err := idToken.Claims(&claims); err != nil {
+		log.Printf("OIDC login rejected: %v", err)
+		return "", ErrOIDCLoginFailed
+	}
+
+	user, err := s.oidcUser(ctx, cfg, idToken, claims)
+	if err != nil {
+		return "", err
+	}
+
+	accessToken, err := s.issueToken(ctx, user)
+	if err != nil {
+		return "", err
+	}
+	s.recordLogin(ctx, user.Email, user, true)
+	return accessToken, nil
+}
+
+// oidcUser resolves the signed-in identity to a user. A known identity
+// maps straight to its user; otherwise a new account is created in the
+// organization for the verified email.
+//
+// Any user can create an organization and point it at an identity provider
+// they control, which may claim any email as verified. An identity is
+// therefore never linked to an existing account, not even one in the same
+// organization: an email that is already registered is refused and its
+// owner keeps signing in the way they always have.
+func (s *authService) oidcUser(ctx context.Context, cfg *models.OIDCProvider, idToken *oidc.IDToken, claims oidcClaims) (*models.User, error) {
+	identity, err := s.oidc.repo.GetIdentity(ctx, idToken.Issuer, idToken.Subject)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if identity != nil {
+		user, err := s.repo.GetByID(ctx, identity.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if user.OrganizationID == nil || *user.OrganizationID != cfg.OrganizationID {
+			s.recordLogin(ctx, user.Email, user, false)
+			return nil, ErrOIDCAccountConflict
+		}
+		return user, nil
+	}
+
+	email := strings.ToLower(claims.Email)
+	if email == "" || !claims.EmailVerified {
+		return nil, ErrOIDCEmailNotVerified
+	}
+	if cfg.EmailDomain != "" && !strings.HasSuffix(email, "@"+strings.ToLower(cfg.EmailDomain)) {
+		return nil, ErrOIDCEmailNotAllowed
+	}
+
+	existing, err := s.repo.GetByEmail(ctx, email)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if existing != nil {
+		s.recordLogin(ctx, email, existing, false)
+		return nil, ErrOIDCAccountExists
+	}
+
+	return s.createOIDCUser(ctx, email, cfg.OrganizationID, &models.ExternalIdentity{
+		Issuer:    idToken.Issuer,
+		Subject:   idToken.Subject,
+		CreatedAt: time.Now().UTC(),
+	})
+}
+
+// createOIDCUser registers a user who signs in with their identity provider
+// for the first time and links the identity to them. The password is
+// random and never shown, so the account can only be used through the
+// provider until a reset.
+func (s *authService) createOIDCUser(ctx context.Context, email string, orgID uuid.UUID, identity *models.ExternalIdentity) (*models.User, error) {
+	hashedPassword, err := s.hasher.Hash(rand.Text())
+	if err != nil {
+		return nil, fmt.Errorf("Failed to hash password: %w", err)
+	}
+
+	user := &models.User{
+		ID:             uuid.New(),
+		Email:          email,
+		PasswordHash:   hashedPassword,
+		OrganizationID: &orgID,
+		Role:           models.RoleTeacher,
+		CreatedAt:      time.Now().UTC(),
+		IsVerified:     true,
+	}
+	identity.UserID = user.ID
+	if err := s.oidc.repo.CreateUserWithIdentity(ctx, user, identity); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrDuplicateEmail):
+			// registered by a concurrent request
+			return nil, ErrOIDCAccountExists
+		case errors.Is(err, repository.ErrIdentityLinked):
+			return nil, ErrOIDCIdentityLinked
+		}
+		return nil, err
+	}
+
+	s.audit.Record(ctx, models.AuditEvent{
+		Event:          models.AuditRegister,
+		Success:        true,
+		ActorID:        &user.ID,
+		OrganizationID: &orgID,
+		Email:          user.Email,
+		Details:        map[string]string{"method": "oidc"},
+	})
+	s.audit.Record(ctx, models.AuditEvent{
+		Event:          models.AuditIdentityLink,
+		Success:        true,
+		ActorID:        &user.ID,
+		OrganizationID: &orgID,
+		TargetType:     "user",
+		TargetID:       user.ID.String(),
+		Email:          user.Email,
+		Details:        map[string]string{"issuer": identity.Issuer},
+	})
+	return user, nil
+}
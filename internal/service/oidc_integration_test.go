@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/dvprokofiev/seating-generator-api/internal/models"
+	"github.com/dvprokofiev/seating-generator-api/internal/repository"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthService_OIDC_Integration(t *testing.T) {
+	_, err := testDB.Exec("TRUNCATE users, organizations CASCADE")
+	require.NoError(t, err)
+
+	repos := repository.NewRepository(testDB)
+	policy := NewRolePolicy()
+	svc := NewAuthService(repos.Users, "test-secret", WithOIDC(repos.OIDC, testOIDCRedirect))
+	orgSvc := NewOrganizationService(repos.Organizations, repos.Users, policy, NewAuditService(repos.Audit, policy),
+		WithOIDCProviders(repos.OIDC), WithLoopbackOIDCIssuers())
+	ctx := context.Background()
+
+	require.NoError(t, svc.Register(ctx, "admin@school.test", "password123"))
+	require.NoError(t, svc.Register(ctx, "outsider@school.test", "password123"))
+	result, err := svc.Login(ctx, "admin@school.test", "password123")
+	require.NoError(t, err)
+	p, err := svc.ParseToken(ctx, result.Token)
+	require.NoError(t, err)
+	org, err := orgSvc.Create(ContextWithPrincipal(ctx, *p), "School 57")
+	require.NoError(t, err)
+
+	result, err = svc.Login(ctx, "admin@school.test", "password123")
+	require.NoError(t, err)
+	p, err = svc.ParseToken(ctx, result.Token)
+	require.NoError(t, err)
+	adminCtx := ContextWithPrincipal(ctx, *p)
+
+	idp := newFakeOIDCProvider(t, "seating-app")
+	_, err = orgSvc.SetOIDCProvider(adminCtx, org.ID, models.OIDCProvider{
+		Issuer: idp.Issuer(), ClientID: "seating-app", ClientSecret: "s3cret", EmailDomain: "school.test",
+	})
+	require.NoError(t, err)
+
+	signIn := func(t *testing.T, claims jwt.MapClaims) (*Principal, error) {
+		t.Helper()
+		authURL, err := svc.BeginOIDCLogin(ctx, org.ID)
+		require.NoError(t, err)
+		state, code := idp.Authorize(t, authURL, claims)
+		token, err := svc.FinishOIDCLogin(ctx, state, code)
+		if err != nil {
+			return nil, err
+		}
+		return svc.ParseToken(ctx, token)
+	}
+
+	t.Run("existing_account_is_not_taken_over", func(t *testing.T) {
+		_, err := signIn(t, jwt.MapClaims{"sub": "outsider-1", "email": "outsider@school.test", "email_verified": true})
+		assert.ErrorIs(t, err, ErrOIDCAccountExists)
+
+		outsider, err := repos.Users.GetByEmail(ctx, "outsider@school.test")
+		require.NoError(t, err)
+		assert.Nil(t, outsider.OrganizationID)
+		assert.False(t, outsider.IsVerified)
+		_, err = repos.OIDC.GetIdentity(ctx, idp.Issuer(), "outsider-1")
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+	})
+
+	t.Run("member_is_not_linked_by_email", func(t *testing.T) {
+		_, err := signIn(t, jwt.MapClaims{"sub": "admin-1", "email": "admin@school.test", "email_verified": true})
+		assert.ErrorIs(t, err, ErrOIDCAccountExists)
+
+		_, err = repos.OIDC.GetIdentity(ctx, idp.Issuer(), "admin-1")
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+	})
+
+	t.Run("new_user_is_created_verified", func(t *testing.T) {
+		p, err := signIn(t, jwt.MapClaims{"sub": "teacher-1", "email": "teacher@school.test", "email_verified": true})
+		require.NoError(t, err)
+		assert.True(t, p.InOrganization(org.ID))
+		assert.Equal(t, models.RoleTeacher, p.Role)
+
+		user, err := repos.Users.GetByEmail(ctx, "teacher@school.test")
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, p.UserID)
+		assert.True(t, user.IsVerified)
+	})
+
+	t.Run("identity_is_remembered", func(t *testing.T) {
+		first, err := repos.OIDC.GetIdentity(ctx, idp.Issuer(), "teacher-1")
+		require.NoError(t, err)
+
+		p, err := signIn(t, jwt.MapClaims{"sub": "teacher-1"})
+		require.NoError(t, err)
+		assert.Equal(t, first.UserID, p.UserID)
+	})
+
+	t.Run("state_cannot_be_reused", func(t *testing.T) {
+		authURL, err := svc.BeginOIDCLogin(ctx, org.ID)
+		require.NoError(t, err)
+		state, code := idp.Authorize(t, authURL, jwt.MapClaims{"sub": "teacher-1"})
+
+		_, err = svc.FinishOIDCLogin(ctx, state, code)
+		require.NoError(t, err)
+		_, err = svc.FinishOIDCLogin(ctx, state, code)
+		assert.ErrorIs(t, err, ErrInvalidOIDCState)
+	})
+
+	t.Run("removed_provider_disables_sign_in", func(t *testing.T) {
+		require.NoError(t, orgSvc.DeleteOIDCProvider(adminCtx, org.ID))
+
+		_, err := svc.BeginOIDCLogin(ctx, org.ID)
+		assert.ErrorIs(t, err, ErrOIDCNotConfigured)
+	})
+}
@@ -0,0 +1,261 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"testing"
+
+	"github.com/dvprokofiev/seating-generator-api/internal/models"
+	"github.com/dvprokofiev/seating-generator-api/internal/repository"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const testOIDCRedirect = "https://seating.test/sso/callback"
+
+func TestAuthService_OIDC_Unit(t *testing.T) {
+	ctx := context.Background()
+	orgID := uuid.New()
+	otherOrgID := uuid.New()
+	userRepo := new(repository.MockUserRepository)
+	oidcRepo := new(repository.MockOIDCRepository)
+	svc := NewAuthService(userRepo, "secret", WithOIDC(oidcRepo, testOIDCRedirect))
+
+	idp := newFakeOIDCProvider(t, "seating-app")
+	cfg := &models.OIDCProvider{OrganizationID: orgID, Issuer: idp.Issuer(), ClientID: "seating-app", ClientSecret: "s3cret", EmailDomain: "school.test"}
+	newUser := func(email, subject string) (any, any) {
+		user := mock.MatchedBy(func(u *models.User) bool {
+			return u.Email == email && u.Role == models.RoleTeacher && u.IsVerified &&
+				u.OrganizationID != nil && *u.OrganizationID == orgID
+		})
+		identity := mock.MatchedBy(func(id *models.ExternalIdentity) bool {
+			return id.Issuer == idp.Issuer() && id.Subject == subject && id.UserID != uuid.Nil
+		})
+		return user, identity
+	}
+
+	// begin starts a login for the organization and returns the state the
+	// service saved for it.
+	begin := func(t *testing.T) (string, *models.OIDCLoginState) {
+		t.Helper()
+		var saved *models.OIDCLoginState
+		oidcRepo.On("GetProvider", mock.Anything, orgID).Return(cfg, nil).Once()
+		oidcRepo.On("SaveLoginState", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			saved = args.Get(1).(*models.OIDCLoginState)
+		}).Return(nil).Once()
+
+		authURL, err := svc.BeginOIDCLogin(ctx, orgID)
+		require.NoError(t, err)
+		return authURL, saved
+	}
+
+	// signIn runs a whole login in which the provider vouches for claims.
+	signIn := func(t *testing.T, claims jwt.MapClaims) (string, error) {
+		t.Helper()
+		authURL, saved := begin(t)
+		state, code := idp.Authorize(t, authURL, claims)
+
+		oidcRepo.On("TakeLoginState", mock.Anything, state).Return(saved, nil).Once()
+		oidcRepo.On("GetProvider", mock.Anything, orgID).Return(cfg, nil).Once()
+		return svc.FinishOIDCLogin(ctx, state, code)
+	}
+
+	t.Run("new_user_joins_organization", func(t *testing.T) {
+		var created *models.User
+		oidcRepo.On("GetIdentity", mock.Anything, idp.Issuer(), "new-1").Return(nil, sql.ErrNoRows).Once()
+		userRepo.On("GetByEmail", mock.Anything, "new@school.test").Return(nil, sql.ErrNoRows).Once()
+		user, identity := newUser("new@school.test", "new-1")
+		oidcRepo.On("CreateUserWithIdentity", mock.Anything, user, identity).Run(func(args mock.Arguments) {
+			created = args.Get(1).(*models.User)
+			assert.Equal(t, created.ID, args.Get(2).(*models.ExternalIdentity).UserID)
+		}).Return(nil).Once()
+
+		token, err := signIn(t, jwt.MapClaims{"sub": "new-1", "email": "New@School.test", "email_verified": true})
+		require.NoError(t, err)
+		require.NotNil(t, created)
+
+		userRepo.On("GetByID", mock.Anything, created.ID).Return(created, nil).Once()
+		p, err := svc.ParseToken(ctx, token)
+		require.NoError(t, err)
+		assert.True(t, p.InOrganization(orgID))
+		assert.Equal(t, models.RoleTeacher, p.Role)
+		userRepo.AssertExpectations(t)
+		oidcRepo.AssertExpectations(t)
+	})
+
+	t.Run("known_identity_signs_in_after_email_change", func(t *testing.T) {
+		user := &models.User{ID: uuid.New(), Email: "new@school.test", OrganizationID: &orgID, Role: models.RoleTeacher}
+		oidcRepo.On("GetIdentity", mock.Anything, idp.Issuer(), "new-1").
+			Return(&models.ExternalIdentity{Issuer: idp.Issuer(), Subject: "new-1", UserID: user.ID}, nil).Once()
+		userRepo.On("GetByID", mock.Anything, user.ID).Return(user, nil).Once()
+
+		token, err := signIn(t, jwt.MapClaims{"sub": "new-1", "email": "renamed@school.test"})
+		require.NoError(t, err)
+		assert.NotEmpty(t, token)
+		userRepo.AssertExpectations(t)
+		oidcRepo.AssertExpectations(t)
+	})
+
+	t.Run("existing_accounts_never_linked", func(t *testing.T) {
+		for name, existing := range map[string]*models.User{
+			"member":       {ID: uuid.New(), Email: "member@school.test", OrganizationID: &orgID, Role: models.RoleSchoolAdmin},
+			"outsider":     {ID: uuid.New(), Email: "outsider@school.test", Role: models.RoleTeacher},
+			"other_member": {ID: uuid.New(), Email: "elsewhere@school.test", OrganizationID: &otherOrgID, Role: models.RoleSchoolAdmin},
+		} {
+			oidcRepo.On("GetIdentity", mock.Anything, idp.Issuer(), name).Return(nil, sql.ErrNoRows).Once()
+			userRepo.On("GetByEmail", mock.Anything, existing.Email).Return(existing, nil).Once()
+
+			_, err := signIn(t, jwt.MapClaims{"sub": name, "email": existing.Email, "email_verified": true})
+			assert.ErrorIs(t, err, ErrOIDCAccountExists, name)
+		}
+		userRepo.AssertExpectations(t)
+		oidcRepo.AssertExpectations(t)
+	})
+
+	t.Run("identity_user_moved_to_other_organization_refused", func(t *testing.T) {
+		moved := &models.User{ID: uuid.New(), Email: "moved@school.test", OrganizationID: &otherOrgID, Role: models.RoleTeacher}
+		oidcRepo.On("GetIdentity", mock.Anything, idp.Issuer(), "moved-1").
+			Return(&models.ExternalIdentity{Issuer: idp.Issuer(), Subject: "moved-1", UserID: moved.ID}, nil).Once()
+		userRepo.On("GetByID", mock.Anything, moved.ID).Return(moved, nil).Once()
+
+		_, err := signIn(t, jwt.MapClaims{"sub": "moved-1"})
+		assert.ErrorIs(t, err, ErrOIDCAccountConflict)
+		userRepo.AssertExpectations(t)
+		oidcRepo.AssertExpectations(t)
+	})
+
+	t.Run("email_registered_concurrently", func(t *testing.T) {
+		oidcRepo.On("GetIdentity", mock.Anything, idp.Issuer(), "racing-1").Return(nil, sql.ErrNoRows).Once()
+		userRepo.On("GetByEmail", mock.Anything, "racing@school.test").Return(nil, sql.ErrNoRows).Once()
+		user, identity := newUser("racing@school.test", "racing-1")
+		oidcRepo.On("CreateUserWithIdentity", mock.Anything, user, identity).Return(repository.ErrDuplicateEmail).Once()
+
+		_, err := signIn(t, jwt.MapClaims{"sub": "racing-1", "email": "racing@school.test", "email_verified": true})
+		assert.ErrorIs(t, err, ErrOIDCAccountExists)
+		userRepo.AssertExpectations(t)
+		oidcRepo.AssertExpectations(t)
+	})
+
+	t.Run("identity_linked_concurrently", func(t *testing.T) {
+		oidcRepo.On("GetIdentity", mock.Anything, idp.Issuer(), "twice-1").Return(nil, sql.ErrNoRows).Once()
+		userRepo.On("GetByEmail", mock.Anything, "twice@school.test").Return(nil, sql.ErrNoRows).Once()
+		user, identity := newUser("twice@school.test", "twice-1")
+		oidcRepo.On("CreateUserWithIdentity", mock.Anything, user, identity).Return(repository.ErrIdentityLinked).Once()
+
+		_, err := signIn(t, jwt.MapClaims{"sub": "twice-1", "email": "twice@school.test", "email_verified": true})
+		assert.ErrorIs(t, err, ErrOIDCIdentityLinked)
+		userRepo.AssertExpectations(t)
+		oidcRepo.AssertExpectations(t)
+	})
+
+	t.Run("unverified_email_refused", func(t *testing.T) {
+		oidcRepo.On("GetIdentity", mock.Anything, idp.Issuer(), "unverified-1").Return(nil, sql.ErrNoRows).Once()
+
+		_, err := signIn(t, jwt.MapClaims{"sub": "unverified-1", "email": "someone@school.test", "email_verified": false})
+		assert.ErrorIs(t, err, ErrOIDCEmailNotVerified)
+		oidcRepo.AssertExpectations(t)
+	})
+
+	t.Run("foreign_domain_refused", func(t *testing.T) {
+		oidcRepo.On("GetIdentity", mock.Anything, idp.Issuer(), "foreign-1").Return(nil, sql.ErrNoRows).Once()
+
+		_, err := signIn(t, jwt.MapClaims{"sub": "foreign-1", "email": "someone@gmail.test", "email_verified": true})
+		assert.ErrorIs(t, err, ErrOIDCEmailNotAllowed)
+		oidcRepo.AssertExpectations(t)
+	})
+
+	t.Run("nonce_mismatch_refused", func(t *testing.T) {
+		_, err := signIn(t, jwt.MapClaims{"sub": "new-1", "nonce": "replayed"})
+		assert.ErrorIs(t, err, ErrOIDCLoginFailed)
+		oidcRepo.AssertExpectations(t)
+	})
+
+	t.Run("wrong_audience_refused", func(t *testing.T) {
+		_, err := signIn(t, jwt.MapClaims{"sub": "new-1", "aud": "another-app"})
+		assert.ErrorIs(t, err, ErrOIDCLoginFailed)
+		oidcRepo.AssertExpectations(t)
+	})
+
+	t.Run("code_bound_to_its_verifier", func(t *testing.T) {
+		firstURL, _ := begin(t)
+		secondURL, second := begin(t)
+
+		_, code := idp.Authorize(t, firstURL, jwt.MapClaims{"sub": "new-1"})
+		state, _ := idp.Authorize(t, secondURL, jwt.MapClaims{"sub": "new-1"})
+
+		oidcRepo.On("TakeLoginState", mock.Anything, state).Return(second, nil).Once()
+		oidcRepo.On("GetProvider", mock.Anything, orgID).Return(cfg, nil).Once()
+		_, err := svc.FinishOIDCLogin(ctx, state, code)
+		assert.ErrorIs(t, err, ErrOIDCLoginFailed)
+		oidcRepo.AssertExpectations(t)
+	})
+
+	t.Run("used_state_refused", func(t *testing.T) {
+		oidcRepo.On("TakeLoginState", mock.Anything, "used").Return(nil, sql.ErrNoRows).Once()
+
+		_, err := svc.FinishOIDCLogin(ctx, "used", "code")
+		assert.ErrorIs(t, err, ErrInvalidOIDCState)
+		oidcRepo.AssertExpectations(t)
+	})
+
+	t.Run("organization_without_provider", func(t *testing.T) {
+		oidcRepo.On("GetProvider", mock.Anything, otherOrgID).Return(nil, sql.ErrNoRows).Once()
+
+		_, err := svc.BeginOIDCLogin(ctx, otherOrgID)
+		assert.ErrorIs(t, err, ErrOIDCNotConfigured)
+		oidcRepo.AssertExpectations(t)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		svc := NewAuthService(new(repository.MockUserRepository), "secret")
+
+		_, err := svc.BeginOIDCLogin(ctx, orgID)
+		assert.ErrorIs(t, err, ErrOIDCNotConfigured)
+	})
+}
+
+func TestValidIssuer(t *testing.T) {
+	assert.True(t, validIssuer("https://accounts.school.test", false))
+	assert.True(t, validIssuer("https://login.school.test/tenant/v2.0", false))
+	assert.False(t, validIssuer("http://127.0.0.1:5556", false))
+	assert.False(t, validIssuer("http://localhost:8080/realms/school", false))
+	assert.True(t, validIssuer("http://127.0.0.1:5556", true))
+	assert.True(t, validIssuer("http://localhost:8080/realms/school", true))
+	assert.False(t, validIssuer("http://accounts.school.test", true))
+	assert.False(t, validIssuer("https://accounts.school.test?tenant=1", false))
+	assert.False(t, validIssuer("accounts.school.test", false))
+}
+
+func TestOIDCConfig_ProviderCache(t *testing.T) {
+	ctx := context.Background()
+	orgID := uuid.New()
+	first := newFakeOIDCProvider(t, "seating-app")
+	second := newFakeOIDCProvider(t, "seating-app")
+	c := &oidcConfig{client: http.DefaultClient, providers: map[uuid.UUID]cachedOIDCProvider{}}
+
+	p, err := c.provider(ctx, &models.OIDCProvider{OrganizationID: orgID, Issuer: first.Issuer()})
+	require.NoError(t, err)
+	again, err := c.provider(ctx, &models.OIDCProvider{OrganizationID: orgID, Issuer: first.Issuer()})
+	require.NoError(t, err)
+	assert.Same(t, p, again)
+
+	changed, err := c.provider(ctx, &models.OIDCProvider{OrganizationID: orgID, Issuer: second.Issuer()})
+	require.NoError(t, err)
+	assert.Equal(t, second.Issuer()+"/token", changed.Endpoint().TokenURL)
+	assert.Len(t, c.providers, 1)
+
+	c.forget(orgID)
+	assert.Empty(t, c.providers)
+
+	for range oidcProviderCacheSize {
+		c.providers[uuid.New()] = cachedOIDCProvider{issuer: first.Issuer(), provider: p}
+	}
+	_, err = c.provider(ctx, &models.OIDCProvider{OrganizationID: orgID, Issuer: first.Issuer()})
+	require.NoError(t, err)
+	assert.Len(t, c.providers, oidcProviderCacheSize)
+	assert.Contains(t, c.providers, orgID)
+}
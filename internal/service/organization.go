@@ -0,0 +1,400 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/dvprokofiev/seating-generator-api/internal/models"
+	"github.com/dvprokofiev/seating-generator-api/internal/repository"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidOrganizationName = errors.New("Organization name must be between 1 and 255 characters")
+	ErrOrganizationNotFound    = errors.New("Organization not found")
+	ErrAlreadyInOrganization   = errors.New("User already belongs to an organization")
+	ErrUserNotFound            = errors.New("User not found")
+	ErrInvalidRole             = errors.New("Invalid role")
+	ErrCannotRemoveSelf        = errors.New("Admins cannot remove themselves from the organization")
+	ErrOIDCProviderNotFound    = errors.New("Identity provider is not configured for this organization")
+	ErrInvalidOIDCIssuer       = errors.New("Issuer must be an https URL")
+	ErrInvitationNotFound      = errors.New("Invitation not found or expired")
+)
+
+const invitationTTL = 7 * 24 * time.Hour
+
+type OrganizationService interface {
+	Create(ctx context.Context, name string) (*models.Organization, error)
+	Get(ctx context.Context, orgID uuid.UUID) (*models.Organization, error)
+	ListMembers(ctx context.Context, orgID uuid.UUID) ([]models.User, error)
+	InviteMember(ctx context.Context, orgID uuid.UUID, email string, role models.Role) error
+	ListInvitations(ctx context.Context) ([]models.Invitation, error)
+	AcceptInvitation(ctx context.Context, invitationID uuid.UUID) (*models.Organization, error)
+	RemoveMember(ctx context.Context, orgID, userID uuid.UUID) error
+	SetMFARequired(ctx context.Context, orgID uuid.UUID, required bool) (*models.Organization, error)
+	GetOIDCProvider(ctx context.Context, orgID uuid.UUID) (*models.OIDCProvider, error)
+	SetOIDCProvider(ctx context.Context, orgID uuid.UUID, provider models.OIDCProvider) (*models.OIDCProvider, error)
+	DeleteOIDCProvider(ctx context.Context, orgID uuid.UUID) error
+}
+
+type organizationService struct {
+	orgs   repository.OrganizationRepository
+	users  repository.UserRepository
+	policy Policy
+	audit  AuditService
+	oidc   repository.OIDCRepository
+
+	loopbackIssuers bool
+}
+
+type OrganizationOption func(*organizationService)
+
+// WithOIDCProviders lets school admins set up sign-in through their
+// identity provider.
+func WithOIDCProviders(repo repository.OIDCRepository) OrganizationOption {
+	return func(s *organizationService) {
+		s.oidc = repo
+	}
+}
+
+// WithLoopbackOIDCIssuers also accepts plain http issuers on the loopback
+// interface, for identity providers run locally in development and tests.
+// Never enable it in production: it lets school admins point the server at
+// services listening on its own host.
+func WithLoopbackOIDCIssuers() OrganizationOption {
+	return func(s *organizationService) {
+		s.loopbackIssuers = true
+	}
+}
+
+func NewOrganizationService(orgs repository.OrganizationRepository, users repository.UserRepository, policy Policy, audit AuditService, opts ...OrganizationOption) OrganizationService {
+	s := &organizationService{
+		orgs:   orgs,
+		users:  users,
+		policy: policy,
+		audit:  audit,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Create registers a new school with the caller as its first admin. Any
+// authenticated user who is not yet a member of a school may do this.
+func (s *organizationService) Create(ctx context.Context, name string) (*models.Organization, error) {
+	p, err := authorize(ctx, s.policy, ActionOrganizationCreate, uuid.Nil)
+	if err != nil {
+		return nil, err
+	}
+	if p.OrganizationID != nil {
+		return nil, ErrAlreadyInOrganization
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > 255 {
+		return nil, ErrInvalidOrganizationName
+	}
+
+	org := &models.Organization{
+		ID:        uuid.New(),
+		Name:      name,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	if err := s.orgs.Create(ctx, org, p.UserID); err != nil {
+		if errors.Is(err, repository.ErrMembershipConflict) {
+			return nil, ErrAlreadyInOrganization
+		}
+		return nil, err
+	}
+
+	s.audit.Record(ctx, models.AuditEvent{
+		Event:          models.AuditOrganizationCreate,
+		Success:        true,
+		OrganizationID: &org.ID,
+		TargetType:     "organization",
+		TargetID:       org.ID.String(),
+		Details:        map[string]string{"name": org.Name},
+	})
+	return org, nil
+}
+
+func (s *organizationService) Get(ctx context.Context, orgID uuid.UUID) (*models.Organization, error) {
+	if _, err := authorize(ctx, s.policy, ActionOrganizationRead, orgID); err != nil {
+		return nil, err
+	}
+
+	org, err := s.orgs.GetByID(ctx, orgID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrOrganizationNotFound
+		}
+		return nil, err
+	}
+	return org, nil
+}
+
+func (s *organizationService) ListMembers(ctx context.Context, orgID uuid.UUID) ([]models.User, error) {
+	if _, err := authorize(ctx, s.policy, ActionOrganizationRead, orgID); err != nil {
+		return nil, err
+	}
+
+	return s.orgs.ListMembers(ctx, orgID)
+}
+
+// InviteMember invites whoever owns email to join the organization. The
+// address is not looked up: inviting a registered user, a stranger or a
+// member of another school all succeed the same way, and nobody's
+// membership changes until the invitee accepts.
+func (s *organizationService) InviteMember(ctx context.Context, orgID uuid.UUID, email string, role models.Role) error {
+	p, err := authorize(ctx, s.policy, ActionOrganizationManageMembers, orgID)
+	if err != nil {
+		return err
+	}
+	if !role.Valid() {
+		return ErrInvalidRole
+	}
+
+	now := time.Now().UTC()
+	inv := &models.Invitation{
+		ID:             uuid.New(),
+		OrganizationID: orgID,
+		Email:          strings.ToLower(strings.TrimSpace(email)),
+		Role:           role,
+		InvitedBy:      &p.UserID,
+		ExpiresAt:      now.Add(invitationTTL),
+		CreatedAt:      now,
+	}
+	if err := s.orgs.SaveInvitation(ctx, inv); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, models.AuditEvent{
+		Event:      models.AuditMemberInvite,
+		Success:    true,
+		TargetType: "invitation",
+		TargetID:   inv.ID.String(),
+		Email:      inv.Email,
+		Details:    map[string]string{"role": string(role)},
+	})
+	return nil
+}
+
+// invitee returns the signed-in user invitations are matched against.
+// Only a verified email proves the caller owns the invited address.
+func (s *organizationService) invitee(ctx context.Context) (*models.User, error) {
+	p, err := authorize(ctx, s.policy, ActionOrganizationJoin, uuid.Nil)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.users.GetByID(ctx, p.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.IsVerified {
+		return nil, ErrEmailNotVerified
+	}
+	return user, nil
+}
+
+// ListInvitations returns the pending invitations sent to the caller's
+// email.
+func (s *organizationService) ListInvitations(ctx context.Context) ([]models.Invitation, error) {
+	user, err := s.invitee(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.orgs.ListInvitations(ctx, user.Email)
+}
+
+// AcceptInvitation makes the caller a member of the organization that
+// invited their email, with the role they were invited as.
+func (s *organizationService) AcceptInvitation(ctx context.Context, invitationID uuid.UUID) (*models.Organization, error) {
+	user, err := s.invitee(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if user.OrganizationID != nil {
+		return nil, ErrAlreadyInOrganization
+	}
+
+	inv, err := s.orgs.AcceptInvitation(ctx, invitationID, user.ID, user.Email)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrInvitationNotFound
+		case errors.Is(err, repository.ErrMembershipConflict):
+			return nil, ErrAlreadyInOrganization
+		}
+		return nil, err
+	}
+
+	s.audit.Record(ctx, models.AuditEvent{
+		Event:          models.AuditMemberAdd,
+		Success:        true,
+		OrganizationID: &inv.OrganizationID,
+		TargetType:     "user",
+		TargetID:       user.ID.String(),
+		Email:          user.Email,
+		Details:        map[string]string{"role": string(inv.Role), "invitation": inv.ID.String()},
+	})
+
+	org, err := s.orgs.GetByID(ctx, inv.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+	return org, nil
+}
+
+func (s *organizationService) RemoveMember(ctx context.Context, orgID, userID uuid.UUID) error {
+	p, err := authorize(ctx, s.policy, ActionOrganizationManageMembers, orgID)
+	if err != nil {
+		return err
+	}
+	if p.UserID == userID {
+		return ErrCannotRemoveSelf
+	}
+
+	if err := s.orgs.RemoveMember(ctx, orgID, userID); err != nil {
+		if errors.Is(err, repository.ErrMemberNotFound) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+
+	s.audit.Record(ctx, models.AuditEvent{
+		Event:      models.AuditMemberRemove,
+		Success:    true,
+		TargetType: "user",
+		TargetID:   userID.String(),
+	})
+	return nil
+}
+
+// SetMFARequired makes every member enroll in two-factor authentication on
+// their next login. Access tokens issued before stay valid until they expire.
+func (s *organizationService) SetMFARequired(ctx context.Context, orgID uuid.UUID, required bool) (*models.Organization, error) {
+	if _, err := authorize(ctx, s.policy, ActionOrganizationManageSecurity, orgID); err != nil {
+		return nil, err
+	}
+
+	if err := s.orgs.SetRequireMFA(ctx, orgID, required); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrOrganizationNotFound
+		}
+		return nil, err
+	}
+
+	s.audit.Record(ctx, models.AuditEvent{
+		Event:      models.AuditMFARequirement,
+		Success:    true,
+		TargetType: "organization",
+		TargetID:   orgID.String(),
+		Details:    map[string]string{"required": strconv.FormatBool(required)},
+	})
+	return s.Get(ctx, orgID)
+}
+
+func (s *organizationService) GetOIDCProvider(ctx context.Context, orgID uuid.UUID) (*models.OIDCProvider, error) {
+	if s.oidc == nil {
+		return nil, ErrOIDCNotConfigured
+	}
+	if _, err := authorize(ctx, s.policy, ActionOrganizationManageSecurity, orgID); err != nil {
+		return nil, err
+	}
+
+	provider, err := s.oidc.GetProvider(ctx, orgID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrOIDCProviderNotFound
+		}
+		return nil, err
+	}
+	return provider, nil
+}
+
+// SetOIDCProvider creates or replaces the organization's identity provider.
+// The issuer is not contacted here; a wrong issuer shows up on the first
+// sign-in.
+func (s *organizationService) SetOIDCProvider(ctx context.Context, orgID uuid.UUID, provider models.OIDCProvider) (*models.OIDCProvider, error) {
+	if s.oidc == nil {
+		return nil, ErrOIDCNotConfigured
+	}
+	if _, err := authorize(ctx, s.policy, ActionOrganizationManageSecurity, orgID); err != nil {
+		return nil, err
+	}
+
+	provider.Issuer = strings.TrimSuffix(strings.TrimSpace(provider.Issuer), "/")
+	if !validIssuer(provider.Issuer, s.loopbackIssuers) {
+		return nil, ErrInvalidOIDCIssuer
+	}
+	provider.OrganizationID = orgID
+	provider.EmailDomain = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(provider.EmailDomain), "@"))
+	provider.UpdatedAt = time.Now().UTC()
+
+	if err := s.oidc.SaveProvider(ctx, &provider); err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(ctx, models.AuditEvent{
+		Event:      models.AuditOIDCProviderSet,
+		Success:    true,
+		TargetType: "organization",
+		TargetID:   orgID.String(),
+		Details:    map[string]string{"issuer": provider.Issuer, "client_id": provider.ClientID},
+	})
+	return &provider, nil
+}
+
+func (s *organizationService) DeleteOIDCProvider(ctx context.Context, orgID uuid.UUID) error {
+	if s.oidc == nil {
+		return ErrOIDCNotConfigured
+	}
+	if _, err := authorize(ctx, s.policy, ActionOrganizationManageSecurity, orgID); err != nil {
+		return err
+	}
+
+	if err := s.oidc.DeleteProvider(ctx, orgID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrOIDCProviderNotFound
+		}
+		return err
+	}
+
+	s.audit.Record(ctx, models.AuditEvent{
+		Event:      models.AuditOIDCProviderDelete,
+		Success:    true,
+		TargetType: "organization",
+		TargetID:   orgID.String(),
+	})
+	return nil
+}
+
+// validIssuer accepts https URLs, and plain http on the loopback interface
+// when allowLoopback is set.
+func validIssuer(issuer string, allowLoopback bool) bool {
+	u, err := url.Parse(issuer)
+	if err != nil || u.Host == "" || u.RawQuery != "" || u.Fragment != "" {
+		return false
+	}
+	switch u.Scheme {
+	case "https":
+		return true
+	case "http":
+		if !allowLoopback {
+			return false
+		}
+		host := u.Hostname()
+		return host == "localhost" || net.ParseIP(host).IsLoopback()
+	}
+	return false
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dvprokofiev/seating-generator-api/internal/models"
+	"github.com/dvprokofiev/seating-generator-api/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func loginPrincipal(t *testing.T, email, password string) context.Context {
+	t.Helper()
+	ctx := context.Background()
+
+	result, err := testSvc.Login(ctx, email, password)
+	require.NoError(t, err)
+
+	p, err := testSvc.ParseToken(ctx, result.Token)
+	require.NoError(t, err)
+
+	return ContextWithPrincipal(ctx, *p)
+}
+
+func TestOrganizationService_Integration(t *testing.T) {
+	_, err := testDB.Exec("TRUNCATE users, organizations CASCADE")
+	require.NoError(t, err)
+
+	repos := repository.NewRepository(testDB)
+	policy := NewRolePolicy()
+	orgSvc := NewOrganizationService(repos.Organizations, repos.Users, policy, NewAuditService(repos.Audit, policy))
+	ctx := context.Background()
+
+	require.NoError(t, testSvc.Register(ctx, "admin@school.test", "password123"))
+	require.NoError(t, testSvc.Register(ctx, "teacher@school.test", "password123"))
+	require.NoError(t, testSvc.Register(ctx, "outsider@school.test", "password123"))
+
+	org, err := orgSvc.Create(loginPrincipal(t, "admin@school.test", "password123"), "School 57")
+	require.NoError(t, err)
+
+	t.Run("creator_logs_in_as_admin", func(t *testing.T) {
+		adminCtx := loginPrincipal(t, "admin@school.test", "password123")
+		p, _ := PrincipalFromContext(adminCtx)
+
+		assert.Equal(t, models.RoleSchoolAdmin, p.Role)
+		assert.True(t, p.InOrganization(org.ID))
+	})
+
+	t.Run("invited_teacher_joins", func(t *testing.T) {
+		adminCtx := loginPrincipal(t, "admin@school.test", "password123")
+		require.NoError(t, orgSvc.InviteMember(adminCtx, org.ID, "Teacher@school.test", models.RoleTeacher))
+		require.NoError(t, orgSvc.InviteMember(adminCtx, org.ID, "nobody@school.test", models.RoleTeacher))
+
+		teacherCtx := loginPrincipal(t, "teacher@school.test", "password123")
+		_, err := orgSvc.ListInvitations(teacherCtx)
+		assert.ErrorIs(t, err, ErrEmailNotVerified)
+
+		for _, email := range []string{"teacher@school.test", "outsider@school.test"} {
+			user, err := repos.Users.GetByEmail(ctx, email)
+			require.NoError(t, err)
+			require.NoError(t, repos.Users.UpdateVerified(ctx, user.ID, true))
+		}
+
+		invitations, err := orgSvc.ListInvitations(teacherCtx)
+		require.NoError(t, err)
+		require.Len(t, invitations, 1)
+		assert.Equal(t, "School 57", invitations[0].OrganizationName)
+
+		outsiderCtx := loginPrincipal(t, "outsider@school.test", "password123")
+		_, err = orgSvc.AcceptInvitation(outsiderCtx, invitations[0].ID)
+		assert.ErrorIs(t, err, ErrInvitationNotFound)
+
+		joined, err := orgSvc.AcceptInvitation(teacherCtx, invitations[0].ID)
+		require.NoError(t, err)
+		assert.Equal(t, org.ID, joined.ID)
+
+		members, err := orgSvc.ListMembers(adminCtx, org.ID)
+		require.NoError(t, err)
+		assert.Len(t, members, 2)
+
+		_, err = orgSvc.AcceptInvitation(teacherCtx, invitations[0].ID)
+		assert.ErrorIs(t, err, ErrAlreadyInOrganization)
+	})
+
+	t.Run("outsider_cannot_read_organization", func(t *testing.T) {
+		outsiderCtx := loginPrincipal(t, "outsider@school.test", "password123")
+
+		_, err := orgSvc.Get(outsiderCtx, org.ID)
+		assert.ErrorIs(t, err, ErrForbidden)
+	})
+
+	t.Run("teacher_cannot_remove_members", func(t *testing.T) {
+		teacherCtx := loginPrincipal(t, "teacher@school.test", "password123")
+		adminCtx := loginPrincipal(t, "admin@school.test", "password123")
+		admin, _ := PrincipalFromContext(adminCtx)
+
+		err := orgSvc.RemoveMember(teacherCtx, org.ID, admin.UserID)
+		assert.ErrorIs(t, err, ErrForbidden)
+	})
+
+	t.Run("admin_removes_teacher", func(t *testing.T) {
+		adminCtx := loginPrincipal(t, "admin@school.test", "password123")
+		teacherCtx := loginPrincipal(t, "teacher@school.test", "password123")
+		teacher, _ := PrincipalFromContext(teacherCtx)
+
+		err := orgSvc.RemoveMember(adminCtx, org.ID, teacher.UserID)
+		require.NoError(t, err)
+
+		teacherCtx = loginPrincipal(t, "teacher@school.test", "password123")
+		teacher, _ = PrincipalFromContext(teacherCtx)
+		assert.Nil(t, teacher.OrganizationID)
+	})
+}
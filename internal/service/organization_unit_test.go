@@ -0,0 +1,351 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/dvprokofiev/seating-generator-api/internal/models"
+	"github.com/dvprokofiev/seating-generator-api/internal/repository"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestOrganizationService_Unit(t *testing.T) {
+	orgID := uuid.New()
+	adminID := uuid.New()
+	teacherID := uuid.New()
+
+	adminCtx := ContextWithPrincipal(context.Background(), Principal{
+		UserID: adminID, OrganizationID: &orgID, Role: models.RoleSchoolAdmin,
+	})
+	teacherCtx := ContextWithPrincipal(context.Background(), Principal{
+		UserID: teacherID, OrganizationID: &orgID, Role: models.RoleTeacher,
+	})
+
+	t.Run("create_makes_caller_admin", func(t *testing.T) {
+		orgRepo := new(repository.MockOrganizationRepository)
+		svc := NewOrganizationService(orgRepo, new(repository.MockUserRepository), NewRolePolicy(), nopAuditService{})
+		ctx := ContextWithPrincipal(context.Background(), Principal{UserID: teacherID, Role: models.RoleTeacher})
+
+		orgRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.Organization"), teacherID).Return(nil).Once()
+
+		org, err := svc.Create(ctx, "  School 57  ")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "School 57", org.Name)
+		assert.NotEqual(t, uuid.Nil, org.ID)
+		orgRepo.AssertExpectations(t)
+	})
+
+	t.Run("create_requires_principal", func(t *testing.T) {
+		orgRepo := new(repository.MockOrganizationRepository)
+		svc := NewOrganizationService(orgRepo, new(repository.MockUserRepository), NewRolePolicy(), nopAuditService{})
+
+		_, err := svc.Create(context.Background(), "School 57")
+
+		assert.ErrorIs(t, err, ErrUnauthenticated)
+		orgRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("create_rejects_existing_member", func(t *testing.T) {
+		orgRepo := new(repository.MockOrganizationRepository)
+		svc := NewOrganizationService(orgRepo, new(repository.MockUserRepository), NewRolePolicy(), nopAuditService{})
+
+		_, err := svc.Create(teacherCtx, "Another school")
+
+		assert.ErrorIs(t, err, ErrAlreadyInOrganization)
+	})
+
+	t.Run("create_rejects_blank_name", func(t *testing.T) {
+		orgRepo := new(repository.MockOrganizationRepository)
+		svc := NewOrganizationService(orgRepo, new(repository.MockUserRepository), NewRolePolicy(), nopAuditService{})
+		ctx := ContextWithPrincipal(context.Background(), Principal{UserID: teacherID, Role: models.RoleTeacher})
+
+		_, err := svc.Create(ctx, "   ")
+
+		assert.ErrorIs(t, err, ErrInvalidOrganizationName)
+	})
+
+	t.Run("teacher_can_read_own_organization", func(t *testing.T) {
+		orgRepo := new(repository.MockOrganizationRepository)
+		svc := NewOrganizationService(orgRepo, new(repository.MockUserRepository), NewRolePolicy(), nopAuditService{})
+
+		orgRepo.On("GetByID", mock.Anything, orgID).Return(&models.Organization{ID: orgID, Name: "School 57"}, nil).Once()
+
+		org, err := svc.Get(teacherCtx, orgID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, orgID, org.ID)
+	})
+
+	t.Run("foreign_organization_forbidden", func(t *testing.T) {
+		orgRepo := new(repository.MockOrganizationRepository)
+		svc := NewOrganizationService(orgRepo, new(repository.MockUserRepository), NewRolePolicy(), nopAuditService{})
+
+		_, err := svc.ListMembers(adminCtx, uuid.New())
+
+		assert.ErrorIs(t, err, ErrForbidden)
+		orgRepo.AssertNotCalled(t, "ListMembers", mock.Anything, mock.Anything)
+	})
+
+	t.Run("organization_not_found", func(t *testing.T) {
+		orgRepo := new(repository.MockOrganizationRepository)
+		svc := NewOrganizationService(orgRepo, new(repository.MockUserRepository), NewRolePolicy(), nopAuditService{})
+
+		orgRepo.On("GetByID", mock.Anything, orgID).Return(nil, sql.ErrNoRows).Once()
+
+		_, err := svc.Get(adminCtx, orgID)
+
+		assert.ErrorIs(t, err, ErrOrganizationNotFound)
+	})
+
+	t.Run("admin_invites_without_looking_up_email", func(t *testing.T) {
+		orgRepo := new(repository.MockOrganizationRepository)
+		userRepo := new(repository.MockUserRepository)
+		svc := NewOrganizationService(orgRepo, userRepo, NewRolePolicy(), nopAuditService{})
+
+		orgRepo.On("SaveInvitation", mock.Anything, mock.MatchedBy(func(inv *models.Invitation) bool {
+			return inv.OrganizationID == orgID && inv.Email == "new.teacher@test.ru" && inv.Role == models.RoleTeacher &&
+				inv.InvitedBy != nil && *inv.InvitedBy == adminID && inv.ExpiresAt.After(time.Now())
+		})).Return(nil).Once()
+
+		err := svc.InviteMember(adminCtx, orgID, " New.Teacher@test.ru ", models.RoleTeacher)
+
+		assert.NoError(t, err)
+		orgRepo.AssertExpectations(t)
+		userRepo.AssertNotCalled(t, "GetByEmail", mock.Anything, mock.Anything)
+	})
+
+	t.Run("teacher_cannot_invite_member", func(t *testing.T) {
+		orgRepo := new(repository.MockOrganizationRepository)
+		svc := NewOrganizationService(orgRepo, new(repository.MockUserRepository), NewRolePolicy(), nopAuditService{})
+
+		err := svc.InviteMember(teacherCtx, orgID, "someone@test.ru", models.RoleTeacher)
+
+		assert.ErrorIs(t, err, ErrForbidden)
+		orgRepo.AssertNotCalled(t, "SaveInvitation", mock.Anything, mock.Anything)
+	})
+
+	inviteeID := uuid.New()
+	inviteeCtx := ContextWithPrincipal(context.Background(), Principal{UserID: inviteeID, Role: models.RoleTeacher})
+	invitationID := uuid.New()
+
+	t.Run("invitee_lists_invitations", func(t *testing.T) {
+		orgRepo := new(repository.MockOrganizationRepository)
+		userRepo := new(repository.MockUserRepository)
+		svc := NewOrganizationService(orgRepo, userRepo, NewRolePolicy(), nopAuditService{})
+
+		userRepo.On("GetByID", mock.Anything, inviteeID).
+			Return(&models.User{ID: inviteeID, Email: "new@test.ru", IsVerified: true}, nil).Once()
+		orgRepo.On("ListInvitations", mock.Anything, "new@test.ru").
+			Return([]models.Invitation{{ID: invitationID, OrganizationID: orgID}}, nil).Once()
+
+		invitations, err := svc.ListInvitations(inviteeCtx)
+
+		assert.NoError(t, err)
+		assert.Len(t, invitations, 1)
+		orgRepo.AssertExpectations(t)
+	})
+
+	t.Run("invitee_accepts_invitation", func(t *testing.T) {
+		orgRepo := new(repository.MockOrganizationRepository)
+		userRepo := new(repository.MockUserRepository)
+		svc := NewOrganizationService(orgRepo, userRepo, NewRolePolicy(), nopAuditService{})
+
+		userRepo.On("GetByID", mock.Anything, inviteeID).
+			Return(&models.User{ID: inviteeID, Email: "new@test.ru", IsVerified: true}, nil).Once()
+		orgRepo.On("AcceptInvitation", mock.Anything, invitationID, inviteeID, "new@test.ru").
+			Return(&models.Invitation{ID: invitationID, OrganizationID: orgID, Role: models.RoleSchoolAdmin}, nil).Once()
+		orgRepo.On("GetByID", mock.Anything, orgID).Return(&models.Organization{ID: orgID, Name: "School 57"}, nil).Once()
+
+		org, err := svc.AcceptInvitation(inviteeCtx, invitationID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, orgID, org.ID)
+		orgRepo.AssertExpectations(t)
+	})
+
+	t.Run("unverified_invitee_refused", func(t *testing.T) {
+		orgRepo := new(repository.MockOrganizationRepository)
+		userRepo := new(repository.MockUserRepository)
+		svc := NewOrganizationService(orgRepo, userRepo, NewRolePolicy(), nopAuditService{})
+
+		userRepo.On("GetByID", mock.Anything, inviteeID).Return(&models.User{ID: inviteeID, Email: "new@test.ru"}, nil).Once()
+
+		_, err := svc.AcceptInvitation(inviteeCtx, invitationID)
+
+		assert.ErrorIs(t, err, ErrEmailNotVerified)
+		orgRepo.AssertNotCalled(t, "AcceptInvitation", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("member_cannot_accept_invitation", func(t *testing.T) {
+		orgRepo := new(repository.MockOrganizationRepository)
+		userRepo := new(repository.MockUserRepository)
+		svc := NewOrganizationService(orgRepo, userRepo, NewRolePolicy(), nopAuditService{})
+
+		userRepo.On("GetByID", mock.Anything, teacherID).
+			Return(&models.User{ID: teacherID, Email: "teacher@test.ru", OrganizationID: &orgID, IsVerified: true}, nil).Once()
+
+		_, err := svc.AcceptInvitation(teacherCtx, invitationID)
+
+		assert.ErrorIs(t, err, ErrAlreadyInOrganization)
+		orgRepo.AssertNotCalled(t, "AcceptInvitation", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("other_email_invitation_not_found", func(t *testing.T) {
+		orgRepo := new(repository.MockOrganizationRepository)
+		userRepo := new(repository.MockUserRepository)
+		svc := NewOrganizationService(orgRepo, userRepo, NewRolePolicy(), nopAuditService{})
+
+		userRepo.On("GetByID", mock.Anything, inviteeID).
+			Return(&models.User{ID: inviteeID, Email: "new@test.ru", IsVerified: true}, nil).Once()
+		orgRepo.On("AcceptInvitation", mock.Anything, invitationID, inviteeID, "new@test.ru").Return(nil, sql.ErrNoRows).Once()
+
+		_, err := svc.AcceptInvitation(inviteeCtx, invitationID)
+
+		assert.ErrorIs(t, err, ErrInvitationNotFound)
+	})
+
+	t.Run("admin_removes_member", func(t *testing.T) {
+		orgRepo := new(repository.MockOrganizationRepository)
+		svc := NewOrganizationService(orgRepo, new(repository.MockUserRepository), NewRolePolicy(), nopAuditService{})
+
+		orgRepo.On("RemoveMember", mock.Anything, orgID, teacherID).Return(nil).Once()
+
+		err := svc.RemoveMember(adminCtx, orgID, teacherID)
+
+		assert.NoError(t, err)
+		orgRepo.AssertExpectations(t)
+	})
+
+	t.Run("admin_cannot_remove_self", func(t *testing.T) {
+		orgRepo := new(repository.MockOrganizationRepository)
+		svc := NewOrganizationService(orgRepo, new(repository.MockUserRepository), NewRolePolicy(), nopAuditService{})
+
+		err := svc.RemoveMember(adminCtx, orgID, adminID)
+
+		assert.ErrorIs(t, err, ErrCannotRemoveSelf)
+		orgRepo.AssertNotCalled(t, "RemoveMember", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("admin_requires_mfa", func(t *testing.T) {
+		orgRepo := new(repository.MockOrganizationRepository)
+		svc := NewOrganizationService(orgRepo, new(repository.MockUserRepository), NewRolePolicy(), nopAuditService{})
+
+		orgRepo.On("SetRequireMFA", mock.Anything, orgID, true).Return(nil).Once()
+		orgRepo.On("GetByID", mock.Anything, orgID).Return(&models.Organization{ID: orgID, RequireMFA: true}, nil).Once()
+
+		org, err := svc.SetMFARequired(adminCtx, orgID, true)
+
+		assert.NoError(t, err)
+		assert.True(t, org.RequireMFA)
+		orgRepo.AssertExpectations(t)
+	})
+
+	t.Run("teacher_cannot_require_mfa", func(t *testing.T) {
+		orgRepo := new(repository.MockOrganizationRepository)
+		svc := NewOrganizationService(orgRepo, new(repository.MockUserRepository), NewRolePolicy(), nopAuditService{})
+
+		_, err := svc.SetMFARequired(teacherCtx, orgID, true)
+
+		assert.ErrorIs(t, err, ErrForbidden)
+		orgRepo.AssertNotCalled(t, "SetRequireMFA", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("admin_sets_oidc_provider", func(t *testing.T) {
+		oidcRepo := new(repository.MockOIDCRepository)
+		svc := NewOrganizationService(new(repository.MockOrganizationRepository), new(repository.MockUserRepository),
+			NewRolePolicy(), nopAuditService{}, WithOIDCProviders(oidcRepo))
+
+		oidcRepo.On("SaveProvider", mock.Anything, mock.MatchedBy(func(p *models.OIDCProvider) bool {
+			return p.OrganizationID == orgID && p.Issuer == "https://idp.school.test" && p.EmailDomain == "school.test"
+		})).Return(nil).Once()
+
+		p, err := svc.SetOIDCProvider(adminCtx, orgID, models.OIDCProvider{
+			Issuer: " https://idp.school.test/ ", ClientID: "seating", EmailDomain: "@School.test",
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, orgID, p.OrganizationID)
+		oidcRepo.AssertExpectations(t)
+	})
+
+	t.Run("oidc_provider_needs_https_issuer", func(t *testing.T) {
+		oidcRepo := new(repository.MockOIDCRepository)
+		svc := NewOrganizationService(new(repository.MockOrganizationRepository), new(repository.MockUserRepository),
+			NewRolePolicy(), nopAuditService{}, WithOIDCProviders(oidcRepo))
+
+		_, err := svc.SetOIDCProvider(adminCtx, orgID, models.OIDCProvider{Issuer: "http://idp.school.test", ClientID: "seating"})
+
+		assert.ErrorIs(t, err, ErrInvalidOIDCIssuer)
+		oidcRepo.AssertNotCalled(t, "SaveProvider", mock.Anything, mock.Anything)
+	})
+
+	t.Run("teacher_cannot_read_oidc_provider", func(t *testing.T) {
+		oidcRepo := new(repository.MockOIDCRepository)
+		svc := NewOrganizationService(new(repository.MockOrganizationRepository), new(repository.MockUserRepository),
+			NewRolePolicy(), nopAuditService{}, WithOIDCProviders(oidcRepo))
+
+		_, err := svc.GetOIDCProvider(teacherCtx, orgID)
+
+		assert.ErrorIs(t, err, ErrForbidden)
+	})
+
+	t.Run("delete_missing_oidc_provider", func(t *testing.T) {
+		oidcRepo := new(repository.MockOIDCRepository)
+		svc := NewOrganizationService(new(repository.MockOrganizationRepository), new(repository.MockUserRepository),
+			NewRolePolicy(), nopAuditService{}, WithOIDCProviders(oidcRepo))
+
+		oidcRepo.On("DeleteProvider", mock.Anything, orgID).Return(sql.ErrNoRows).Once()
+
+		err := svc.DeleteOIDCProvider(adminCtx, orgID)
+
+		assert.ErrorIs(t, err, ErrOIDCProviderNotFound)
+	})
+
+	t.Run("oidc_disabled", func(t *testing.T) {
+		svc := NewOrganizationService(new(repository.MockOrganizationRepository), new(repository.MockUserRepository), NewRolePolicy(), nopAuditService{})
+
+		_, err := svc.GetOIDCProvider(adminCtx, orgID)
+
+		assert.ErrorIs(t, err, ErrOIDCNotConfigured)
+	})
+}
+
+func TestAuthService_TokenClaims(t *testing.T) {
+	mockRepo := new(repository.MockUserRepository)
+	svc := NewAuthService(mockRepo, "secret")
+	orgID := uuid.New()
+
+	user := &models.User{ID: uuid.New(), OrganizationID: &orgID, Role: models.RoleSchoolAdmin}
+	mockRepo.On("GetByID", mock.Anything, user.ID).Return(user, nil)
+	token, err := svc.(*authService).issueToken(context.Background(), user)
+	assert.NoError(t, err)
+
+	p, err := svc.ParseToken(context.Background(), token)
+	assert.NoError(t, err)
+	assert.Equal(t, user.ID, p.UserID)
+	assert.Equal(t, models.RoleSchoolAdmin, p.Role)
+	assert.Equal(t, &orgID, p.OrganizationID)
+
+	otherSvc := NewAuthService(mockRepo, "another-secret")
+	_, err = otherSvc.ParseToken(context.Background(), token)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	t.Run("membership_is_read_from_user_row", func(t *testing.T) {
+		removedRepo := new(repository.MockUserRepository)
+		removed := &models.User{ID: user.ID, Role: models.RoleTeacher}
+		removedRepo.On("GetByID", mock.Anything, user.ID).Return(removed, nil).Once()
+
+		p, err := NewAuthService(removedRepo, "secret").ParseToken(context.Background(), token)
+
+		assert.NoError(t, err)
+		assert.Nil(t, p.OrganizationID)
+		assert.Equal(t, models.RoleTeacher, p.Role)
+		assert.ErrorIs(t, NewRolePolicy().Authorize(*p, ActionOrganizationManageMembers, orgID), ErrForbidden)
+		removedRepo.AssertExpectations(t)
+	})
+}
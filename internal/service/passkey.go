@@ -0,0 +1,376 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/dvprokofiev/seating-generator-api/internal/models"
+	"github.com/dvprokofiev/seating-generator-api/internal/repository"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrPasskeysNotConfigured = errors.New("Passkey login is not available")
+	ErrInvalidCeremony       = errors.New("Passkey request expired or was already used")
+	ErrInvalidPasskey        = errors.New("Passkey could not be verified")
+	ErrPasskeyNotFound       = errors.New("Passkey not found")
+	ErrPasskeyExists         = errors.New("Passkey is already registered")
+)
+
+const (
+	ceremonyTTL        = 5 * time.Minute
+	maxPasskeyNameLen  = 64
+	defaultPasskeyName = "Passkey"
+)
+
+// PasskeyCeremony is handed to the browser to start navigator.credentials
+// create() or get(). The browser's answer is posted back with CeremonyID.
+type PasskeyCeremony struct {
+	CeremonyID uuid.UUID `json:"ceremony_id"`
+	Options    any       `json:"options"`
+}
+
+type passkeyConfig struct {
+	repo     repository.PasskeyRepository
+	webauthn *webauthn.WebAuthn
+}
+
+// WithPasskeys enables WebAuthn registration and passkey login.
+func WithPasskeys(repo repository.PasskeyRepository, wa *webauthn.WebAuthn) AuthOption {
+	return func(s *authService) {
+		s.passkeys = &passkeyConfig{repo: repo, webauthn: wa}
+	}
+}
+
+// webauthnUser adapts a user and their passkeys to the webauthn library. The
+// user handle is the user ID, so a discoverable login can find its owner.
+type webauthnUser struct {
+	user        *models.User
+	passkeys    []models.Passkey
+	credentials []webauthn.Credential
+}
+
+func (u *webauthnUser) WebAuthnID() []byte                         { return u.user.ID[:] }
+func (u *webauthnUser) WebAuthnName() string                       { return u.user.Email }
+func (u *webauthnUser) WebAuthnDisplayName() string                { return u.user.Email }
+func (u *webauthnUser) WebAuthnCredentials() []webauthn.Credential { return u.credentials }
+
+func (u *webauthnUser) passkey(credentialID []byte) *models.Passkey {
+	for i := range u.passkeys {
+		if bytes.Equal(u.passkeys[i].CredentialID, credentialID) {
+			return &u.passkeys[i]
+		}
+	}
+	return nil
+}
+
+func (s *authService) loadWebAuthnUser(ctx context.Context, user *models.User) (*webauthnUser, error) {
+	passkeys, err := s.passkeys.repo.ListByUser(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	u := &webauthnUser{user: user, passkeys: passkeys}
+	for _, pk := range passkeys {
+		var cred webauthn.Credential
+		if err := json.Unmarshal(pk.Credential, &cred); err != nil {
+			return nil, err
+		}
+		u.credentials = append(u.credentials, cred)
+	}
+	return u, nil
+}
+
+func (s *authService) startCeremony(ctx context.Context, kind models.WebAuthnCeremonyKind, userID *uuid.UUID, session *webauthn.SessionData, options any) (*PasskeyCeremony, error) {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &models.WebAuthnCeremony{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Kind:      kind,
+		Session:   data,
+		ExpiresAt: time.Now().Add(ceremonyTTL).UTC(),
+	}
+	if err := s.passkeys.repo.SaveCeremony(ctx, c); err != nil {
+		return nil, err
+	}
+	return &PasskeyCeremony{CeremonyID: c.ID, Options: options}, nil
+}
+
+func (s *authService) takeCeremony(ctx context.Context, id uuid.UUID, kind models.WebAuthnCeremonyKind) (*models.WebAuthnCeremony, webauthn.SessionData, error) {
+	var session webauthn.SessionData
+
+	c, err := s.passkeys.repo.TakeCeremony(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, session, ErrInvalidCeremony
+		}
+		return nil, session, err
+	}
+	if c.Kind != kind {
+		return nil, session, ErrInvalidCeremony
+	}
+	if err := json.Unmarshal(c.Session, &session); err != nil {
+		return nil, session, err
+	}
+	return c, session, nil
+}
+
+// BeginPasskeyRegistration starts adding a passkey to the caller's account.
+// Passkeys are created as discoverable credentials so that logging in does
+// not need an email address, and must verify the user with a PIN or
+// biometric, which lets a passkey login stand in for password and TOTP.
+func (s *authService) BeginPasskeyRegistration(ctx context.Context) (*PasskeyCeremony, error) {
+	if s.passkeys == nil {
+		return nil, ErrPasskeysNotConfigured
+	}
+	p, err := authorize(ctx, s.policy, ActionAccountManage, uuid.Nil)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.repo.GetByID(ctx, p.UserID)
+	if err != nil {
+		return nil, err
+	}
+	u, err := s.loadWebAuthnUser(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	creation, session, err := s.passkeys.webauthn.BeginRegistration(u,
+		webauthn.WithAuthenticatorSelection(protocol.AuthenticatorSelection{
+			ResidentKey:        protocol.ResidentKeyRequirementRequired,
+			RequireResidentKey: protocol.ResidentKeyRequired(),
+			UserVerification:   protocol.VerificationRequired,
+		}),
+		webauthn.WithExclusions(webauthn.Credentials(u.credentials).CredentialDescriptors()),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.startCeremony(ctx, models.CeremonyRegistration, &user.ID, session, creation)
+}
+
+// FinishPasskeyRegistration verifies the browser's attestation response and
+// stores the new credential under the given name.
+func (s *authService) FinishPasskeyRegistration(ctx context.Context, ceremonyID uuid.UUID, response []byte, name string) (*models.Passkey, error) {
+	if s.passkeys == nil {
+		return nil, ErrPasskeysNotConfigured
+	}
+	p, err := authorize(ctx, s.policy, ActionAccountManage, uuid.Nil)
+	if err != nil {
+		return nil, err
+	}
+
+	c, session, err := s.takeCeremony(ctx, ceremonyID, models.CeremonyRegistration)
+	if err != nil {
+		return nil, err
+	}
+	if c.UserID == nil || *c.UserID != p.UserID {
+		return nil, ErrInvalidCeremony
+	}
+
+	user, err := s.repo.GetByID(ctx, p.UserID)
+	if err != nil {
+		return nil, err
+	}
+	u, err := s.loadWebAuthnUser(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := protocol.ParseCredentialCreationResponseBytes(response)
+	if err != nil {
+		return nil, ErrInvalidPasskey
+	}
+	cred, err := s.passkeys.webauthn.CreateCredential(u, session, parsed)
+	if err != nil {
+		log.Printf("Passkey registration rejected: %v", err)
+		return nil, ErrInvalidPasskey
+	}
+
+	data, err := json.Marshal(cred)
+	if err != nil {
+		return nil, err
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = defaultPasskeyName
+	}
+	if utf8.RuneCountInString(name) > maxPasskeyNameLen {
+		name = string([]rune(name)[:maxPasskeyNameLen])
+	}
+
+	pk := &models.Passkey{
+		ID:           uuid.New(),
+		UserID:       user.ID,
+		CredentialID: cred.ID,
+		Name:         name,
+		Credential:   data,
+		CreatedAt:    time.Now().UTC(),
+	}
+	if err := s.passkeys.repo.Create(ctx, pk); err != nil {
+		if errors.Is(err, repository.ErrDuplicateCredential) {
+			return nil, ErrPasskeyExists
+		}
+		return nil, err
+	}
+
+	s.audit.Record(ctx, models.AuditEvent{
+		Event:          models.AuditPasskeyRegister,
+		Success:        true,
+		ActorID:        &user.ID,
+		OrganizationID: user.OrganizationID,
+		TargetType:     "passkey",
+		TargetID:       pk.ID.String(),
+		Email:          user.Email,
+	})
+	return pk, nil
+}
+
+func (s *authService) ListPasskeys(ctx context.Context) ([]models.Passkey, error) {
+	if s.passkeys == nil {
+		return nil, ErrPasskeysNotConfigured
+	}
+	p, err := authorize(ctx, s.policy, ActionAccountManage, uuid.Nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.passkeys.repo.ListByUser(ctx, p.UserID)
+}
+
+func (s *authService) DeletePasskey(ctx context.Context, id uuid.UUID) error {
+	if s.passkeys == nil {
+		return ErrPasskeysNotConfigured
+	}
+	p, err := authorize(ctx, s.policy, ActionAccountManage, uuid.Nil)
+	if err != nil {
+		return err
+	}
+
+	if err := s.passkeys.repo.Delete(ctx, p.UserID, id); err != nil {
+		if errors.Is(err, repository.ErrPasskeyNotFound) {
+			return ErrPasskeyNotFound
+		}
+		return err
+	}
+
+	s.audit.Record(ctx, models.AuditEvent{
+		Event:      models.AuditPasskeyDelete,
+		Success:    true,
+		TargetType: "passkey",
+		TargetID:   id.String(),
+	})
+	return nil
+}
+
+// BeginPasskeyLogin starts a passkey login. The authenticator always picks
+// a discoverable credential itself: the server never names the credentials
+// of an account, so the response reveals nothing about which emails are
+// registered.
+func (s *authService) BeginPasskeyLogin(ctx context.Context) (*PasskeyCeremony, error) {
+	if s.passkeys == nil {
+		return nil, ErrPasskeysNotConfigured
+	}
+
+	assertion, session, err := s.passkeys.webauthn.BeginDiscoverableLogin(
+		webauthn.WithUserVerification(protocol.VerificationRequired))
+	if err != nil {
+		return nil, err
+	}
+	return s.startCeremony(ctx, models.CeremonyLogin, nil, session, assertion)
+}
+
+// FinishPasskeyLogin verifies the browser's assertion and returns the same
+// access token as Login. The ceremony requires user verification, so the
+// passkey proves both possession of the device and the PIN or biometric
+// that unlocks it, and no TOTP code is asked for on top.
+func (s *authService) FinishPasskeyLogin(ctx context.Context, ceremonyID uuid.UUID, response []byte) (string, error) {
+	if s.passkeys == nil {
+		return "", ErrPasskeysNotConfigured
+	}
+
+	_, session, err := s.takeCeremony(ctx, ceremonyID, models.CeremonyLogin)
+	if err != nil {
+		return "", err
+	}
+	if session.UserVerification != protocol.VerificationRequired {
+		// started before user verification was required
+		return "", ErrInvalidCeremony
+	}
+
+	parsed, err := protocol.ParseCredentialRequestResponseBytes(response)
+	if err != nil {
+		return "", ErrInvalidPasskey
+	}
+
+	var u *webauthnUser
+	cred, err := s.passkeys.webauthn.ValidateDiscoverableLogin(func(rawID, userHandle []byte) (webauthn.User, error) {
+		found, err := s.findPasskeyOwner(ctx, rawID, userHandle)
+		u = found
+		return found, err
+	}, session, parsed)
+	if err != nil {
+		log.Printf("Passkey login rejected: %v", err)
+		if u != nil {
+			s.recordLogin(ctx, u.user.Email, u.user, false)
+		}
+		return "", ErrInvalidPasskey
+	}
+	if cred.Authenticator.CloneWarning {
+		// the signature counter went backwards: the key may have been copied
+		log.Printf("Passkey login rejected: possible cloned authenticator for user %s", u.user.ID)
+		s.recordLogin(ctx, u.user.Email, u.user, false)
+		return "", ErrInvalidPasskey
+	}
+
+	if pk := u.passkey(cred.ID); pk != nil {
+		if data, err := json.Marshal(cred); err == nil {
+			if err := s.passkeys.repo.UpdateAfterLogin(ctx, pk.ID, data, time.Now().UTC()); err != nil {
+				log.Printf("Passkey login error: failed to update credential: %v", err)
+			}
+		}
+	}
+
+	token, err := s.issueToken(ctx, u.user)
+	if err != nil {
+		return "", err
+	}
+	s.recordLogin(ctx, u.user.Email, u.user, true)
+	return token, nil
+}
+
+func (s *authService) findPasskeyOwner(ctx context.Context, credentialID, userHandle []byte) (*webauthnUser, error) {
+	pk, err := s.passkeys.repo.GetByCredentialID(ctx, credentialID)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(pk.UserID[:], userHandle) {
+		return nil, ErrInvalidPasskey
+	}
+	return s.webauthnUserByID(ctx, pk.UserID)
+}
+
+func (s *authService) webauthnUserByID(ctx context.Context, userID uuid.UUID) (*webauthnUser, error) {
+	user, err := s.repo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.loadWebAuthnUser(ctx, user)
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/protocol/webauthncbor"
+	"github.com/stretchr/testify/require"
+)
+
+// softAuthenticator is a minimal platform authenticator holding one ES256
+// passkey. It answers create() with "none" attestation and get() with a
+// signed assertion, like a browser would post them back.
+type softAuthenticator struct {
+	origin       string
+	rpID         string
+	key          *ecdsa.PrivateKey
+	credentialID []byte
+	userHandle   []byte
+	signCount    uint32
+	// presenceOnly makes it behave like a security key without a PIN,
+	// which checks presence but cannot verify the user.
+	presenceOnly bool
+}
+
+const (
+	flagUserPresent  = 0x01
+	flagUserVerified = 0x04
+	flagAttestedData = 0x40
+)
+
+func newSoftAuthenticator(t *testing.T, origin, rpID string) *softAuthenticator {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	id := make([]byte, 32)
+	rand.Read(id)
+	return &softAuthenticator{origin: origin, rpID: rpID, key: key, credentialID: id}
+}
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func (a *softAuthenticator) clientData(t *testing.T, typ string, challenge protocol.URLEncodedBase64) []byte {
+	t.Helper()
+	data, err := json.Marshal(map[string]any{
+		"type":      typ,
+		"challenge": challenge.String(),
+		"origin":    a.origin,
+	})
+	require.NoError(t, err)
+	return data
+}
+
+func (a *softAuthenticator) authData(flags byte, attested []byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(a.rpID))
+	data := append(rpIDHash[:], flags)
+	data = binary.BigEndian.AppendUint32(data, a.signCount)
+	return append(data, attested...)
+}
+
+func (a *softAuthenticator) flags() byte {
+	if a.presenceOnly {
+		return flagUserPresent
+	}
+	return flagUserPresent | flagUserVerified
+}
+
+// Create answers a registration ceremony.
+func (a *softAuthenticator) Create(t *testing.T, creation *protocol.CredentialCreation) []byte {
+	t.Helper()
+	userID, ok := creation.Response.User.ID.(protocol.URLEncodedBase64)
+	require.True(t, ok)
+	a.userHandle = userID
+
+	x := a.key.PublicKey.X.FillBytes(make([]byte, 32))
+	y := a.key.PublicKey.Y.FillBytes(make([]byte, 32))
+	coseKey, err := webauthncbor.Marshal(map[int]any{1: 2, 3: -7, -1: 1, -2: x, -3: y})
+	require.NoError(t, err)
+
+	attested := make([]byte, 16) // zero AAGUID
+	attested = binary.BigEndian.AppendUint16(attested, uint16(len(a.credentialID)))
+	attested = append(attested, a.credentialID...)
+	attested = append(attested, coseKey...)
+
+	attestation, err := webauthncbor.Marshal(map[string]any{
+		"fmt":      "none",
+		"attStmt":  map[string]any{},
+		"authData": a.authData(a.flags()|flagAttestedData, attested),
+	})
+	require.NoError(t, err)
+
+	body, err := json.Marshal(map[string]any{
+		"id":    b64(a.credentialID),
+		"rawId": b64(a.credentialID),
+		"type":  "public-key",
+		"response": map[string]string{
+			"clientDataJSON":    b64(a.clientData(t, "webauthn.create", creation.Response.Challenge)),
+			"attestationObject": b64(attestation),
+		},
+	})
+	require.NoError(t, err)
+	return body
+}
+
+// Get answers a login ceremony, advancing the signature counter.
+func (a *softAuthenticator) Get(t *testing.T, assertion *protocol.CredentialAssertion) []byte {
+	t.Helper()
+	a.signCount++
+
+	authData := a.authData(a.flags(), nil)
+	clientData := a.clientData(t, "webauthn.get", assertion.Response.Challenge)
+	clientDataHash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(authData, clientDataHash[:]...))
+	sig, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	require.NoError(t, err)
+
+	body, err := json.Marshal(map[string]any{
+		"id":    b64(a.credentialID),
+		"rawId": b64(a.credentialID),
+		"type":  "public-key",
+		"response": map[string]string{
+			"clientDataJSON":    b64(clientData),
+			"authenticatorData": b64(authData),
+			"signature":         b64(sig),
+			"userHandle":        b64(a.userHandle),
+		},
+	})
+	require.NoError(t, err)
+	return body
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/dvprokofiev/seating-generator-api/internal/repository"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthService_Passkeys_Integration(t *testing.T) {
+	_, err := testDB.Exec("TRUNCATE users CASCADE")
+	require.NoError(t, err)
+
+	repos := repository.NewRepository(testDB)
+	svc := NewAuthService(repos.Users, "test-secret", WithPasskeys(repos.Passkeys, newTestWebAuthn(t)))
+	ctx := context.Background()
+
+	require.NoError(t, svc.Register(ctx, "passkey@school.test", "password123"))
+	result, err := svc.Login(ctx, "passkey@school.test", "password123")
+	require.NoError(t, err)
+	p, err := svc.ParseToken(ctx, result.Token)
+	require.NoError(t, err)
+	userCtx := ContextWithPrincipal(ctx, *p)
+
+	authenticator := newSoftAuthenticator(t, testOrigin, testRPID)
+
+	ceremony, err := svc.BeginPasskeyRegistration(userCtx)
+	require.NoError(t, err)
+	pk, err := svc.FinishPasskeyRegistration(userCtx, ceremony.CeremonyID,
+		authenticator.Create(t, ceremony.Options.(*protocol.CredentialCreation)), "Classroom PC")
+	require.NoError(t, err)
+
+	t.Run("login_updates_sign_count", func(t *testing.T) {
+		ceremony, err := svc.BeginPasskeyLogin(ctx)
+		require.NoError(t, err)
+
+		token, err := svc.FinishPasskeyLogin(ctx, ceremony.CeremonyID, authenticator.Get(t, ceremony.Options.(*protocol.CredentialAssertion)))
+		require.NoError(t, err)
+		got, err := svc.ParseToken(ctx, token)
+		require.NoError(t, err)
+		assert.Equal(t, p.UserID, got.UserID)
+
+		stored, err := repos.Passkeys.GetByCredentialID(ctx, authenticator.credentialID)
+		require.NoError(t, err)
+		var cred webauthn.Credential
+		require.NoError(t, json.Unmarshal(stored.Credential, &cred))
+		assert.Equal(t, uint32(1), cred.Authenticator.SignCount)
+		assert.NotNil(t, stored.LastUsedAt)
+	})
+
+	t.Run("ceremony_cannot_be_reused", func(t *testing.T) {
+		ceremony, err := svc.BeginPasskeyLogin(ctx)
+		require.NoError(t, err)
+		response := authenticator.Get(t, ceremony.Options.(*protocol.CredentialAssertion))
+
+		_, err = svc.FinishPasskeyLogin(ctx, ceremony.CeremonyID, response)
+		require.NoError(t, err)
+		_, err = svc.FinishPasskeyLogin(ctx, ceremony.CeremonyID, response)
+		assert.ErrorIs(t, err, ErrInvalidCeremony)
+	})
+
+	t.Run("expired_ceremony", func(t *testing.T) {
+		ceremony, err := svc.BeginPasskeyLogin(ctx)
+		require.NoError(t, err)
+		_, err = testDB.Exec("UPDATE webauthn_ceremonies SET expires_at = NOW() - INTERVAL '1 second'")
+		require.NoError(t, err)
+
+		_, err = svc.FinishPasskeyLogin(ctx, ceremony.CeremonyID, authenticator.Get(t, ceremony.Options.(*protocol.CredentialAssertion)))
+		assert.ErrorIs(t, err, ErrInvalidCeremony)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		require.NoError(t, svc.DeletePasskey(userCtx, pk.ID))
+
+		passkeys, err := svc.ListPasskeys(userCtx)
+		require.NoError(t, err)
+		assert.Empty(t, passkeys)
+		assert.ErrorIs(t, svc.DeletePasskey(userCtx, pk.ID), ErrPasskeyNotFound)
+	})
+}
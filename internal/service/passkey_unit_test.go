@@ -0,0 +1,231 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/dvprokofiev/seating-generator-api/internal/models"
+	"github.com/dvprokofiev/seating-generator-api/internal/repository"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testRPID   = "seating.test"
+	testOrigin = "https://seating.test"
+)
+
+func newTestWebAuthn(t *testing.T) *webauthn.WebAuthn {
+	t.Helper()
+	wa, err := webauthn.New(&webauthn.Config{
+		RPID:          testRPID,
+		RPDisplayName: "Seating Generator",
+		RPOrigins:     []string{testOrigin},
+	})
+	require.NoError(t, err)
+	return wa
+}
+
+func TestAuthService_Passkeys_Unit(t *testing.T) {
+	ctx := context.Background()
+	userRepo := new(repository.MockUserRepository)
+	passkeyRepo := new(repository.MockPasskeyRepository)
+	svc := NewAuthService(userRepo, "secret", WithPasskeys(passkeyRepo, newTestWebAuthn(t)))
+
+	user := &models.User{ID: uuid.New(), Email: "teacher@test.ru", Role: models.RoleTeacher}
+	userCtx := ContextWithPrincipal(ctx, Principal{UserID: user.ID, Role: user.Role})
+
+	// saveCeremony captures the ceremony the next Begin call stores, so the
+	// test can hand it back to TakeCeremony.
+	saveCeremony := func() **models.WebAuthnCeremony {
+		var saved *models.WebAuthnCeremony
+		passkeyRepo.On("SaveCeremony", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			saved = args.Get(1).(*models.WebAuthnCeremony)
+		}).Return(nil).Once()
+		return &saved
+	}
+
+	// register adds a passkey held by a to the user's account and returns
+	// it as stored.
+	register := func(t *testing.T, a *softAuthenticator, existing ...models.Passkey) models.Passkey {
+		t.Helper()
+		userRepo.On("GetByID", mock.Anything, user.ID).Return(user, nil).Twice()
+		passkeyRepo.On("ListByUser", mock.Anything, user.ID).Return(existing, nil).Twice()
+		saved := saveCeremony()
+		var created models.Passkey
+		passkeyRepo.On("Create", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			created = *args.Get(1).(*models.Passkey)
+		}).Return(nil).Once()
+
+		ceremony, err := svc.BeginPasskeyRegistration(userCtx)
+		require.NoError(t, err)
+		passkeyRepo.On("TakeCeremony", mock.Anything, ceremony.CeremonyID).Return(*saved, nil).Once()
+		_, err = svc.FinishPasskeyRegistration(userCtx, ceremony.CeremonyID,
+			a.Create(t, ceremony.Options.(*protocol.CredentialCreation)), "")
+		require.NoError(t, err)
+		return created
+	}
+
+	// login runs a login ceremony answered by a with the credential pk and
+	// returns the result.
+	login := func(t *testing.T, a *softAuthenticator, pk models.Passkey) (string, error) {
+		t.Helper()
+		saved := saveCeremony()
+		passkeyRepo.On("GetByCredentialID", mock.Anything, pk.CredentialID).Return(&pk, nil).Once()
+		userRepo.On("GetByID", mock.Anything, user.ID).Return(user, nil).Once()
+		passkeyRepo.On("ListByUser", mock.Anything, user.ID).Return([]models.Passkey{pk}, nil).Once()
+
+		ceremony, err := svc.BeginPasskeyLogin(ctx)
+		require.NoError(t, err)
+		passkeyRepo.On("TakeCeremony", mock.Anything, ceremony.CeremonyID).Return(*saved, nil).Once()
+		return svc.FinishPasskeyLogin(ctx, ceremony.CeremonyID, a.Get(t, ceremony.Options.(*protocol.CredentialAssertion)))
+	}
+
+	authenticator := newSoftAuthenticator(t, testOrigin, testRPID)
+	registered := register(t, authenticator)
+	userRepo.AssertExpectations(t)
+	passkeyRepo.AssertExpectations(t)
+
+	t.Run("register", func(t *testing.T) {
+		other := newSoftAuthenticator(t, testOrigin, testRPID)
+		userRepo.On("GetByID", mock.Anything, user.ID).Return(user, nil).Twice()
+		passkeyRepo.On("ListByUser", mock.Anything, user.ID).Return([]models.Passkey{registered}, nil).Twice()
+		saved := saveCeremony()
+		passkeyRepo.On("Create", mock.Anything, mock.MatchedBy(func(pk *models.Passkey) bool {
+			return pk.UserID == user.ID && string(pk.CredentialID) == string(other.credentialID)
+		})).Return(nil).Once()
+
+		ceremony, err := svc.BeginPasskeyRegistration(userCtx)
+		require.NoError(t, err)
+		creation := ceremony.Options.(*protocol.CredentialCreation)
+		assert.Len(t, creation.Response.CredentialExcludeList, 1)
+		passkeyRepo.On("TakeCeremony", mock.Anything, ceremony.CeremonyID).Return(*saved, nil).Once()
+
+		pk, err := svc.FinishPasskeyRegistration(userCtx, ceremony.CeremonyID, other.Create(t, creation), "  Classroom PC  ")
+		require.NoError(t, err)
+		assert.Equal(t, "Classroom PC", pk.Name)
+		assert.Equal(t, other.credentialID, pk.CredentialID)
+		userRepo.AssertExpectations(t)
+		passkeyRepo.AssertExpectations(t)
+	})
+
+	t.Run("ceremony_is_single_use", func(t *testing.T) {
+		ceremonyID := uuid.New()
+		passkeyRepo.On("TakeCeremony", mock.Anything, ceremonyID).Return(nil, sql.ErrNoRows).Once()
+
+		_, err := svc.FinishPasskeyRegistration(userCtx, ceremonyID, []byte(`{}`), "")
+
+		assert.ErrorIs(t, err, ErrInvalidCeremony)
+		passkeyRepo.AssertExpectations(t)
+	})
+
+	t.Run("registration_requires_user_verification", func(t *testing.T) {
+		userRepo.On("GetByID", mock.Anything, user.ID).Return(user, nil).Twice()
+		passkeyRepo.On("ListByUser", mock.Anything, user.ID).Return([]models.Passkey{registered}, nil).Twice()
+		saved := saveCeremony()
+
+		ceremony, err := svc.BeginPasskeyRegistration(userCtx)
+		require.NoError(t, err)
+		creation := ceremony.Options.(*protocol.CredentialCreation)
+		assert.Equal(t, protocol.VerificationRequired, creation.Response.AuthenticatorSelection.UserVerification)
+		passkeyRepo.On("TakeCeremony", mock.Anything, ceremony.CeremonyID).Return(*saved, nil).Once()
+		securityKey := newSoftAuthenticator(t, testOrigin, testRPID)
+		securityKey.presenceOnly = true
+
+		_, err = svc.FinishPasskeyRegistration(userCtx, ceremony.CeremonyID, securityKey.Create(t, creation), "")
+
+		assert.ErrorIs(t, err, ErrInvalidPasskey)
+		userRepo.AssertExpectations(t)
+		passkeyRepo.AssertExpectations(t)
+	})
+
+	t.Run("discoverable_login", func(t *testing.T) {
+		saved := saveCeremony()
+		passkeyRepo.On("GetByCredentialID", mock.Anything, registered.CredentialID).Return(&registered, nil).Once()
+		userRepo.On("GetByID", mock.Anything, user.ID).Return(user, nil).Once()
+		passkeyRepo.On("ListByUser", mock.Anything, user.ID).Return([]models.Passkey{registered}, nil).Once()
+		passkeyRepo.On("UpdateAfterLogin", mock.Anything, registered.ID, mock.Anything, mock.Anything).Return(nil).Once()
+
+		ceremony, err := svc.BeginPasskeyLogin(ctx)
+		require.NoError(t, err)
+		assertion := ceremony.Options.(*protocol.CredentialAssertion)
+		assert.Empty(t, assertion.Response.AllowedCredentials)
+		assert.Equal(t, protocol.VerificationRequired, assertion.Response.UserVerification)
+		passkeyRepo.On("TakeCeremony", mock.Anything, ceremony.CeremonyID).Return(*saved, nil).Once()
+
+		token, err := svc.FinishPasskeyLogin(ctx, ceremony.CeremonyID, authenticator.Get(t, assertion))
+		require.NoError(t, err)
+
+		userRepo.On("GetByID", mock.Anything, user.ID).Return(user, nil).Once()
+		p, err := svc.ParseToken(ctx, token)
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, p.UserID)
+		userRepo.AssertExpectations(t)
+		passkeyRepo.AssertExpectations(t)
+	})
+
+	t.Run("user_presence_alone_is_rejected", func(t *testing.T) {
+		authenticator.presenceOnly = true
+		defer func() { authenticator.presenceOnly = false }()
+
+		_, err := login(t, authenticator, registered)
+
+		assert.ErrorIs(t, err, ErrInvalidPasskey)
+		userRepo.AssertExpectations(t)
+		passkeyRepo.AssertExpectations(t)
+	})
+
+	t.Run("replayed_counter_is_rejected", func(t *testing.T) {
+		copied := newSoftAuthenticator(t, testOrigin, testRPID)
+		copied.signCount = 5
+		stored := register(t, copied, registered)
+		copied.signCount = 2
+
+		_, err := login(t, copied, stored)
+
+		assert.ErrorIs(t, err, ErrInvalidPasskey)
+		userRepo.AssertExpectations(t)
+		passkeyRepo.AssertExpectations(t)
+	})
+
+	t.Run("wrong_key_is_rejected", func(t *testing.T) {
+		impostor := newSoftAuthenticator(t, testOrigin, testRPID)
+		impostor.credentialID = authenticator.credentialID
+		impostor.userHandle = authenticator.userHandle
+		impostor.signCount = 100
+
+		_, err := login(t, impostor, registered)
+
+		assert.ErrorIs(t, err, ErrInvalidPasskey)
+		userRepo.AssertExpectations(t)
+		passkeyRepo.AssertExpectations(t)
+	})
+
+	t.Run("wrong_origin_is_rejected", func(t *testing.T) {
+		authenticator.origin = "https://evil.test"
+		defer func() { authenticator.origin = testOrigin }()
+
+		_, err := login(t, authenticator, registered)
+
+		assert.ErrorIs(t, err, ErrInvalidPasskey)
+		userRepo.AssertExpectations(t)
+		passkeyRepo.AssertExpectations(t)
+	})
+
+	t.Run("registration_requires_caller", func(t *testing.T) {
+		_, err := svc.BeginPasskeyRegistration(ctx)
+
+		assert.ErrorIs(t, err, ErrUnauthenticated)
+	})
+
+	t.Run("not_configured", func(t *testing.T) {
+		_, err := NewAuthService(new(repository.MockUserRepository), "secret").BeginPasskeyLogin(ctx)
+
+		assert.ErrorIs(t, err, ErrPasskeysNotConfigured)
+	})
+}
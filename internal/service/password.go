@@ -0,0 +1,99 @@
+package service
+
+import (
+	"errors"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+var ErrUnsupportedHash = errors.New("Unsupported password hash format")
+
+// PasswordHasher hashes passwords into self-describing strings: bcrypt's
+// "$2b$<cost>$..." or the PHC format "$argon2id$v=19$m=...,t=...,p=...$salt$key".
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	// Verify returns ErrUnsupportedHash if encoded was not produced by this
+	// kind of hasher; a wrong password is (false, nil).
+	Verify(encoded, password string) (bool, error)
+	// NeedsRehash reports whether encoded uses another algorithm or other
+	// parameters than Hash currently would.
+	NeedsRehash(encoded string) bool
+}
+
+type rehashingHasher struct {
+	current  PasswordHasher
+	accepted []PasswordHasher
+}
+
+// NewRehashingHasher hashes with current and verifies hashes made by current
+// or any of accepted, so that stored hashes can be migrated to current one
+// login at a time.
+func NewRehashingHasher(current PasswordHasher, accepted ...PasswordHasher) PasswordHasher {
+	return &rehashingHasher{
+		current:  current,
+		accepted: append([]PasswordHasher{current}, accepted...),
+	}
+}
+
+func (h *rehashingHasher) Hash(password string) (string, error) {
+	return h.current.Hash(password)
+}
+
+func (h *rehashingHasher) Verify(encoded, password string) (bool, error) {
+	for _, hasher := range h.accepted {
+		ok, err := hasher.Verify(encoded, password)
+		if errors.Is(err, ErrUnsupportedHash) {
+			continue
+		}
+		return ok, err
+	}
+	return false, ErrUnsupportedHash
+}
+
+func (h *rehashingHasher) NeedsRehash(encoded string) bool {
+	return h.current.NeedsRehash(encoded)
+}
+
+type bcryptHasher struct {
+	cost int
+}
+
+func NewBcryptHasher(cost int) PasswordHasher {
+	return &bcryptHasher{cost: cost}
+}
+
+func isBcryptHash(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") || strings.HasPrefix(encoded, "$2b$") || strings.HasPrefix(encoded, "$2y$")
+}
+
+func (h *bcryptHasher) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+func (h *bcryptHasher) Verify(encoded, password string) (bool, error) {
+	if !isBcryptHash(encoded) {
+		return false, ErrUnsupportedHash
+	}
+
+	err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (h *bcryptHasher) NeedsRehash(encoded string) bool {
+	if !isBcryptHash(encoded) {
+		return true
+	}
+	cost, err := bcrypt.Cost([]byte(encoded))
+	return err != nil || cost != h.cost
+}
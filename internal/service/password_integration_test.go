@@ -0,0 +1,43 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/dvprokofiev/seating-generator-api/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestAuthService_Rehash_Integration(t *testing.T) {
+	_, err := testDB.Exec("TRUNCATE users CASCADE")
+	require.NoError(t, err)
+
+	repos := repository.NewRepository(testDB)
+	bcryptHasher := NewBcryptHasher(bcrypt.DefaultCost)
+	argon2idHasher := NewArgon2idHasher(testArgon2idParams)
+
+	oldSvc := NewAuthService(repos.Users, "test-secret", WithPasswordHasher(bcryptHasher))
+	newSvc := NewAuthService(repos.Users, "test-secret",
+		WithPasswordHasher(NewRehashingHasher(argon2idHasher, bcryptHasher)))
+
+	ctx := context.Background()
+	email := "rehash@test.com"
+	require.NoError(t, oldSvc.Register(ctx, email, "password123"))
+
+	storedHash := func() string {
+		var hash string
+		require.NoError(t, testDB.QueryRow("SELECT password_hash FROM users WHERE email = $1", email).Scan(&hash))
+		return hash
+	}
+	require.True(t, strings.HasPrefix(storedHash(), "$2a$"))
+
+	_, err = newSvc.Login(ctx, email, "password123")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(storedHash(), "$argon2id$"))
+
+	_, err = newSvc.Login(ctx, email, "password123")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"unicode/utf8"
+)
+
+var ErrWeakPassword = errors.New("Password does not meet the password policy")
+
+type PasswordViolationCode string
+
+const (
+	PasswordTooShort      PasswordViolationCode = "too_short"
+	PasswordTooLong       PasswordViolationCode = "too_long"
+	PasswordMatchesEmail  PasswordViolationCode = "matches_email"
+	PasswordKnownBreached PasswordViolationCode = "breached"
+)
+
+type PasswordViolation struct {
+	Code    PasswordViolationCode `json:"code"`
+	Message string                `json:"message"`
+}
+
+// PasswordPolicyError lists every rule a password broke so the client can
+// show them all at once. It matches ErrWeakPassword, and ErrPasswordTooShort
+// when the password is too short.
+type PasswordPolicyError struct {
+	Violations []PasswordViolation
+}
+
+func (e *PasswordPolicyError) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		messages[i] = v.Message
+	}
+	return ErrWeakPassword.Error() + ": " + strings.Join(messages, "; ")
+}
+
+func (e *PasswordPolicyError) Is(target error) bool {
+	switch target {
+	case ErrWeakPassword:
+		return true
+	case ErrPasswordTooShort:
+		return e.has(PasswordTooShort)
+	}
+	return false
+}
+
+func (e *PasswordPolicyError) has(code PasswordViolationCode) bool {
+	for _, v := range e.Violations {
+		if v.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// BreachedPasswordChecker reports whether a password appears in a list of
+// leaked passwords. *breach.Checker implements it.
+type BreachedPasswordChecker interface {
+	IsBreached(ctx context.Context, password string) (bool, error)
+}
+
+type PasswordPolicy struct {
+	// MinLength is counted in characters, not bytes.
+	MinLength int
+	// MaxBytes caps the encoded length; bcrypt ignores everything past 72
+	// bytes. Zero means no limit.
+	MaxBytes      int
+	DisallowEmail bool
+	// Breached is consulted last and skipped when nil. Lookup errors are
+	// logged and the password is accepted.
+	Breached BreachedPasswordChecker
+}
+
+func DefaultPasswordPolicy() PasswordPolicy {
+	return PasswordPolicy{
+		MinLength:     8,
+		MaxBytes:      72,
+		DisallowEmail: true,
+	}
+}
+
+// WithPasswordPolicy sets the rules new passwords are checked against.
+func WithPasswordPolicy(p PasswordPolicy) AuthOption {
+	return func(s *authService) {
+		s.passwordPolicy = p
+	}
+}
+
+// Check returns nil or a *PasswordPolicyError.
+func (p PasswordPolicy) Check(ctx context.Context, email, password string) error {
+	var violations []PasswordViolation
+
+	if utf8.RuneCountInString(password) < p.MinLength {
+		violations = append(violations, PasswordViolation{
+			Code:    PasswordTooShort,
+			Message: fmt.Sprintf("Password must be at least %d characters long", p.MinLength),
+		})
+	}
+	if p.MaxBytes > 0 && len(password) > p.MaxBytes {
+		violations = append(violations, PasswordViolation{
+			Code:    PasswordTooLong,
+			Message: fmt.Sprintf("Password must be at most %d bytes long", p.MaxBytes),
+		})
+	}
+	if p.DisallowEmail && matchesEmail(email, password) {
+		violations = append(violations, PasswordViolation{
+			Code:    PasswordMatchesEmail,
+			Message: "Password must not be the same as the email address",
+		})
+	}
+	if len(violations) > 0 {
+		return &PasswordPolicyError{Violations: violations}
+	}
+
+	if p.Breached != nil {
+		breached, err := p.Breached.IsBreached(ctx, password)
+		if err != nil {
+			log.Printf("Breached password check failed: %v", err)
+		} else if breached {
+			return &PasswordPolicyError{Violations: []PasswordViolation{{
+				Code:    PasswordKnownBreached,
+				Message: "Password appears in a list of leaked passwords, choose another one",
+			}}}
+		}
+	}
+	return nil
+}
+
+func matchesEmail(email, password string) bool {
+	email = strings.TrimSpace(email)
+	if email == "" {
+		return false
+	}
+	if strings.EqualFold(password, email) {
+		return true
+	}
+	local, _, _ := strings.Cut(email, "@")
+	return local != "" && strings.EqualFold(password, local)
+}
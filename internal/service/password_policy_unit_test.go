@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/dvprokofiev/seating-generator-api/internal/breach"
+	"github.com/dvprokofiev/seating-generator-api/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type failingBreachChecker struct{}
+
+func (failingBreachChecker) IsBreached(context.Context, string) (bool, error) {
+	return false, errors.New("list unavailable")
+}
+
+func violationCodes(t *testing.T, err error) []PasswordViolationCode {
+	t.Helper()
+	var policyErr *PasswordPolicyError
+	require.ErrorAs(t, err, &policyErr)
+
+	codes := make([]PasswordViolationCode, len(policyErr.Violations))
+	for i, v := range policyErr.Violations {
+		codes[i] = v.Code
+	}
+	return codes
+}
+
+func TestPasswordPolicy_Check(t *testing.T) {
+	ctx := context.Background()
+	policy := DefaultPasswordPolicy()
+	policy.Breached = breach.NewChecker(breach.Embedded())
+
+	t.Run("accepts_good_password", func(t *testing.T) {
+		assert.NoError(t, policy.Check(ctx, "teacher@test.ru", "seating chart 57"))
+	})
+
+	t.Run("length_is_counted_in_characters", func(t *testing.T) {
+		// 7 Cyrillic letters are 14 bytes
+		err := policy.Check(ctx, "teacher@test.ru", "пароль7")
+
+		assert.Equal(t, []PasswordViolationCode{PasswordTooShort}, violationCodes(t, err))
+		assert.ErrorIs(t, err, ErrPasswordTooShort)
+		assert.ErrorIs(t, err, ErrWeakPassword)
+	})
+
+	t.Run("rejects_past_bcrypt_limit", func(t *testing.T) {
+		err := policy.Check(ctx, "teacher@test.ru", strings.Repeat("ж", 37))
+
+		assert.Equal(t, []PasswordViolationCode{PasswordTooLong}, violationCodes(t, err))
+		assert.NotErrorIs(t, err, ErrPasswordTooShort)
+	})
+
+	t.Run("rejects_email_and_local_part", func(t *testing.T) {
+		err := policy.Check(ctx, "Long.Teacher@test.ru", "long.teacher@TEST.ru")
+		assert.Equal(t, []PasswordViolationCode{PasswordMatchesEmail}, violationCodes(t, err))
+
+		err = policy.Check(ctx, "long.teacher@test.ru", "Long.Teacher")
+		assert.Equal(t, []PasswordViolationCode{PasswordMatchesEmail}, violationCodes(t, err))
+	})
+
+	t.Run("reports_all_violations", func(t *testing.T) {
+		err := policy.Check(ctx, "abc@test.ru", "abc")
+
+		assert.Equal(t, []PasswordViolationCode{PasswordTooShort, PasswordMatchesEmail}, violationCodes(t, err))
+	})
+
+	t.Run("rejects_breached_password", func(t *testing.T) {
+		err := policy.Check(ctx, "teacher@test.ru", "password123")
+
+		assert.Equal(t, []PasswordViolationCode{PasswordKnownBreached}, violationCodes(t, err))
+	})
+
+	t.Run("breach_check_fails_open", func(t *testing.T) {
+		p := DefaultPasswordPolicy()
+		p.Breached = failingBreachChecker{}
+
+		assert.NoError(t, p.Check(ctx, "teacher@test.ru", "password123"))
+	})
+}
+
+func TestAuthService_Register_PasswordPolicy(t *testing.T) {
+	mockRepo := new(repository.MockUserRepository)
+	policy := DefaultPasswordPolicy()
+	policy.Breached = breach.NewChecker(breach.Embedded())
+	svc := NewAuthService(mockRepo, "secret", WithPasswordPolicy(policy))
+
+	err := svc.Register(context.Background(), "teacher@test.ru", "qwerty123")
+
+	assert.Equal(t, []PasswordViolationCode{PasswordKnownBreached}, violationCodes(t, err))
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
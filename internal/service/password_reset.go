@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/dvprokofiev/seating-generator-api/internal/mailer"
+	"github.com/dvprokofiev/seating-generator-api/internal/models"
+	"github.com/dvprokofiev/seating-generator-api/internal/repository"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrPasswordResetRequired       = errors.New("Password reset required, follow the link sent to your email")
+	ErrPasswordResetsNotConfigured = errors.New("Password resets are not available")
+	ErrInvalidPasswordResetLink    = errors.New("Invalid or expired password reset link")
+	ErrEmailNotVerified            = errors.New("Email address is not verified")
+	ErrPasswordUnchanged           = errors.New("New password must differ from the current one")
+)
+
+const passwordResetTTL = 24 * time.Hour
+
+type passwordResetConfig struct {
+	repo repository.PasswordResetRepository
+	url  string
+}
+
+// WithPasswordResets lets operators force a password reset. The user gets
+// an email with resetURL plus the token as the "token" query parameter;
+// the page behind it posts the token and the new password to
+// ResetPassword. Links are sent with the mailer set by WithMailer.
+func WithPasswordResets(repo repository.PasswordResetRepository, resetURL string) AuthOption {
+	return func(s *authService) {
+		s.passwordResets = &passwordResetConfig{repo: repo, url: resetURL}
+	}
+}
+
+// sendPasswordResetLink stores a new reset link for the user and mails it
+// to their address.
+func (s *authService) sendPasswordResetLink(ctx context.Context, user *models.User) error {
+	link, err := url.Parse(s.passwordResets.url)
+	if err != nil {
+		return err
+	}
+	token := rand.Text()
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+
+	now := time.Now().UTC()
+	err = s.passwordResets.repo.Save(ctx, &models.PasswordReset{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		TokenHash: hashMagicLinkToken(token),
+		ExpiresAt: now.Add(passwordResetTTL),
+		CreatedAt: now,
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Choose a new password",
+		Body: fmt.Sprintf("An administrator asked you to choose a new password. "+
+			"You cannot sign in until you do.\n\nOpen this link to set it:\n\n%s\n\n"+
+			"The link works once and expires in %d hours.\n",
+			link, int(passwordResetTTL.Hours())),
+	})
+}
+
+// ResetPassword redeems a token from a password reset link and sets the
+// user's new password. It does not sign the user in; they log in with the
+// new password afterwards. A new password that is rejected leaves the link
+// usable for another try.
+func (s *authService) ResetPassword(ctx context.Context, token, newPassword string) error {
+	if s.passwordResets == nil {
+		return ErrPasswordResetsNotConfigured
+	}
+
+	tokenHash := hashMagicLinkToken(token)
+	reset, err := s.passwordResets.repo.Get(ctx, tokenHash)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrInvalidPasswordResetLink
+		}
+		return err
+	}
+
+	user, err := s.repo.GetByID(ctx, reset.UserID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrInvalidPasswordResetLink
+		}
+		return err
+	}
+	if user.DisabledAt != nil {
+		return ErrAccountDisabled
+	}
+
+	if err := s.passwordPolicy.Check(ctx, user.Email, newPassword); err != nil {
+		return err
+	}
+	if same, _ := s.hasher.Verify(user.PasswordHash, newPassword); same {
+		return ErrPasswordUnchanged
+	}
+
+	hash, err := s.hasher.Hash(newPassword)
+	if err != nil {
+		return fmt.Errorf("Failed to hash password: %w", err)
+	}
+	if _, err := s.passwordResets.repo.Take(ctx, tokenHash); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// redeemed by a concurrent request
+			return ErrInvalidPasswordResetLink
+		}
+		return err
+	}
+	if err := s.repo.UpdatePassword(ctx, user.ID, hash); err != nil {
+		return err
+	}
+	if err := s.repo.SetPasswordResetRequired(ctx, user.ID, false); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, models.AuditEvent{
+		Event:          models.AuditPasswordReset,
+		Success:        true,
+		ActorID:        &user.ID,
+		OrganizationID: user.OrganizationID,
+		Email:          user.Email,
+	})
+	return nil
+}
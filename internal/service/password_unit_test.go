@@ -0,0 +1,187 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/dvprokofiev/seating-generator-api/internal/models"
+	"github.com/dvprokofiev/seating-generator-api/internal/repository"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var testArgon2idParams = Argon2idParams{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+func TestPasswordHashers_Unit(t *testing.T) {
+	t.Run("argon2id_phc_round_trip", func(t *testing.T) {
+		h := NewArgon2idHasher(testArgon2idParams)
+
+		encoded, err := h.Hash("password123")
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(encoded, "$argon2id$v=19$m=1024,t=1,p=1$"))
+
+		ok, err := h.Verify(encoded, "password123")
+		assert.NoError(t, err)
+		assert.True(t, ok)
+
+		ok, err = h.Verify(encoded, "password124")
+		assert.NoError(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("argon2id_salts_differ", func(t *testing.T) {
+		h := NewArgon2idHasher(testArgon2idParams)
+
+		a, _ := h.Hash("password123")
+		b, _ := h.Hash("password123")
+
+		assert.NotEqual(t, a, b)
+	})
+
+	t.Run("argon2id_verifies_other_params_but_wants_rehash", func(t *testing.T) {
+		old := NewArgon2idHasher(testArgon2idParams)
+		stronger := testArgon2idParams
+		stronger.Iterations = 2
+		current := NewArgon2idHasher(stronger)
+
+		encoded, _ := old.Hash("password123")
+
+		ok, err := current.Verify(encoded, "password123")
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		assert.True(t, current.NeedsRehash(encoded))
+		assert.False(t, old.NeedsRehash(encoded))
+	})
+
+	t.Run("argon2id_rejects_malformed_hash", func(t *testing.T) {
+		h := NewArgon2idHasher(testArgon2idParams)
+
+		_, err := h.Verify("$argon2id$v=19$m=1024$nope", "password123")
+		assert.Error(t, err)
+		assert.False(t, errors.Is(err, ErrUnsupportedHash))
+	})
+
+	t.Run("argon2id_rejects_damaged_hash", func(t *testing.T) {
+		h := NewArgon2idHasher(testArgon2idParams)
+		encoded, _ := h.Hash("password123")
+		parts := strings.Split(encoded, "$")
+
+		damaged := map[string]string{
+			"empty_key":        strings.Join(append(parts[:5:5], ""), "$"),
+			"empty_salt":       strings.Join([]string{"", parts[1], parts[2], parts[3], "", parts[5]}, "$"),
+			"zero_iterations":  strings.Replace(encoded, "t=1", "t=0", 1),
+			"zero_parallelism": strings.Replace(encoded, "p=1", "p=0", 1),
+		}
+		for name, encoded := range damaged {
+			ok, err := h.Verify(encoded, "password123")
+			assert.Error(t, err, name)
+			assert.False(t, ok, name)
+			assert.True(t, h.NeedsRehash(encoded), name)
+		}
+	})
+
+	t.Run("bcrypt_cost_change_wants_rehash", func(t *testing.T) {
+		encoded, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+		h := NewBcryptHasher(bcrypt.DefaultCost)
+
+		ok, err := h.Verify(string(encoded), "password123")
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		assert.True(t, h.NeedsRehash(string(encoded)))
+	})
+
+	t.Run("each_hasher_refuses_foreign_format", func(t *testing.T) {
+		bcryptHash, _ := NewBcryptHasher(bcrypt.MinCost).Hash("password123")
+		argonHash, _ := NewArgon2idHasher(testArgon2idParams).Hash("password123")
+
+		_, err := NewArgon2idHasher(testArgon2idParams).Verify(bcryptHash, "password123")
+		assert.ErrorIs(t, err, ErrUnsupportedHash)
+		_, err = NewBcryptHasher(bcrypt.MinCost).Verify(argonHash, "password123")
+		assert.ErrorIs(t, err, ErrUnsupportedHash)
+	})
+
+	t.Run("rehashing_hasher_accepts_legacy_format", func(t *testing.T) {
+		legacy := NewBcryptHasher(bcrypt.MinCost)
+		h := NewRehashingHasher(NewArgon2idHasher(testArgon2idParams), legacy)
+		bcryptHash, _ := legacy.Hash("password123")
+
+		ok, err := h.Verify(bcryptHash, "password123")
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		assert.True(t, h.NeedsRehash(bcryptHash))
+
+		_, err = h.Verify("plaintext", "password123")
+		assert.ErrorIs(t, err, ErrUnsupportedHash)
+	})
+}
+
+func TestAuthService_Login_Rehash(t *testing.T) {
+	argon2id := NewArgon2idHasher(testArgon2idParams)
+	hasher := NewRehashingHasher(argon2id, NewBcryptHasher(bcrypt.DefaultCost))
+	legacyHash, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+
+	t.Run("bcrypt_hash_upgraded_to_argon2id", func(t *testing.T) {
+		mockRepo := new(repository.MockUserRepository)
+		svc := NewAuthService(mockRepo, "secret", WithPasswordHasher(hasher))
+		user := &models.User{ID: uuid.New(), Email: "old@test.ru", PasswordHash: string(legacyHash)}
+
+		mockRepo.On("GetByEmail", mock.Anything, "old@test.ru").Return(user, nil).Once()
+		mockRepo.On("UpdatePassword", mock.Anything, user.ID, mock.MatchedBy(func(hash string) bool {
+			ok, _ := argon2id.Verify(hash, "password123")
+			return ok && !hasher.NeedsRehash(hash)
+		})).Return(nil).Once()
+
+		result, err := svc.Login(context.Background(), "old@test.ru", "password123")
+
+		assert.NoError(t, err)
+		assert.NotEmpty(t, result.Token)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("current_hash_left_alone", func(t *testing.T) {
+		mockRepo := new(repository.MockUserRepository)
+		svc := NewAuthService(mockRepo, "secret", WithPasswordHasher(hasher))
+		current, _ := hasher.Hash("password123")
+		user := &models.User{ID: uuid.New(), Email: "new@test.ru", PasswordHash: current}
+
+		mockRepo.On("GetByEmail", mock.Anything, "new@test.ru").Return(user, nil).Once()
+
+		_, err := svc.Login(context.Background(), "new@test.ru", "password123")
+
+		assert.NoError(t, err)
+		mockRepo.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("failed_upgrade_does_not_fail_login", func(t *testing.T) {
+		mockRepo := new(repository.MockUserRepository)
+		svc := NewAuthService(mockRepo, "secret", WithPasswordHasher(hasher))
+		user := &models.User{ID: uuid.New(), Email: "old@test.ru", PasswordHash: string(legacyHash)}
+
+		mockRepo.On("GetByEmail", mock.Anything, "old@test.ru").Return(user, nil).Once()
+		mockRepo.On("UpdatePassword", mock.Anything, user.ID, mock.Anything).
+			Return(errors.New("database connection lost")).Once()
+
+		result, err := svc.Login(context.Background(), "old@test.ru", "password123")
+
+		assert.NoError(t, err)
+		assert.NotEmpty(t, result.Token)
+	})
+
+	t.Run("wrong_password_never_rehashes", func(t *testing.T) {
+		mockRepo := new(repository.MockUserRepository)
+		svc := NewAuthService(mockRepo, "secret", WithPasswordHasher(hasher))
+		user := &models.User{ID: uuid.New(), Email: "old@test.ru", PasswordHash: string(legacyHash)}
+
+		mockRepo.On("GetByEmail", mock.Anything, "old@test.ru").Return(user, nil).Once()
+
+		_, err := svc.Login(context.Background(), "old@test.ru", "password124")
+
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+		mockRepo.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
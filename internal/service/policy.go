@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/dvprokofiev/seating-generator-api/internal/models"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrUnauthenticated = errors.New("Authentication required")
+	ErrForbidden       = errors.New("Access denied")
+)
+
+// Principal is the authenticated caller of a service method, as
+// established by the auth middleware from the access token or API key.
+// SessionID is set for access tokens of a tracked session, ImpersonatorID
+// when an operator acts as the user. APIKeyID and Scopes are set only for
+// API keys, which may do no more than their scopes allow. IsOperator is
+// never set for API keys.
+type Principal struct {
+	UserID         uuid.UUID
+	OrganizationID *uuid.UUID
+	Role           models.Role
+	IsOperator     bool
+	SessionID      *uuid.UUID
+	ImpersonatorID *uuid.UUID
+	APIKeyID       *uuid.UUID
+	Scopes         []models.APIKeyScope
+}
+
+// InOrganization reports whether the principal is a member of orgID.
+func (p Principal) InOrganization(orgID uuid.UUID) bool {
+	return p.OrganizationID != nil && *p.OrganizationID == orgID
+}
+
+// HasScope reports whether the principal may act within scope. Callers
+// with an access token have every scope.
+func (p Principal) HasScope(scope models.APIKeyScope) bool {
+	return p.APIKeyID == nil || slices.Contains(p.Scopes, scope)
+}
+
+type principalKey struct{}
+
+func ContextWithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
+
+type Action string
+
+const (
+	ActionOrganizationRead           Action = "organization:read"
+	ActionOrganizationManageMembers  Action = "organization:manage_members"
+	ActionOrganizationManageSecurity Action = "organization:manage_security"
+	ActionAuditRead                  Action = "audit:read"
+
+	// Actions on the caller's own account, not scoped to an organization.
+	ActionAccountManage      Action = "account:manage"
+	ActionOrganizationCreate Action = "organization:create"
+	ActionOrganizationJoin   Action = "organization:join"
+
+	// Operator actions across all organizations.
+	ActionUsersManage  Action = "users:manage"
+	ActionAuditReadAll Action = "audit:read_all"
+)
+
+// actionScopes names the API key scope each action needs. Actions not
+// listed here cannot be performed with an API key at all.
+var actionScopes = map[Action]models.APIKeyScope{
+	ActionOrganizationRead: models.ScopeOrganizationRead,
+}
+
+// Policy decides whether a principal may perform an action on a resource
+// owned by the given organization, or on their own account or the whole
+// system when orgID is uuid.Nil. Service methods call it before touching
+// the repository; handlers never make authorization decisions themselves.
+type Policy interface {
+	Authorize(p Principal, action Action, orgID uuid.UUID) error
+}
+
+type rolePolicy struct {
+	grants   map[models.Role]map[Action]bool
+	account  map[Action]bool
+	operator map[Action]bool
+}
+
+// NewRolePolicy returns the default policy: members may act only inside
+// their own organization, and only with the actions granted to their role.
+// Any user may manage their own account and operators may act on every
+// account, but neither with an API key or while impersonated, so that a
+// leaked key cannot be turned into a login and an impersonating operator
+// cannot change the user's credentials.
+func NewRolePolicy() Policy {
+	return &rolePolicy{
+		account: map[Action]bool{
+			ActionAccountManage:      true,
+			ActionOrganizationCreate: true,
+			ActionOrganizationJoin:   true,
+		},
+		operator: map[Action]bool{
+			ActionUsersManage:  true,
+			ActionAuditReadAll: true,
+		},
+		grants: map[models.Role]map[Action]bool{
+			models.RoleTeacher: {
+				ActionOrganizationRead: true,
+			},
+			models.RoleSchoolAdmin: {
+				ActionOrganizationRead:           true,
+				ActionOrganizationManageMembers:  true,
+				ActionOrganizationManageSecurity: true,
+				ActionAuditRead:                  true,
+			},
+		},
+	}
+}
+
+func (rp *rolePolicy) Authorize(p Principal, action Action, orgID uuid.UUID) error {
+	if rp.account[action] || rp.operator[action] {
+		if orgID != uuid.Nil || p.APIKeyID != nil || p.ImpersonatorID != nil {
+			return ErrForbidden
+		}
+		if rp.operator[action] && !p.IsOperator {
+			return ErrForbidden
+		}
+		return nil
+	}
+	if !p.InOrganization(orgID) {
+		return ErrForbidden
+	}
+	if !rp.grants[p.Role][action] {
+		return ErrForbidden
+	}
+	if p.APIKeyID != nil {
+		scope, ok := actionScopes[action]
+		if !ok || !p.HasScope(scope) {
+			return ErrForbidden
+		}
+	}
+	return nil
+}
+
+// authorize resolves the caller from ctx and checks it against the policy.
+func authorize(ctx context.Context, policy Policy, action Action, orgID uuid.UUID) (Principal, error) {
+	p, ok := PrincipalFromContext(ctx)
+	if !ok {
+		return Principal{}, ErrUnauthenticated
+	}
+	if err := policy.Authorize(p, action, orgID); err != nil {
+		return Principal{}, err
+	}
+	return p, nil
+}
@@ -11,18 +11,17 @@ import (
 	"github.com/dvprokofiev/seating-generator-api/internal/models"
 	"github.com/dvprokofiev/seating-generator-api/internal/repository"
 	"github.com/google/uuid"
-	"golang.org/x/crypto/bcrypt"
 )
 
 func (s *authService) Register(ctx context.Context, email, password string) error {
 	if _, err := mail.ParseAddress(email); err != nil {
 		return ErrInvalidEmail
 	}
-	if len(password) < 8 {
-		return ErrPasswordTooShort
+	if err := s.passwordPolicy.Check(ctx, email, password); err != nil {
+		return err
 	}
 
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	hashedPassword, err := s.hasher.Hash(password)
 	if err != nil {
 		return fmt.Errorf("Failed to hash password: %w", err)
 	}
@@ -30,16 +29,25 @@ func (s *authService) Register(ctx context.Context, email, password string) erro
 	user := &models.User{
 		ID:           uuid.New(),
 		Email:        strings.ToLower(email),
-		PasswordHash: string(hashedPassword),
+		PasswordHash: hashedPassword,
+		Role:         models.RoleTeacher,
 		CreatedAt:    time.Now().UTC(),
 	}
 
 	err = s.repo.Create(ctx, user)
 	if err != nil {
 		if errors.Is(err, repository.ErrDuplicateEmail) {
+			s.audit.Record(ctx, models.AuditEvent{Event: models.AuditRegister, Email: user.Email})
 			return ErrUserAlreadyExists
 		}
 		return err
 	}
+
+	s.audit.Record(ctx, models.AuditEvent{
+		Event:   models.AuditRegister,
+		Success: true,
+		ActorID: &user.ID,
+		Email:   user.Email,
+	})
 	return nil
 }
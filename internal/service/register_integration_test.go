@@ -92,9 +92,9 @@ func TestAuthService_Register_Integration(t *testing.T) {
 		err := testSvc.Register(ctx, email, password)
 		require.NoError(t, err)
 
-		token, err := testSvc.Login(ctx, email, password)
+		result, err := testSvc.Login(ctx, email, password)
 
 		assert.NoError(t, err)
-		assert.NotEmpty(t, token)
+		assert.NotEmpty(t, result.Token)
 	})
 }
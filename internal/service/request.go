@@ -0,0 +1,21 @@
+package service
+
+import "context"
+
+// RequestMeta describes the HTTP client behind a service call. The handler
+// layer attaches it to the context so the service layer can record it.
+type RequestMeta struct {
+	IPAddress string
+	UserAgent string
+}
+
+type requestMetaKey struct{}
+
+func ContextWithRequestMeta(ctx context.Context, m RequestMeta) context.Context {
+	return context.WithValue(ctx, requestMetaKey{}, m)
+}
+
+func RequestMetaFromContext(ctx context.Context) RequestMeta {
+	m, _ := ctx.Value(requestMetaKey{}).(RequestMeta)
+	return m
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/dvprokofiev/seating-generator-api/internal/models"
+	"github.com/dvprokofiev/seating-generator-api/internal/repository"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrSessionsNotConfigured = errors.New("Session management is not available")
+	ErrSessionNotFound       = errors.New("Session not found")
+)
+
+// sessionTouchInterval limits how often last_seen_at is written for a
+// session that makes many requests.
+const sessionTouchInterval = time.Minute
+
+// ActiveSession is a session as shown to its owner. Current marks the
+// session the request was made with.
+type ActiveSession struct {
+	models.Session
+	Current bool `json:"current"`
+}
+
+// WithSessions records a session for every access token, so that users can
+// see where they are signed in and sign a device out. Access tokens without
+// a session, issued before sessions were enabled, stay valid until they
+// expire.
+func WithSessions(repo repository.SessionRepository) AuthOption {
+	return func(s *authService) {
+		s.sessions = repo
+	}
+}
+
+func (s *authService) startSession(ctx context.Context, user *models.User, now time.Time, ttl time.Duration) (*models.Session, error) {
+	meta := RequestMetaFromContext(ctx)
+	now = now.UTC()
+	session := &models.Session{
+		ID:         uuid.New(),
+		UserID:     user.ID,
+		UserAgent:  meta.UserAgent,
+		IPAddress:  meta.IPAddress,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(ttl),
+	}
+	if err := s.sessions.Create(ctx, session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// checkSession rejects tokens whose session was revoked or has expired.
+func (s *authService) checkSession(ctx context.Context, id, userID uuid.UUID) error {
+	if s.sessions == nil {
+		return nil
+	}
+
+	session, err := s.sessions.Get(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrInvalidToken
+		}
+		return err
+	}
+	if session.UserID != userID {
+		return ErrInvalidToken
+	}
+
+	now := time.Now()
+	if now.Sub(session.LastSeenAt) > sessionTouchInterval {
+		if err := s.sessions.UpdateLastSeen(ctx, id, now.UTC()); err != nil {
+			log.Printf("Session error: failed to update last use of %s: %v", id, err)
+		}
+	}
+	return nil
+}
+
+func (s *authService) ListSessions(ctx context.Context) ([]ActiveSession, error) {
+	if s.sessions == nil {
+		return nil, ErrSessionsNotConfigured
+	}
+	p, err := authorize(ctx, s.policy, ActionAccountManage, uuid.Nil)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions, err := s.sessions.ListByUser(ctx, p.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	active := make([]ActiveSession, 0, len(sessions))
+	for _, session := range sessions {
+		active = append(active, ActiveSession{
+			Session: session,
+			Current: p.SessionID != nil && *p.SessionID == session.ID,
+		})
+	}
+	return active, nil
+}
+
+// RevokeSession signs one of the caller's sessions out. Tokens of that
+// session are rejected from the next request on.
+func (s *authService) RevokeSession(ctx context.Context, id uuid.UUID) error {
+	if s.sessions == nil {
+		return ErrSessionsNotConfigured
+	}
+	p, err := authorize(ctx, s.policy, ActionAccountManage, uuid.Nil)
+	if err != nil {
+		return err
+	}
+
+	if err := s.sessions.Delete(ctx, p.UserID, id); err != nil {
+		if errors.Is(err, repository.ErrSessionNotFound) {
+			return ErrSessionNotFound
+		}
+		return err
+	}
+
+	s.audit.Record(ctx, models.AuditEvent{
+		Event:      models.AuditSessionRevoke,
+		Success:    true,
+		TargetType: "session",
+		TargetID:   id.String(),
+	})
+	return nil
+}
@@ -0,0 +1,48 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dvprokofiev/seating-generator-api/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthService_Sessions_Integration(t *testing.T) {
+	_, err := testDB.Exec("TRUNCATE users CASCADE")
+	require.NoError(t, err)
+
+	repos := repository.NewRepository(testDB)
+	svc := NewAuthService(repos.Users, "test-secret", WithSessions(repos.Sessions))
+	ctx := ContextWithRequestMeta(context.Background(), RequestMeta{IPAddress: "198.51.100.7", UserAgent: "integration-test"})
+
+	require.NoError(t, svc.Register(ctx, "roaming@school.test", "password123"))
+	classroomLogin, err := svc.Login(ctx, "roaming@school.test", "password123")
+	require.NoError(t, err)
+	homeLogin, err := svc.Login(ctx, "roaming@school.test", "password123")
+	require.NoError(t, err)
+
+	home, err := svc.ParseToken(ctx, homeLogin.Token)
+	require.NoError(t, err)
+	homeCtx := ContextWithPrincipal(ctx, *home)
+
+	sessions, err := svc.ListSessions(homeCtx)
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+	assert.Equal(t, "198.51.100.7", sessions[0].IPAddress)
+	assert.Equal(t, "integration-test", sessions[0].UserAgent)
+
+	t.Run("revoked_session_is_signed_out", func(t *testing.T) {
+		classroom, err := svc.ParseToken(ctx, classroomLogin.Token)
+		require.NoError(t, err)
+
+		require.NoError(t, svc.RevokeSession(homeCtx, *classroom.SessionID))
+
+		_, err = svc.ParseToken(ctx, classroomLogin.Token)
+		assert.ErrorIs(t, err, ErrInvalidToken)
+		_, err = svc.ParseToken(ctx, homeLogin.Token)
+		assert.NoError(t, err)
+		assert.ErrorIs(t, svc.RevokeSession(homeCtx, *classroom.SessionID), ErrSessionNotFound)
+	})
+}
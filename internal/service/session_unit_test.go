@@ -0,0 +1,188 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/dvprokofiev/seating-generator-api/internal/models"
+	"github.com/dvprokofiev/seating-generator-api/internal/repository"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// policyFunc adapts a function to Policy.
+type policyFunc func(p Principal, action Action, orgID uuid.UUID) error
+
+func (f policyFunc) Authorize(p Principal, action Action, orgID uuid.UUID) error {
+	return f(p, action, orgID)
+}
+
+func TestAuthService_Sessions_Unit(t *testing.T) {
+	ctx := ContextWithRequestMeta(context.Background(), RequestMeta{IPAddress: "203.0.113.9", UserAgent: "Classroom PC"})
+	hash, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.DefaultCost)
+	user := &models.User{ID: uuid.New(), Email: "teacher@school.test", PasswordHash: string(hash), Role: models.RoleTeacher}
+
+	userRepo := new(repository.MockUserRepository)
+	sessionRepo := new(repository.MockSessionRepository)
+	svc := NewAuthService(userRepo, "secret", WithSessions(sessionRepo))
+
+	// login signs user in and returns the token with the session stored
+	// for it.
+	login := func(t *testing.T) (string, models.Session) {
+		t.Helper()
+		var session models.Session
+		userRepo.On("GetByEmail", mock.Anything, user.Email).Return(user, nil).Once()
+		sessionRepo.On("Create", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			session = *args.Get(1).(*models.Session)
+		}).Return(nil).Once()
+
+		result, err := svc.Login(ctx, user.Email, "password123")
+		require.NoError(t, err)
+		return result.Token, session
+	}
+
+	classroomToken, classroom := login(t)
+	_, home := login(t)
+	homeCtx := ContextWithPrincipal(ctx, Principal{UserID: user.ID, Role: user.Role, SessionID: &home.ID})
+	userRepo.AssertExpectations(t)
+	sessionRepo.AssertExpectations(t)
+
+	t.Run("login_records_session", func(t *testing.T) {
+		assert.Equal(t, user.ID, classroom.UserID)
+		assert.Equal(t, "Classroom PC", classroom.UserAgent)
+		assert.Equal(t, "203.0.113.9", classroom.IPAddress)
+		assert.WithinDuration(t, classroom.CreatedAt.Add(accessTokenTTL), classroom.ExpiresAt, time.Second)
+		assert.NotEqual(t, classroom.ID, home.ID)
+	})
+
+	t.Run("token_names_its_session", func(t *testing.T) {
+		sessionRepo.On("Get", mock.Anything, classroom.ID).Return(&classroom, nil).Once()
+		userRepo.On("GetByID", mock.Anything, user.ID).Return(user, nil).Once()
+
+		p, err := svc.ParseToken(ctx, classroomToken)
+		require.NoError(t, err)
+		assert.Equal(t, classroom.ID, *p.SessionID)
+		sessionRepo.AssertExpectations(t)
+		userRepo.AssertExpectations(t)
+	})
+
+	t.Run("list_marks_current_session", func(t *testing.T) {
+		sessionRepo.On("ListByUser", mock.Anything, user.ID).Return([]models.Session{classroom, home}, nil).Once()
+
+		list, err := svc.ListSessions(homeCtx)
+		require.NoError(t, err)
+		require.Len(t, list, 2)
+
+		assert.False(t, list[0].Current)
+		assert.True(t, list[1].Current)
+		assert.Equal(t, home.ID, list[1].ID)
+		sessionRepo.AssertExpectations(t)
+	})
+
+	t.Run("last_seen_is_throttled", func(t *testing.T) {
+		stale := classroom
+		stale.LastSeenAt = time.Now().Add(-time.Hour).UTC()
+		sessionRepo.On("Get", mock.Anything, classroom.ID).Return(&stale, nil).Once()
+		sessionRepo.On("UpdateLastSeen", mock.Anything, classroom.ID, mock.AnythingOfType("time.Time")).Return(nil).Once()
+		userRepo.On("GetByID", mock.Anything, user.ID).Return(user, nil).Once()
+
+		_, err := svc.ParseToken(ctx, classroomToken)
+		require.NoError(t, err)
+
+		fresh := classroom
+		fresh.LastSeenAt = time.Now().UTC()
+		sessionRepo.On("Get", mock.Anything, classroom.ID).Return(&fresh, nil).Once()
+		userRepo.On("GetByID", mock.Anything, user.ID).Return(user, nil).Once()
+
+		_, err = svc.ParseToken(ctx, classroomToken)
+		require.NoError(t, err)
+		sessionRepo.AssertExpectations(t)
+		userRepo.AssertExpectations(t)
+		sessionRepo.AssertNumberOfCalls(t, "UpdateLastSeen", 1)
+	})
+
+	t.Run("session_of_another_user_rejected", func(t *testing.T) {
+		now := time.Now()
+		forged, err := svc.(*authService).signToken(Claims{
+			SessionID: &classroom.ID,
+			RegisteredClaims: jwt.RegisteredClaims{
+				Subject:   uuid.NewString(),
+				ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+			},
+		})
+		require.NoError(t, err)
+		sessionRepo.On("Get", mock.Anything, classroom.ID).Return(&classroom, nil).Once()
+
+		_, err = svc.ParseToken(ctx, forged)
+		assert.ErrorIs(t, err, ErrInvalidToken)
+		sessionRepo.AssertExpectations(t)
+	})
+
+	t.Run("token_without_session_accepted", func(t *testing.T) {
+		legacy := NewAuthService(userRepo, "secret")
+		userRepo.On("GetByEmail", mock.Anything, user.Email).Return(user, nil).Once()
+		result, err := legacy.Login(ctx, user.Email, "password123")
+		require.NoError(t, err)
+
+		userRepo.On("GetByID", mock.Anything, user.ID).Return(user, nil).Once()
+		p, err := svc.ParseToken(ctx, result.Token)
+		require.NoError(t, err)
+		assert.Nil(t, p.SessionID)
+		userRepo.AssertExpectations(t)
+	})
+
+	t.Run("api_key_cannot_manage_sessions", func(t *testing.T) {
+		keyID := uuid.New()
+		keyCtx := ContextWithPrincipal(ctx, Principal{UserID: user.ID, APIKeyID: &keyID})
+
+		_, err := svc.ListSessions(keyCtx)
+		assert.ErrorIs(t, err, ErrForbidden)
+		assert.ErrorIs(t, svc.RevokeSession(keyCtx, classroom.ID), ErrForbidden)
+	})
+
+	t.Run("cannot_revoke_foreign_session", func(t *testing.T) {
+		otherID := uuid.New()
+		otherCtx := ContextWithPrincipal(ctx, Principal{UserID: otherID})
+		sessionRepo.On("Delete", mock.Anything, otherID, classroom.ID).Return(repository.ErrSessionNotFound).Once()
+
+		assert.ErrorIs(t, svc.RevokeSession(otherCtx, classroom.ID), ErrSessionNotFound)
+		sessionRepo.AssertExpectations(t)
+	})
+
+	t.Run("revoked_session_token_rejected", func(t *testing.T) {
+		sessionRepo.On("Delete", mock.Anything, user.ID, classroom.ID).Return(nil).Once()
+		require.NoError(t, svc.RevokeSession(homeCtx, classroom.ID))
+
+		sessionRepo.On("Get", mock.Anything, classroom.ID).Return(nil, sql.ErrNoRows).Once()
+		_, err := svc.ParseToken(ctx, classroomToken)
+		assert.ErrorIs(t, err, ErrInvalidToken)
+		sessionRepo.AssertExpectations(t)
+	})
+
+	t.Run("policy_decides_account_actions", func(t *testing.T) {
+		var asked []Action
+		strict := NewAuthService(userRepo, "secret", WithSessions(sessionRepo),
+			WithPolicy(policyFunc(func(p Principal, action Action, orgID uuid.UUID) error {
+				asked = append(asked, action)
+				return ErrForbidden
+			})))
+
+		_, err := strict.ListSessions(homeCtx)
+		assert.ErrorIs(t, err, ErrForbidden)
+		assert.ErrorIs(t, strict.RevokeSession(homeCtx, classroom.ID), ErrForbidden)
+		assert.Equal(t, []Action{ActionAccountManage, ActionAccountManage}, asked)
+	})
+
+	t.Run("not_configured", func(t *testing.T) {
+		plain := NewAuthService(userRepo, "secret")
+
+		_, err := plain.ListSessions(homeCtx)
+		assert.ErrorIs(t, err, ErrSessionsNotConfigured)
+	})
+}
@@ -0,0 +1,248 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/dvprokofiev/seating-generator-api/internal/models"
+	"github.com/dvprokofiev/seating-generator-api/internal/signing"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	accessTokenTTL        = 24 * time.Hour
+	impersonationTokenTTL = time.Hour
+	challengeTokenTTL     = 5 * time.Minute
+)
+
+// Purposes of challenge tokens. Access tokens have no purpose, so a
+// challenge token is never accepted by ParseToken.
+const (
+	purposeMFA       = "mfa"
+	purposeMFAEnroll = "mfa_enroll"
+)
+
+var ErrInvalidToken = errors.New("Invalid or expired token")
+
+// TokenKeys supplies the asymmetric keys tokens are signed with. Tokens
+// name their key in the kid header, so several keys can be valid at once.
+type TokenKeys interface {
+	SigningKey() (*signing.Key, error)
+	Key(id string) (*signing.Key, bool)
+	JWKS() signing.JWKS
+}
+
+// WithTokenKeys signs tokens with the current key of keys instead of the
+// HS256 secret. Tokens signed with the secret are still accepted while the
+// secret is set, so enabling keys does not log anybody out.
+func WithTokenKeys(keys TokenKeys) AuthOption {
+	return func(s *authService) {
+		s.keys = keys
+	}
+}
+
+// Claims are carried by every access token minted by Login. Role and
+// OrganizationID describe the user's membership at login time for the
+// client's benefit only; ParseToken reads both, and the operator flag, from
+// the user row, so a removed member or demoted admin loses access on the
+// next request. SessionID is set when sessions are tracked, ImpersonatorID
+// when an operator acts as the user, and Purpose only on challenge tokens.
+type Claims struct {
+	Role           models.Role `json:"role,omitempty"`
+	OrganizationID *uuid.UUID  `json:"org,omitempty"`
+	SessionID      *uuid.UUID  `json:"sid,omitempty"`
+	ImpersonatorID *uuid.UUID  `json:"act,omitempty"`
+	Purpose        string      `json:"purpose,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// checkCanSignIn refuses users who may not hold tokens. It runs wherever a
+// token is minted or accepted, so every login method respects a disabled
+// account or a forced password reset, and tokens issued before either
+// stop working.
+func checkCanSignIn(user *models.User) error {
+	if user.DisabledAt != nil {
+		return ErrAccountDisabled
+	}
+	if user.PasswordResetRequired {
+		return ErrPasswordResetRequired
+	}
+	return nil
+}
+
+func (s *authService) issueToken(ctx context.Context, user *models.User) (string, error) {
+	return s.issueAccessToken(ctx, user, nil)
+}
+
+// issueAccessToken mints an access token for user. Tokens an operator gets
+// by impersonation name the operator and expire sooner.
+func (s *authService) issueAccessToken(ctx context.Context, user *models.User, impersonatorID *uuid.UUID) (string, error) {
+	if err := checkCanSignIn(user); err != nil {
+		return "", err
+	}
+
+	ttl := accessTokenTTL
+	if impersonatorID != nil {
+		ttl = impersonationTokenTTL
+	}
+
+	now := time.Now()
+	claims := Claims{
+		Role:           user.Role,
+		OrganizationID: user.OrganizationID,
+		ImpersonatorID: impersonatorID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   user.ID.String(),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}
+
+	if s.sessions != nil {
+		session, err := s.startSession(ctx, user, now, ttl)
+		if err != nil {
+			return "", err
+		}
+		claims.SessionID = &session.ID
+	}
+
+	return s.signToken(claims)
+}
+
+// issueChallengeToken mints a short-lived token that proves the user passed
+// the password step of a login and may only be used for the given purpose.
+func (s *authService) issueChallengeToken(user *models.User, purpose string) (string, error) {
+	if err := checkCanSignIn(user); err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	claims := Claims{
+		Purpose: purpose,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   user.ID.String(),
+			ExpiresAt: jwt.NewNumericDate(now.Add(challengeTokenTTL)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}
+
+	return s.signToken(claims)
+}
+
+func (s *authService) signToken(claims Claims) (string, error) {
+	if s.keys == nil {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.jwtSecret)
+	}
+
+	key, err := s.keys.SigningKey()
+	if err != nil {
+		return "", err
+	}
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.Private)
+}
+
+// verificationKey picks the key a token claims to be signed with. The
+// algorithm must be the one of that key, so a public key can never be
+// used as an HMAC secret.
+func (s *authService) verificationKey(t *jwt.Token) (any, error) {
+	if kid, ok := t.Header["kid"].(string); ok && s.keys != nil {
+		key, ok := s.keys.Key(kid)
+		if !ok || t.Method.Alg() != key.Method.Alg() {
+			return nil, ErrInvalidToken
+		}
+		return key.Public, nil
+	}
+
+	if t.Method.Alg() != jwt.SigningMethodHS256.Alg() || len(s.jwtSecret) == 0 {
+		return nil, ErrInvalidToken
+	}
+	return s.jwtSecret, nil
+}
+
+// PublicKeys returns the verification keys as a JSON Web Key Set. It is
+// empty when tokens are signed with the HS256 secret.
+func (s *authService) PublicKeys() signing.JWKS {
+	if s.keys == nil {
+		return signing.JWKS{Keys: []signing.JWK{}}
+	}
+	return s.keys.JWKS()
+}
+
+func (s *authService) parseClaims(tokenString string) (*Claims, uuid.UUID, error) {
+	var claims Claims
+	_, err := jwt.ParseWithClaims(tokenString, &claims, s.verificationKey,
+		jwt.WithValidMethods([]string{
+			jwt.SigningMethodHS256.Alg(), jwt.SigningMethodRS256.Alg(), jwt.SigningMethodEdDSA.Alg(),
+		}),
+		jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, uuid.Nil, ErrInvalidToken
+	}
+
+	userID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return nil, uuid.Nil, ErrInvalidToken
+	}
+	return &claims, userID, nil
+}
+
+// parseChallengeToken returns the user a challenge token was issued to.
+func (s *authService) parseChallengeToken(tokenString, purpose string) (uuid.UUID, error) {
+	claims, userID, err := s.parseClaims(tokenString)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if claims.Purpose != purpose {
+		return uuid.Nil, ErrInvalidToken
+	}
+	return userID, nil
+}
+
+func (s *authService) ParseToken(ctx context.Context, tokenString string) (*Principal, error) {
+	claims, userID, err := s.parseClaims(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Purpose != "" {
+		return nil, ErrInvalidToken
+	}
+	if claims.SessionID != nil {
+		if err := s.checkSession(ctx, *claims.SessionID, userID); err != nil {
+			return nil, err
+		}
+	}
+	user, err := s.checkAccount(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Principal{
+		UserID:         userID,
+		OrganizationID: user.OrganizationID,
+		Role:           user.Role,
+		IsOperator:     user.IsOperator,
+		SessionID:      claims.SessionID,
+		ImpersonatorID: claims.ImpersonatorID,
+	}, nil
+}
+
+// checkAccount loads the user a token was issued to and rejects tokens of
+// users who were deleted, disabled or told to reset their password since.
+func (s *authService) checkAccount(ctx context.Context, userID uuid.UUID) (*models.User, error) {
+	user, err := s.repo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrInvalidToken
+		}
+		return nil, err
+	}
+	if err := checkCanSignIn(user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
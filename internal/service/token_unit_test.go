@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"testing"
+	"time"
+
+	"github.com/dvprokofiev/seating-generator-api/internal/models"
+	"github.com/dvprokofiev/seating-generator-api/internal/repository"
+	"github.com/dvprokofiev/seating-generator-api/internal/signing"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newEd25519Key(t *testing.T, id string, notBefore time.Time) *signing.Key {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	return &signing.Key{ID: id, Method: jwt.SigningMethodEdDSA, Private: priv, Public: pub, NotBefore: notBefore}
+}
+
+func TestAuthService_TokenKeys(t *testing.T) {
+	ctx := context.Background()
+	user := &models.User{ID: uuid.New(), Role: models.RoleTeacher}
+	oldKey := newEd25519Key(t, "2026-09", time.Now().Add(-48*time.Hour))
+	newKey := newEd25519Key(t, "2026-10", time.Now().Add(-time.Hour))
+
+	oldSet, err := signing.NewKeySet(oldKey)
+	require.NoError(t, err)
+	rotatedSet, err := signing.NewKeySet(oldKey, newKey)
+	require.NoError(t, err)
+
+	userRepo := new(repository.MockUserRepository)
+	before := NewAuthService(userRepo, "secret", WithTokenKeys(oldSet))
+	after := NewAuthService(userRepo, "secret", WithTokenKeys(rotatedSet))
+
+	t.Run("token_names_its_key", func(t *testing.T) {
+		token, err := after.(*authService).issueToken(context.Background(), user)
+		require.NoError(t, err)
+
+		parsed, _, err := jwt.NewParser().ParseUnverified(token, &Claims{})
+		require.NoError(t, err)
+		assert.Equal(t, "2026-10", parsed.Header["kid"])
+		assert.Equal(t, "EdDSA", parsed.Method.Alg())
+
+		userRepo.On("GetByID", mock.Anything, user.ID).Return(user, nil).Once()
+		p, err := after.ParseToken(ctx, token)
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, p.UserID)
+		userRepo.AssertExpectations(t)
+	})
+
+	t.Run("tokens_of_previous_key_survive_rotation", func(t *testing.T) {
+		token, err := before.(*authService).issueToken(context.Background(), user)
+		require.NoError(t, err)
+
+		userRepo.On("GetByID", mock.Anything, user.ID).Return(user, nil).Once()
+		_, err = after.ParseToken(ctx, token)
+		assert.NoError(t, err)
+		userRepo.AssertExpectations(t)
+	})
+
+	t.Run("unknown_key_rejected", func(t *testing.T) {
+		token, err := after.(*authService).issueToken(context.Background(), user)
+		require.NoError(t, err)
+
+		_, err = before.ParseToken(ctx, token)
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("legacy_hs256_accepted_while_secret_set", func(t *testing.T) {
+		legacy := NewAuthService(new(repository.MockUserRepository), "secret")
+		token, err := legacy.(*authService).issueToken(context.Background(), user)
+		require.NoError(t, err)
+
+		userRepo.On("GetByID", mock.Anything, user.ID).Return(user, nil).Once()
+		_, err = after.ParseToken(ctx, token)
+		assert.NoError(t, err)
+		userRepo.AssertExpectations(t)
+
+		withoutSecret := NewAuthService(new(repository.MockUserRepository), "", WithTokenKeys(rotatedSet))
+		_, err = withoutSecret.ParseToken(ctx, token)
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("public_key_as_hmac_secret_rejected", func(t *testing.T) {
+		der, err := x509.MarshalPKIXPublicKey(newKey.Public)
+		require.NoError(t, err)
+		forged := jwt.NewWithClaims(jwt.SigningMethodHS256, Claims{
+			RegisteredClaims: jwt.RegisteredClaims{
+				Subject:   user.ID.String(),
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+			},
+		})
+		forged.Header["kid"] = newKey.ID
+		token, err := forged.SignedString(der)
+		require.NoError(t, err)
+
+		_, err = after.ParseToken(ctx, token)
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("jwks_lists_all_keys", func(t *testing.T) {
+		assert.Len(t, after.PublicKeys().Keys, 2)
+		assert.Empty(t, NewAuthService(new(repository.MockUserRepository), "secret").PublicKeys().Keys)
+	})
+}
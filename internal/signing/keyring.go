@@ -0,0 +1,64 @@
+package signing
+
+import (
+	"context"
+	"log"
+	"sync/atomic"
+	"time"
+)
+
+// Keyring serves the keys of a directory and picks up changes to it. To
+// rotate, add the new private key with a Not-Before header some time ahead,
+// then, once it signs, replace the old private key with its public key and
+// delete that after the last token signed with it has expired.
+type Keyring struct {
+	dir     string
+	current atomic.Pointer[KeySet]
+}
+
+func NewKeyring(dir string) (*Keyring, error) {
+	k := &Keyring{dir: dir}
+	if err := k.Reload(); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// Reload reads the directory again. On error the previous keys stay in use.
+func (k *Keyring) Reload() error {
+	ks, err := LoadDir(k.dir)
+	if err != nil {
+		return err
+	}
+	k.current.Store(ks)
+	return nil
+}
+
+// Watch reloads the directory every interval until ctx is done.
+func (k *Keyring) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := k.Reload(); err != nil {
+				log.Printf("Signing keys: reload of %s failed, keeping previous keys: %v", k.dir, err)
+			}
+		}
+	}
+}
+
+func (k *Keyring) SigningKey() (*Key, error) {
+	return k.current.Load().SigningKey()
+}
+
+func (k *Keyring) Key(id string) (*Key, bool) {
+	return k.current.Load().Key(id)
+}
+
+func (k *Keyring) JWKS() JWKS {
+	return k.current.Load().JWKS()
+}
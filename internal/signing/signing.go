@@ -0,0 +1,198 @@
+package signing
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// NotBeforeHeader is the optional PEM header that schedules when a private
+// key starts signing. Until then the key is only published for verification,
+// so other services can pick it up before the first token signed with it.
+const NotBeforeHeader = "Not-Before"
+
+const minRSABits = 2048
+
+var ErrNoSigningKey = errors.New("No active signing key")
+
+// Key is a token signing key. Private is nil for keys that are kept only to
+// verify tokens, such as a retired signing key whose tokens have not expired
+// yet.
+type Key struct {
+	ID        string
+	Method    jwt.SigningMethod
+	Private   crypto.Signer
+	Public    crypto.PublicKey
+	NotBefore time.Time
+}
+
+// KeySet is an immutable set of keys with unique ids.
+type KeySet struct {
+	keys map[string]*Key
+	now  func() time.Time
+}
+
+func NewKeySet(keys ...*Key) (*KeySet, error) {
+	ks := &KeySet{keys: make(map[string]*Key, len(keys)), now: time.Now}
+	for _, k := range keys {
+		if _, dup := ks.keys[k.ID]; dup {
+			return nil, fmt.Errorf("Duplicate key id %q", k.ID)
+		}
+		ks.keys[k.ID] = k
+	}
+	return ks, nil
+}
+
+// SigningKey returns the private key that became active last. Keys whose
+// NotBefore is still in the future are skipped.
+func (ks *KeySet) SigningKey() (*Key, error) {
+	now := ks.now()
+	var current *Key
+	for _, k := range ks.keys {
+		if k.Private == nil || k.NotBefore.After(now) {
+			continue
+		}
+		if current == nil || k.NotBefore.After(current.NotBefore) ||
+			(k.NotBefore.Equal(current.NotBefore) && k.ID > current.ID) {
+			current = k
+		}
+	}
+	if current == nil {
+		return nil, ErrNoSigningKey
+	}
+	return current, nil
+}
+
+// Key returns the key with the given id, for verifying a token's signature.
+func (ks *KeySet) Key(id string) (*Key, bool) {
+	k, ok := ks.keys[id]
+	return k, ok
+}
+
+// JWK is the public part of a key as published in a JSON Web Key Set
+// (RFC 7517, RFC 8037 for Ed25519).
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS lists the public parts of all keys, including ones not active yet,
+// sorted by id.
+func (ks *KeySet) JWKS() JWKS {
+	set := JWKS{Keys: []JWK{}}
+	for _, k := range ks.keys {
+		jwk := JWK{KeyID: k.ID, Use: "sig", Algorithm: k.Method.Alg()}
+		switch pub := k.Public.(type) {
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	slices.SortFunc(set.Keys, func(a, b JWK) int { return strings.Compare(a.KeyID, b.KeyID) })
+	return set
+}
+
+// LoadDir reads every *.pem file in dir as one key, named by the file name
+// without the extension. A file holds either a private key (PKCS#8, or
+// PKCS#1 for RSA) or a public key (PKIX).
+func LoadDir(dir string) (*KeySet, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]*Key, 0, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		key, err := ParseKey(strings.TrimSuffix(filepath.Base(path), ".pem"), data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		keys = append(keys, key)
+	}
+	return NewKeySet(keys...)
+}
+
+// ParseKey decodes a PEM encoded RSA or Ed25519 key. RSA keys sign with
+// RS256 and must have at least 2048 bits; Ed25519 keys sign with EdDSA.
+func ParseKey(id string, data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("No PEM block found")
+	}
+
+	key := &Key{ID: id}
+	if v, ok := block.Headers[NotBeforeHeader]; ok {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid %s header: %w", NotBeforeHeader, err)
+		}
+		key.NotBefore = t
+	}
+
+	var parsed any
+	var err error
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("Unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if signer, ok := parsed.(crypto.Signer); ok {
+		key.Private = signer
+		parsed = signer.Public()
+	}
+	switch pub := parsed.(type) {
+	case *rsa.PublicKey:
+		if pub.N.BitLen() < minRSABits {
+			return nil, fmt.Errorf("RSA key has %d bits, need at least %d", pub.N.BitLen(), minRSABits)
+		}
+		key.Method = jwt.SigningMethodRS256
+	case ed25519.PublicKey:
+		key.Method = jwt.SigningMethodEdDSA
+	default:
+		return nil, fmt.Errorf("Unsupported key type %T", parsed)
+	}
+	key.Public = parsed
+	return key, nil
+}
@@ -0,0 +1,187 @@
+package signing
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func encodePrivate(t *testing.T, key any, notBefore string) []byte {
+	t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	block := &pem.Block{Type: "PRIVATE KEY", Bytes: der}
+	if notBefore != "" {
+		block.Headers = map[string]string{NotBeforeHeader: notBefore}
+	}
+	return pem.EncodeToMemory(block)
+}
+
+func encodePublic(t *testing.T, key any) []byte {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+}
+
+func TestParseKey(t *testing.T) {
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	t.Run("ed25519_private", func(t *testing.T) {
+		key, err := ParseKey("ed", encodePrivate(t, edKey, "2026-11-01T00:00:00Z"))
+
+		require.NoError(t, err)
+		assert.Equal(t, jwt.SigningMethodEdDSA, key.Method)
+		assert.NotNil(t, key.Private)
+		assert.Equal(t, time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC), key.NotBefore.UTC())
+	})
+
+	t.Run("rsa_pkcs1_private", func(t *testing.T) {
+		data := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)})
+		key, err := ParseKey("rsa", data)
+
+		require.NoError(t, err)
+		assert.Equal(t, jwt.SigningMethodRS256, key.Method)
+		assert.Equal(t, &rsaKey.PublicKey, key.Public)
+	})
+
+	t.Run("public_only", func(t *testing.T) {
+		key, err := ParseKey("retired", encodePublic(t, edKey.Public()))
+
+		require.NoError(t, err)
+		assert.Nil(t, key.Private)
+		assert.Equal(t, jwt.SigningMethodEdDSA, key.Method)
+	})
+
+	t.Run("short_rsa_rejected", func(t *testing.T) {
+		weak, err := rsa.GenerateKey(rand.Reader, 1024)
+		require.NoError(t, err)
+
+		_, err = ParseKey("weak", encodePrivate(t, weak, ""))
+		assert.Error(t, err)
+	})
+
+	t.Run("bad_not_before_rejected", func(t *testing.T) {
+		_, err := ParseKey("ed", encodePrivate(t, edKey, "next tuesday"))
+		assert.Error(t, err)
+	})
+
+	t.Run("not_pem", func(t *testing.T) {
+		_, err := ParseKey("junk", []byte("secret"))
+		assert.Error(t, err)
+	})
+}
+
+func TestKeySet_SigningKey(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	newKey := func(id string, notBefore time.Time, private bool) *Key {
+		pub, priv, _ := ed25519.GenerateKey(rand.Reader)
+		k := &Key{ID: id, Method: jwt.SigningMethodEdDSA, Public: pub, NotBefore: notBefore}
+		if private {
+			k.Private = priv
+		}
+		return k
+	}
+
+	t.Run("latest_active_key_signs", func(t *testing.T) {
+		ks, err := NewKeySet(
+			newKey("2026-09", now.AddDate(0, -1, 0), true),
+			newKey("2026-10", now.AddDate(0, 0, -1), true),
+			newKey("2026-11", now.AddDate(0, 0, 13), true),
+		)
+		require.NoError(t, err)
+		ks.now = func() time.Time { return now }
+
+		key, err := ks.SigningKey()
+		require.NoError(t, err)
+		assert.Equal(t, "2026-10", key.ID)
+
+		ks.now = func() time.Time { return now.AddDate(0, 0, 14) }
+		key, err = ks.SigningKey()
+		require.NoError(t, err)
+		assert.Equal(t, "2026-11", key.ID)
+	})
+
+	t.Run("public_keys_never_sign", func(t *testing.T) {
+		ks, err := NewKeySet(newKey("retired", time.Time{}, false))
+		require.NoError(t, err)
+
+		_, err = ks.SigningKey()
+		assert.ErrorIs(t, err, ErrNoSigningKey)
+		_, ok := ks.Key("retired")
+		assert.True(t, ok)
+	})
+
+	t.Run("duplicate_ids_rejected", func(t *testing.T) {
+		_, err := NewKeySet(newKey("a", now, true), newKey("a", now, true))
+		assert.Error(t, err)
+	})
+}
+
+func TestKeySet_JWKS(t *testing.T) {
+	edPub, _, _ := ed25519.GenerateKey(rand.Reader)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	ks, err := NewKeySet(
+		&Key{ID: "b-rsa", Method: jwt.SigningMethodRS256, Public: &rsaKey.PublicKey},
+		&Key{ID: "a-ed", Method: jwt.SigningMethodEdDSA, Public: edPub},
+	)
+	require.NoError(t, err)
+
+	set := ks.JWKS()
+
+	require.Len(t, set.Keys, 2)
+	assert.Equal(t, JWK{KeyType: "OKP", KeyID: "a-ed", Use: "sig", Algorithm: "EdDSA", Curve: "Ed25519", X: set.Keys[0].X}, set.Keys[0])
+	assert.Len(t, set.Keys[0].X, 43)
+	assert.Equal(t, "RSA", set.Keys[1].KeyType)
+	assert.Equal(t, "RS256", set.Keys[1].Algorithm)
+	assert.Equal(t, "AQAB", set.Keys[1].E)
+}
+
+func TestKeyring_Reload(t *testing.T) {
+	dir := t.TempDir()
+	_, first, _ := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "first.pem"), encodePrivate(t, first, ""), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README"), []byte("not a key"), 0o600))
+
+	keys, err := NewKeyring(dir)
+	require.NoError(t, err)
+	key, err := keys.SigningKey()
+	require.NoError(t, err)
+	assert.Equal(t, "first", key.ID)
+
+	t.Run("picks_up_new_key", func(t *testing.T) {
+		_, second, _ := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "second.pem"),
+			encodePrivate(t, second, time.Now().Add(-time.Minute).Format(time.RFC3339)), 0o600))
+
+		require.NoError(t, keys.Reload())
+
+		key, err := keys.SigningKey()
+		require.NoError(t, err)
+		assert.Equal(t, "second", key.ID)
+		assert.Len(t, keys.JWKS().Keys, 2)
+	})
+
+	t.Run("broken_file_keeps_previous_keys", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "broken.pem"), []byte("garbage"), 0o600))
+
+		assert.Error(t, keys.Reload())
+
+		_, ok := keys.Key("second")
+		assert.True(t, ok)
+	})
+}